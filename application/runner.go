@@ -0,0 +1,344 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/samber/lo"
+)
+
+// -- Error(s) --
+
+var (
+	// ErrComponentAlreadyRegistered is returned when a component is already registered with the same name.
+	ErrComponentAlreadyRegistered = errors.New("component already registered")
+	// ErrComponentNotFound is returned when a component dependency is not registered.
+	ErrComponentNotFound = errors.New("component not found")
+	// ErrDependencyCycle is returned when component dependencies form a cycle.
+	ErrDependencyCycle = errors.New("component dependency cycle")
+	// ErrRunnerAlreadyStarted is returned when the runner is already started.
+	ErrRunnerAlreadyStarted = errors.New("runner already started")
+)
+
+// - Component -
+
+// A Component is a long-living part of an application (e.g. HTTP servers, stream readers, background workers)
+// requiring to be started and stopped in a coordinated manner.
+type Component interface {
+	// Start starts the component. This routine MUST NOT block until the component finishes its work;
+	// long-running operations must be executed in the background.
+	Start(ctx context.Context) error
+	// Stop stops the component gracefully. The given context carries the shutdown deadline.
+	Stop(ctx context.Context) error
+}
+
+// Hooks is a [Component] implementation using routines as start and stop hooks.
+//
+// Useful to adapt existing components not implementing [Component], e.g.
+//
+//	application.Hooks{
+//		OnStart: func(_ context.Context) error { return readerManager.Start() },
+//		OnStop:  readerManager.Close,
+//	}
+type Hooks struct {
+	OnStart func(ctx context.Context) error
+	OnStop  func(ctx context.Context) error
+}
+
+// compile-time assertion
+var _ Component = (*Hooks)(nil)
+
+// Start executes [Hooks.OnStart], if any.
+func (h Hooks) Start(ctx context.Context) error {
+	if h.OnStart == nil {
+		return nil
+	}
+	return h.OnStart(ctx)
+}
+
+// Stop executes [Hooks.OnStop], if any.
+func (h Hooks) Stop(ctx context.Context) error {
+	if h.OnStop == nil {
+		return nil
+	}
+	return h.OnStop(ctx)
+}
+
+// - Runner -
+
+// RunnerState is the lifecycle state of a [Runner].
+type RunnerState uint8
+
+const (
+	// RunnerIdle the runner has not been started yet.
+	RunnerIdle RunnerState = iota
+	// RunnerStarting the runner is starting its components.
+	RunnerStarting
+	// RunnerRunning all components were started.
+	RunnerRunning
+	// RunnerStopping the runner is stopping its components.
+	RunnerStopping
+	// RunnerStopped all components were stopped.
+	RunnerStopped
+)
+
+// compile-time assertion
+var _ fmt.Stringer = RunnerIdle
+
+var _runnerStateStrings = map[RunnerState]string{
+	RunnerIdle:     "IDLE",
+	RunnerStarting: "STARTING",
+	RunnerRunning:  "RUNNING",
+	RunnerStopping: "STOPPING",
+	RunnerStopped:  "STOPPED",
+}
+
+func (s RunnerState) String() string {
+	return _runnerStateStrings[s]
+}
+
+// Runner is a component managing the lifecycle of a set of named [Component] instances.
+//
+// Components are started in dependency order (see [WithDependsOn]), falling back to registration order,
+// and stopped in reverse order within a shutdown deadline (see [WithRunnerShutdownTimeout]).
+// If a component fails to start, the components already started are stopped.
+//
+// Use [Runner.Run] to start all components and block until a termination signal is received.
+type Runner struct {
+	options runnerOptions
+
+	regMu      sync.Mutex
+	components []runnerComponent
+	started    []runnerComponent
+	state      atomic.Uint32
+}
+
+type runnerComponent struct {
+	name      string
+	component Component
+	dependsOn []string
+}
+
+// NewRunner creates a new [Runner] instance.
+func NewRunner(opts ...RunnerOption) *Runner {
+	options := runnerOptions{}
+	for _, opt := range opts {
+		opt(&options)
+	}
+	options.shutdownTimeout = lo.CoalesceOrEmpty(options.shutdownTimeout, 30*time.Second)
+	if len(options.signals) == 0 {
+		options.signals = []os.Signal{os.Interrupt, syscall.SIGTERM}
+	}
+	return &Runner{
+		options:    options,
+		components: make([]runnerComponent, 0),
+	}
+}
+
+// Register registers a [Component] identified by `name`.
+//
+// Use [ComponentOption] routines (e.g. [WithDependsOn]) to configure how the component is managed.
+//
+// This routine must be called before [Runner.Start] is called, otherwise it returns [ErrRunnerAlreadyStarted].
+func (r *Runner) Register(name string, component Component, opts ...ComponentOption) error {
+	r.regMu.Lock()
+	defer r.regMu.Unlock()
+	if r.State() != RunnerIdle {
+		return ErrRunnerAlreadyStarted
+	}
+	for _, c := range r.components {
+		if c.name == name {
+			return fmt.Errorf("%w: %s", ErrComponentAlreadyRegistered, name)
+		}
+	}
+
+	options := componentOptions{}
+	for _, opt := range opts {
+		opt(&options)
+	}
+	r.components = append(r.components, runnerComponent{
+		name:      name,
+		component: component,
+		dependsOn: options.dependsOn,
+	})
+	return nil
+}
+
+// MustRegister registers a [Component] identified by `name`.
+//
+// This routine will panic if any error occurs.
+func (r *Runner) MustRegister(name string, component Component, opts ...ComponentOption) {
+	if err := r.Register(name, component, opts...); err != nil {
+		panic(err)
+	}
+}
+
+// State returns the current [RunnerState].
+func (r *Runner) State() RunnerState {
+	return RunnerState(r.state.Load())
+}
+
+// IsReady indicates whether all registered components were started and the runner is not shutting down.
+func (r *Runner) IsReady() bool {
+	return r.State() == RunnerRunning
+}
+
+// Run starts all registered components and blocks until `ctx` is done or a termination signal is received
+// (see [WithRunnerSignals]). Then, it stops all components.
+func (r *Runner) Run(ctx context.Context) error {
+	if err := r.Start(ctx); err != nil {
+		return err
+	}
+	sigCtx, stop := signal.NotifyContext(ctx, r.options.signals...)
+	defer stop()
+	<-sigCtx.Done()
+
+	// use a detached context as `ctx` might be already done
+	shutdownCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), r.options.shutdownTimeout)
+	defer cancel()
+	return r.Stop(shutdownCtx)
+}
+
+// Start starts all registered components in dependency order.
+//
+// If a component fails to start, all components already started will be stopped in reverse order.
+func (r *Runner) Start(ctx context.Context) error {
+	r.regMu.Lock()
+	defer r.regMu.Unlock()
+	if !r.state.CompareAndSwap(uint32(RunnerIdle), uint32(RunnerStarting)) {
+		return ErrRunnerAlreadyStarted
+	}
+
+	ordered, err := sortComponents(r.components)
+	if err != nil {
+		r.state.Store(uint32(RunnerStopped))
+		return err
+	}
+	r.started = make([]runnerComponent, 0, len(ordered))
+	for _, c := range ordered {
+		if errStart := c.component.Start(ctx); errStart != nil {
+			err = fmt.Errorf("failed to start component %s: %w", c.name, errStart)
+			shutdownCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), r.options.shutdownTimeout)
+			defer cancel()
+			return errors.Join(err, r.stop(shutdownCtx))
+		}
+		r.started = append(r.started, c)
+	}
+	r.state.Store(uint32(RunnerRunning))
+	return nil
+}
+
+// Stop stops all started components in reverse order.
+//
+// Every component is stopped even if a previous one failed; errors are aggregated using [errors.Join].
+func (r *Runner) Stop(ctx context.Context) error {
+	r.regMu.Lock()
+	defer r.regMu.Unlock()
+	if r.State() != RunnerRunning {
+		return nil
+	}
+	return r.stop(ctx)
+}
+
+func (r *Runner) stop(ctx context.Context) error {
+	r.state.Store(uint32(RunnerStopping))
+	errs := make([]error, 0)
+	for i := len(r.started) - 1; i >= 0; i-- {
+		if err := r.started[i].component.Stop(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("failed to stop component %s: %w", r.started[i].name, err))
+		}
+	}
+	r.started = nil
+	r.state.Store(uint32(RunnerStopped))
+	return errors.Join(errs...)
+}
+
+// sortComponents sorts `components` topologically based on their dependencies, keeping registration order
+// among independent components.
+func sortComponents(components []runnerComponent) ([]runnerComponent, error) {
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	index := make(map[string]int, len(components))
+	for i, c := range components {
+		index[c.name] = i
+	}
+	marks := make([]int, len(components))
+	ordered := make([]runnerComponent, 0, len(components))
+	var visit func(i int) error
+	visit = func(i int) error {
+		switch marks[i] {
+		case visited:
+			return nil
+		case visiting:
+			return fmt.Errorf("%w: %s", ErrDependencyCycle, components[i].name)
+		}
+		marks[i] = visiting
+		for _, dep := range components[i].dependsOn {
+			depIdx, ok := index[dep]
+			if !ok {
+				return fmt.Errorf("%w: %s (required by %s)", ErrComponentNotFound, dep, components[i].name)
+			}
+			if err := visit(depIdx); err != nil {
+				return err
+			}
+		}
+		marks[i] = visited
+		ordered = append(ordered, components[i])
+		return nil
+	}
+	for i := range components {
+		if err := visit(i); err != nil {
+			return nil, err
+		}
+	}
+	return ordered, nil
+}
+
+// -- Options --
+
+type runnerOptions struct {
+	shutdownTimeout time.Duration
+	signals         []os.Signal
+}
+
+// RunnerOption is a routine used to set up [Runner] optional configuration.
+type RunnerOption func(*runnerOptions)
+
+// WithRunnerShutdownTimeout sets the deadline for stopping all components. Defaults to 30 seconds.
+func WithRunnerShutdownTimeout(timeout time.Duration) RunnerOption {
+	return func(o *runnerOptions) {
+		o.shutdownTimeout = timeout
+	}
+}
+
+// WithRunnerSignals sets the OS signals triggering the shutdown on [Runner.Run].
+// Defaults to [os.Interrupt] and [syscall.SIGTERM].
+func WithRunnerSignals(signals ...os.Signal) RunnerOption {
+	return func(o *runnerOptions) {
+		o.signals = signals
+	}
+}
+
+type componentOptions struct {
+	dependsOn []string
+}
+
+// ComponentOption is a routine used to set up optional configuration of a [Component] registered in a [Runner].
+type ComponentOption func(*componentOptions)
+
+// WithDependsOn sets the names of the components required to be started before the registered component.
+func WithDependsOn(names ...string) ComponentOption {
+	return func(o *componentOptions) {
+		o.dependsOn = append(o.dependsOn, names...)
+	}
+}
@@ -0,0 +1,97 @@
+package application_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bosonicalio/geck/application"
+)
+
+func newRecorderComponent(name string, calls *[]string, startErr, stopErr error) application.Component {
+	return application.Hooks{
+		OnStart: func(_ context.Context) error {
+			*calls = append(*calls, "start:"+name)
+			return startErr
+		},
+		OnStop: func(_ context.Context) error {
+			*calls = append(*calls, "stop:"+name)
+			return stopErr
+		},
+	}
+}
+
+func TestRunner(t *testing.T) {
+	ctx := context.Background()
+	calls := make([]string, 0)
+	runner := application.NewRunner()
+	runner.MustRegister("http", newRecorderComponent("http", &calls, nil, nil),
+		application.WithDependsOn("database", "kafka"))
+	runner.MustRegister("kafka", newRecorderComponent("kafka", &calls, nil, nil))
+	runner.MustRegister("database", newRecorderComponent("database", &calls, nil, nil))
+	err := runner.Register("kafka", newRecorderComponent("kafka", &calls, nil, nil))
+	assert.ErrorIs(t, err, application.ErrComponentAlreadyRegistered)
+
+	assert.Equal(t, application.RunnerIdle, runner.State())
+	assert.False(t, runner.IsReady())
+	require.NoError(t, runner.Start(ctx))
+	assert.True(t, runner.IsReady())
+	assert.ErrorIs(t, runner.Start(ctx), application.ErrRunnerAlreadyStarted)
+
+	require.NoError(t, runner.Stop(ctx))
+	assert.Equal(t, application.RunnerStopped, runner.State())
+	assert.False(t, runner.IsReady())
+	assert.Equal(t, []string{
+		"start:database", "start:kafka", "start:http",
+		"stop:http", "stop:kafka", "stop:database",
+	}, calls)
+}
+
+func TestRunner_StartFailure(t *testing.T) {
+	ctx := context.Background()
+	calls := make([]string, 0)
+	errStart := errors.New("start failure")
+	errStop := errors.New("stop failure")
+	runner := application.NewRunner()
+	runner.MustRegister("database", newRecorderComponent("database", &calls, nil, errStop))
+	runner.MustRegister("kafka", newRecorderComponent("kafka", &calls, errStart, nil))
+	runner.MustRegister("http", newRecorderComponent("http", &calls, nil, nil))
+
+	err := runner.Start(ctx)
+	assert.ErrorIs(t, err, errStart)
+	assert.ErrorIs(t, err, errStop)
+	assert.Equal(t, application.RunnerStopped, runner.State())
+	assert.Equal(t, []string{"start:database", "start:kafka", "stop:database"}, calls)
+}
+
+func TestRunner_Dependencies(t *testing.T) {
+	ctx := context.Background()
+	runner := application.NewRunner()
+	runner.MustRegister("a", application.Hooks{}, application.WithDependsOn("b"))
+	runner.MustRegister("b", application.Hooks{}, application.WithDependsOn("a"))
+	assert.ErrorIs(t, runner.Start(ctx), application.ErrDependencyCycle)
+
+	runner = application.NewRunner()
+	runner.MustRegister("a", application.Hooks{}, application.WithDependsOn("c"))
+	assert.ErrorIs(t, runner.Start(ctx), application.ErrComponentNotFound)
+}
+
+func TestRunner_Run(t *testing.T) {
+	calls := make([]string, 0)
+	runner := application.NewRunner(application.WithRunnerShutdownTimeout(time.Second))
+	runner.MustRegister("worker", newRecorderComponent("worker", &calls, nil, nil))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- runner.Run(ctx)
+	}()
+	assert.Eventually(t, runner.IsReady, time.Second, time.Millisecond)
+	cancel()
+	assert.NoError(t, <-errCh)
+	assert.Equal(t, []string{"start:worker", "stop:worker"}, calls)
+}
@@ -9,6 +9,7 @@ import (
 	"github.com/samber/lo"
 
 	"github.com/bosonicalio/geck/blob"
+	"github.com/bosonicalio/geck/observability/health"
 )
 
 // Bucket is the Amazon Simple Storage Service (S3) implementation of [blob.Bucket].
@@ -20,7 +21,8 @@ type Bucket struct {
 
 var (
 	// compile-time assertions
-	_ blob.Bucket = (*Bucket)(nil)
+	_ blob.Bucket    = (*Bucket)(nil)
+	_ health.Checker = (*Bucket)(nil)
 )
 
 // NewBucket creates a new S3 bucket instance with the provided name, client, and uploader.
@@ -48,3 +50,13 @@ func (b Bucket) Remove(ctx context.Context, key string) error {
 	})
 	return err
 }
+
+// Check verifies the bucket exists and the caller has permission to access it.
+//
+// Implements [health.Checker].
+func (b Bucket) Check(ctx context.Context) error {
+	_, err := b.client.HeadBucket(ctx, &s3.HeadBucketInput{
+		Bucket: lo.EmptyableToPtr(b.name),
+	})
+	return err
+}
@@ -8,6 +8,7 @@ require (
 	github.com/aws/aws-sdk-go-v2 v1.37.1
 	github.com/aws/aws-sdk-go-v2/config v1.30.2
 	github.com/aws/aws-sdk-go-v2/credentials v1.18.2
+	github.com/aws/aws-sdk-go-v2/service/kms v1.38.3
 	github.com/bosonicalio/geck v0.1.19
 	github.com/samber/lo v1.51.0
 	github.com/stretchr/testify v1.10.0
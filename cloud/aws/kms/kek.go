@@ -0,0 +1,56 @@
+package kms
+
+import (
+	"context"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/kms"
+
+	"github.com/bosonicalio/geck/security/cryptox"
+)
+
+// KEKProvider is the AWS Key Management Service (KMS) implementation of [cryptox.KEKProvider].
+//
+// KEK IDs are KMS key IDs, ARNs or alias names. Data keys are encrypted using the KEK ID as encryption context, so
+// wrapped keys cannot be unwrapped with a different KEK ID.
+type KEKProvider struct {
+	client *kms.Client
+}
+
+// compile-time assertion
+var _ cryptox.KEKProvider = KEKProvider{}
+
+// NewKEKProvider creates a new [KEKProvider] instance.
+func NewKEKProvider(client *kms.Client) KEKProvider {
+	return KEKProvider{client: client}
+}
+
+// WrapKey encrypts `dataKey` using the KMS key identified by `kekID`.
+func (p KEKProvider) WrapKey(ctx context.Context, kekID string, dataKey []byte) ([]byte, error) {
+	out, err := p.client.Encrypt(ctx, &kms.EncryptInput{
+		KeyId:             aws.String(kekID),
+		Plaintext:         dataKey,
+		EncryptionContext: encryptionContext(kekID),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return out.CiphertextBlob, nil
+}
+
+// UnwrapKey decrypts `wrappedKey` using the KMS key identified by `kekID`.
+func (p KEKProvider) UnwrapKey(ctx context.Context, kekID string, wrappedKey []byte) ([]byte, error) {
+	out, err := p.client.Decrypt(ctx, &kms.DecryptInput{
+		KeyId:             aws.String(kekID),
+		CiphertextBlob:    wrappedKey,
+		EncryptionContext: encryptionContext(kekID),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return out.Plaintext, nil
+}
+
+func encryptionContext(kekID string) map[string]string {
+	return map[string]string{"geck:kek": kekID}
+}
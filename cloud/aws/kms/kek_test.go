@@ -0,0 +1,41 @@
+package kms_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/kms"
+	"github.com/samber/lo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bosonicalio/geck/cloud/aws/awstest"
+	geckkms "github.com/bosonicalio/geck/cloud/aws/kms"
+	"github.com/bosonicalio/geck/security/cryptox"
+)
+
+func TestKEKProvider(t *testing.T) {
+	ctx := context.Background()
+	pod, err := awstest.NewPod(ctx,
+		awstest.WithPodImageTag("4.6.0"),
+		awstest.WithPodServices("kms"),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = pod.Close()
+	})
+
+	client := kms.NewFromConfig(pod.Config())
+	key, err := client.CreateKey(ctx, &kms.CreateKeyInput{})
+	require.NoError(t, err)
+	kekID := lo.FromPtr(key.KeyMetadata.KeyId)
+
+	keyring, err := cryptox.NewKeyring(geckkms.NewKEKProvider(client),
+		cryptox.Key{Name: "users", Version: 1, KEKID: kekID})
+	require.NoError(t, err)
+	ciphertext, err := keyring.Encrypt(ctx, "users", []byte("secret"), []byte("user-123"))
+	require.NoError(t, err)
+	plaintext, err := keyring.Decrypt(ctx, ciphertext, []byte("user-123"))
+	require.NoError(t, err)
+	assert.Equal(t, []byte("secret"), plaintext)
+}
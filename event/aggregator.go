@@ -6,7 +6,7 @@ package event
 type Aggregator interface {
 	// RegisterEvents registers the given events into the aggregator.
 	RegisterEvents(events ...Event)
-	// PullEvents returns the events registered in the aggregator.
+	// PullEvents returns the events registered in the aggregator, removing them from the aggregator.
 	PullEvents() []Event
 }
 
@@ -26,7 +26,15 @@ func (t *AggregatorTemplate) RegisterEvents(events ...Event) {
 	t.events = append(t.events, events...)
 }
 
-// PullEvents returns the events registered in the aggregator.
-func (t AggregatorTemplate) PullEvents() []Event {
+// PullEvents returns the events registered in the aggregator, removing them from the aggregator so they
+// are not pulled (e.g. published) twice.
+func (t *AggregatorTemplate) PullEvents() []Event {
+	events := t.events
+	t.events = nil
+	return events
+}
+
+// PendingEvents returns the events registered in the aggregator without removing them.
+func (t *AggregatorTemplate) PendingEvents() []Event {
 	return t.events
 }
@@ -0,0 +1,233 @@
+package event
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/bosonicalio/geck/transport"
+	"github.com/bosonicalio/geck/transport/stream"
+)
+
+// ContentModeStructuredMimeType is the content MIME type of events written using [ContentModeStructured].
+const ContentModeStructuredMimeType = "application/cloudevents+json"
+
+// Header keys defined by the Cloud Events Kafka protocol binding, used by [ContentModeBinary].
+//
+// Keys are lowercase as defined by the specification; hence, they must be set into [stream.Header] directly
+// instead of using [stream.Header.Set] (which canonicalizes keys).
+const (
+	HeaderCloudEventsID          = "ce_id"
+	HeaderCloudEventsSource      = "ce_source"
+	HeaderCloudEventsSpecVersion = "ce_specversion"
+	HeaderCloudEventsType        = "ce_type"
+	HeaderCloudEventsDataSchema  = "ce_dataschema"
+	HeaderCloudEventsSubject     = "ce_subject"
+	HeaderCloudEventsTime        = "ce_time"
+	HeaderContentType            = "content-type"
+)
+
+// ContentMode is the way Cloud Events attributes and data are written into stream messages.
+type ContentMode uint8
+
+const (
+	// ContentModeHeader attributes are written as message headers using the keys defined by this package
+	// (e.g. [HeaderEventID]) and data is written as the message body.
+	ContentModeHeader ContentMode = iota
+	// ContentModeBinary attributes are written as message headers using the `ce_` prefixed keys defined by the
+	// Cloud Events Kafka protocol binding (e.g. [HeaderCloudEventsID]) and data is written as the message body.
+	ContentModeBinary
+	// ContentModeStructured attributes and data are written as a single JSON document
+	// ([ContentModeStructuredMimeType]) in the message body.
+	ContentModeStructured
+)
+
+// structuredEvent is the JSON document written by [ContentModeStructured].
+type structuredEvent struct {
+	SpecVersion     string          `json:"specversion"`
+	ID              string          `json:"id"`
+	Source          string          `json:"source"`
+	Type            string          `json:"type"`
+	DataContentType string          `json:"datacontenttype,omitempty"`
+	DataSchema      string          `json:"dataschema,omitempty"`
+	Subject         string          `json:"subject,omitempty"`
+	Time            string          `json:"time,omitempty"`
+	Data            json.RawMessage `json:"data,omitempty"`
+	DataBase64      []byte          `json:"data_base64,omitempty"`
+}
+
+// newStreamMessage converts `event` into a [stream.Message] identified by `id`, writing Cloud Events attributes
+// using `mode`.
+func newStreamMessage(id string, event Event, mode ContentMode) (stream.Message, error) {
+	data, err := event.Bytes()
+	if err != nil {
+		return stream.Message{}, err
+	}
+	msg := stream.Message{
+		Key: event.Key(),
+	}
+	occurrenceTime := event.OccurrenceTime().Format(time.RFC3339)
+	switch mode {
+	case ContentModeBinary:
+		msg.Header = stream.Header{
+			HeaderCloudEventsID:          {id},
+			HeaderCloudEventsSource:      {event.Source()},
+			HeaderCloudEventsSpecVersion: {CloudEventsCurrentSpecVersion},
+			HeaderCloudEventsType:        {event.Topic().String()},
+			HeaderCloudEventsTime:        {occurrenceTime},
+			HeaderContentType:            {event.BytesContentType().String()},
+		}
+		if schema := event.SchemaSource(); schema != "" {
+			msg.Header[HeaderCloudEventsDataSchema] = []string{schema}
+		}
+		if subject := event.Subject(); subject != "" {
+			msg.Header[HeaderCloudEventsSubject] = []string{subject}
+		}
+		msg.Data = data
+	case ContentModeStructured:
+		doc := structuredEvent{
+			SpecVersion:     CloudEventsCurrentSpecVersion,
+			ID:              id,
+			Source:          event.Source(),
+			Type:            event.Topic().String(),
+			DataContentType: event.BytesContentType().String(),
+			DataSchema:      event.SchemaSource(),
+			Subject:         event.Subject(),
+			Time:            occurrenceTime,
+		}
+		if event.BytesContentType() == transport.MimeTypeJSON {
+			doc.Data = data
+		} else {
+			doc.DataBase64 = data
+		}
+		msg.Data, err = json.Marshal(doc)
+		if err != nil {
+			return stream.Message{}, err
+		}
+		msg.Header = stream.Header{
+			HeaderContentType: {ContentModeStructuredMimeType},
+		}
+	default:
+		const totalHeaders = 8
+		msg.Header = make(stream.Header, totalHeaders)
+		msg.Header.Add(HeaderEventID, id)
+		msg.Header.Add(HeaderSource, event.Source())
+		msg.Header.Add(HeaderSpecVersion, CloudEventsCurrentSpecVersion)
+		msg.Header.Add(HeaderEventType, event.Topic().String())
+		msg.Header.Add(HeaderDataContentType, event.BytesContentType().String())
+		msg.Header.Add(HeaderDataSchema, event.SchemaSource())
+		msg.Header.Add(HeaderSubject, event.Subject())
+		msg.Header.Add(HeaderEventTime, occurrenceTime)
+		msg.Data = data
+	}
+	return msg, nil
+}
+
+// ParseMessage parses the Cloud Events attributes and data of `msg`, detecting the [ContentMode] it was
+// written with.
+//
+// Returns [ErrMissingAttribute] if either the event identifier or type is missing.
+func ParseMessage(msg stream.Message) (Attributes, []byte, error) {
+	contentType := getHeader(msg.Header, HeaderContentType)
+	if !strings.HasPrefix(contentType, ContentModeStructuredMimeType) {
+		attrs, err := ParseAttributes(msg.Header)
+		return attrs, msg.Data, err
+	}
+
+	doc := structuredEvent{}
+	if err := json.Unmarshal(msg.Data, &doc); err != nil {
+		return Attributes{}, nil, err
+	}
+	attrs := Attributes{
+		ID:              doc.ID,
+		Source:          doc.Source,
+		SpecVersion:     doc.SpecVersion,
+		Type:            doc.Type,
+		DataContentType: transport.NewMimeType(doc.DataContentType),
+		DataSchema:      doc.DataSchema,
+		Subject:         doc.Subject,
+	}
+	if doc.DataContentType == "" {
+		// as defined by the Cloud Events JSON format specification
+		attrs.DataContentType = transport.MimeTypeJSON
+	}
+	if err := validateAttributes(attrs, "id", "type"); err != nil {
+		return attrs, nil, err
+	}
+	occurrenceTime, err := parseEventTime(doc.Time)
+	if err != nil {
+		return attrs, nil, err
+	}
+	attrs.Time = occurrenceTime
+	if len(doc.DataBase64) > 0 {
+		return attrs, doc.DataBase64, nil
+	}
+	return attrs, doc.Data, nil
+}
+
+// ParseAttributes parses the Cloud Events attributes written as message headers, using either
+// [ContentModeHeader] or [ContentModeBinary].
+//
+// Returns [ErrMissingAttribute] if either the event identifier or type is missing.
+func ParseAttributes(header stream.Header) (Attributes, error) {
+	if getHeader(header, HeaderCloudEventsID) == "" {
+		attrs := Attributes{
+			ID:              header.Get(HeaderEventID),
+			Source:          header.Get(HeaderSource),
+			SpecVersion:     header.Get(HeaderSpecVersion),
+			Type:            header.Get(HeaderEventType),
+			DataContentType: transport.NewMimeType(header.Get(HeaderDataContentType)),
+			DataSchema:      header.Get(HeaderDataSchema),
+			Subject:         header.Get(HeaderSubject),
+		}
+		if err := validateAttributes(attrs, HeaderEventID, HeaderEventType); err != nil {
+			return attrs, err
+		}
+		occurrenceTime, err := parseEventTime(header.Get(HeaderEventTime))
+		attrs.Time = occurrenceTime
+		return attrs, err
+	}
+
+	contentType, _, _ := strings.Cut(getHeader(header, HeaderContentType), ";")
+	attrs := Attributes{
+		ID:              getHeader(header, HeaderCloudEventsID),
+		Source:          getHeader(header, HeaderCloudEventsSource),
+		SpecVersion:     getHeader(header, HeaderCloudEventsSpecVersion),
+		Type:            getHeader(header, HeaderCloudEventsType),
+		DataContentType: transport.NewMimeType(strings.TrimSpace(contentType)),
+		DataSchema:      getHeader(header, HeaderCloudEventsDataSchema),
+		Subject:         getHeader(header, HeaderCloudEventsSubject),
+	}
+	if err := validateAttributes(attrs, HeaderCloudEventsID, HeaderCloudEventsType); err != nil {
+		return attrs, err
+	}
+	occurrenceTime, err := parseEventTime(getHeader(header, HeaderCloudEventsTime))
+	attrs.Time = occurrenceTime
+	return attrs, err
+}
+
+// getHeader returns the value of `key` as written (e.g. lowercase `ce_` keys), falling back to its canonical
+// form (e.g. headers parsed using [stream.Header.Add]).
+func getHeader(header stream.Header, key string) string {
+	if values := header[key]; len(values) > 0 {
+		return values[0]
+	}
+	return header.Get(key)
+}
+
+func validateAttributes(attrs Attributes, idKey, typeKey string) error {
+	if attrs.ID == "" {
+		return fmt.Errorf("%w: %s", ErrMissingAttribute, idKey)
+	} else if attrs.Type == "" {
+		return fmt.Errorf("%w: %s", ErrMissingAttribute, typeKey)
+	}
+	return nil
+}
+
+func parseEventTime(v string) (time.Time, error) {
+	if v == "" {
+		return time.Time{}, nil
+	}
+	return time.Parse(time.RFC3339, v)
+}
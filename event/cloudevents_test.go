@@ -0,0 +1,119 @@
+package event_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bosonicalio/geck/event"
+	"github.com/bosonicalio/geck/persistence/identifier"
+	"github.com/bosonicalio/geck/transport"
+	"github.com/bosonicalio/geck/transport/stream"
+	"github.com/bosonicalio/geck/transport/stream/memstream"
+)
+
+func TestStreamPublisher_ContentMode(t *testing.T) {
+	tests := []struct {
+		name      string
+		mode      event.ContentMode
+		assertMsg func(t *testing.T, msg stream.Message)
+	}{
+		{
+			name: "header",
+			mode: event.ContentModeHeader,
+			assertMsg: func(t *testing.T, msg stream.Message) {
+				assert.NotEmpty(t, msg.Header.Get(event.HeaderEventID))
+				assert.JSONEq(t, `{"user_id":"123","name":"John"}`, string(msg.Data))
+			},
+		},
+		{
+			name: "binary",
+			mode: event.ContentModeBinary,
+			assertMsg: func(t *testing.T, msg stream.Message) {
+				assert.NotEmpty(t, msg.Header[event.HeaderCloudEventsID])
+				assert.Equal(t, []string{"acme-corp.user.created"}, msg.Header[event.HeaderCloudEventsType])
+				assert.Equal(t, []string{"application/json"}, msg.Header[event.HeaderContentType])
+				assert.NotContains(t, msg.Header, event.HeaderCloudEventsDataSchema)
+				assert.JSONEq(t, `{"user_id":"123","name":"John"}`, string(msg.Data))
+			},
+		},
+		{
+			name: "structured",
+			mode: event.ContentModeStructured,
+			assertMsg: func(t *testing.T, msg stream.Message) {
+				assert.Equal(t, []string{event.ContentModeStructuredMimeType}, msg.Header[event.HeaderContentType])
+				doc := map[string]any{}
+				require.NoError(t, json.Unmarshal(msg.Data, &doc))
+				assert.Equal(t, "1.0", doc["specversion"])
+				assert.Equal(t, "acme-corp.user.created", doc["type"])
+				assert.Equal(t, "/acme-corp/users", doc["source"])
+				assert.Equal(t, "2025-01-01T00:00:00Z", doc["time"])
+				assert.Equal(t, map[string]any{"user_id": "123", "name": "John"}, doc["data"])
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			broker := memstream.NewBroker()
+			publisher := event.NewStreamPublisher(broker, identifier.FactoryKSUID{}, event.WithContentMode(tt.mode))
+			require.NoError(t, publisher.Publish(ctx, []event.Event{userCreated{UserID: "123", Name: "John"}}))
+			records := memstream.RequireRecords(t, broker, "acme-corp.user.created", 1, time.Second)
+			msg := stream.Message{
+				Key:    records[0].Key,
+				Header: records[0].Header,
+				Data:   records[0].Data,
+			}
+			tt.assertMsg(t, msg)
+
+			// headers may be canonicalized by readers (e.g. kafka.ParseHeaders)
+			canonical := make(stream.Header, len(msg.Header))
+			for k, values := range msg.Header {
+				for _, v := range values {
+					canonical.Add(k, v)
+				}
+			}
+			for _, header := range []stream.Header{msg.Header, canonical} {
+				attrs, data, err := event.ParseMessage(stream.Message{Header: header, Data: msg.Data})
+				require.NoError(t, err)
+				assert.NotEmpty(t, attrs.ID)
+				assert.Equal(t, "acme-corp.user.created", attrs.Type)
+				assert.Equal(t, "/acme-corp/users", attrs.Source)
+				assert.Equal(t, "123", attrs.Subject)
+				assert.Equal(t, event.CloudEventsCurrentSpecVersion, attrs.SpecVersion)
+				assert.Equal(t, transport.MimeTypeJSON, attrs.DataContentType)
+				assert.Equal(t, userCreated{}.OccurrenceTime(), attrs.Time)
+				assert.JSONEq(t, `{"user_id":"123","name":"John"}`, string(data))
+			}
+		})
+	}
+}
+
+func TestParseMessage(t *testing.T) {
+	t.Run("structured base64 data", func(t *testing.T) {
+		attrs, data, err := event.ParseMessage(stream.Message{
+			Header: stream.Header{event.HeaderContentType: {"application/cloudevents+json; charset=utf-8"}},
+			Data:   []byte(`{"specversion":"1.0","id":"1","type":"foo","source":"/bar","datacontenttype":"application/msgpack","data_base64":"AQID"}`),
+		})
+		require.NoError(t, err)
+		assert.Equal(t, transport.MimeTypeMsgPack, attrs.DataContentType)
+		assert.Equal(t, []byte{1, 2, 3}, data)
+	})
+
+	t.Run("missing attributes", func(t *testing.T) {
+		_, _, err := event.ParseMessage(stream.Message{
+			Header: stream.Header{event.HeaderContentType: {event.ContentModeStructuredMimeType}},
+			Data:   []byte(`{"specversion":"1.0","id":"1"}`),
+		})
+		assert.ErrorIs(t, err, event.ErrMissingAttribute)
+
+		_, _, err = event.ParseMessage(stream.Message{
+			Header: stream.Header{event.HeaderCloudEventsID: {"1"}},
+		})
+		assert.ErrorIs(t, err, event.ErrMissingAttribute)
+	})
+}
@@ -0,0 +1,54 @@
+package eventstore
+
+import (
+	"github.com/bosonicalio/geck/event"
+	"github.com/bosonicalio/geck/persistence"
+	"github.com/bosonicalio/geck/persistence/audit"
+)
+
+// An Aggregate is an entity which state is derived from the sequence of events it produced (event sourcing).
+//
+// Embed [Base] into your aggregates to implement most of this interface.
+type Aggregate interface {
+	persistence.Storable
+	event.Aggregator
+	// PendingEvents returns the registered events without removing them (see [event.Aggregator.PullEvents]).
+	PendingEvents() []event.Event
+	// AggregateID returns the identifier of the aggregate, the identifier of its event stream.
+	AggregateID() string
+	// Apply mutates the aggregate state based on `e`. This routine is called both when events are raised
+	// (see [Raise]) and when the aggregate is rehydrated from its event stream, so it MUST NOT have side effects.
+	Apply(e event.Event) error
+	// AggregateVersion returns the number of events persisted for the aggregate.
+	AggregateVersion() int64
+	// SetAggregateVersion sets the number of events persisted for the aggregate.
+	SetAggregateVersion(version int64)
+}
+
+// Base is a partial implementation of [Aggregate] to be embedded into concrete aggregates.
+//
+// It follows the [audit.Auditable] version semantics: a zero version indicates the aggregate is new
+// (i.e. no events were persisted), then, the version is increased by one for every event persisted.
+type Base struct {
+	audit.Auditable
+	event.AggregatorTemplate
+}
+
+// AggregateVersion returns the number of events persisted for the aggregate.
+func (b *Base) AggregateVersion() int64 {
+	return b.Version
+}
+
+// SetAggregateVersion sets the number of events persisted for the aggregate.
+func (b *Base) SetAggregateVersion(version int64) {
+	b.Version = version
+}
+
+// Raise applies `e` to `aggregate` and registers it as a pending event to be persisted.
+func Raise(aggregate Aggregate, e event.Event) error {
+	if err := aggregate.Apply(e); err != nil {
+		return err
+	}
+	aggregate.RegisterEvents(e)
+	return nil
+}
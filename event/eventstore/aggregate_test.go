@@ -0,0 +1,147 @@
+package eventstore_test
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bosonicalio/geck/event"
+	"github.com/bosonicalio/geck/event/eventstore"
+	"github.com/bosonicalio/geck/transport"
+)
+
+type amountDeposited struct {
+	AccountID string `json:"account_id"`
+	Amount    int64  `json:"amount"`
+}
+
+var _ event.Event = (*amountDeposited)(nil)
+
+func (a amountDeposited) Topic() event.Topic {
+	return event.NewTopic("acme-corp", "account", "deposited")
+}
+
+func (a amountDeposited) Key() string {
+	return a.AccountID
+}
+
+func (a amountDeposited) Bytes() ([]byte, error) {
+	return json.Marshal(a)
+}
+
+func (a amountDeposited) BytesContentType() transport.MimeType {
+	return transport.MimeTypeJSON
+}
+
+func (a amountDeposited) Source() string {
+	return "/acme-corp/accounts"
+}
+
+func (a amountDeposited) Subject() string {
+	return a.AccountID
+}
+
+func (a amountDeposited) OccurrenceTime() time.Time {
+	return time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+}
+
+func (a amountDeposited) SchemaSource() string {
+	return ""
+}
+
+// amountWithdrawn implements event.Event using pointer receivers.
+type amountWithdrawn struct {
+	AccountID string `json:"account_id"`
+	Amount    int64  `json:"amount"`
+}
+
+var _ event.Event = (*amountWithdrawn)(nil)
+
+func (a *amountWithdrawn) Topic() event.Topic {
+	return event.NewTopic("acme-corp", "account", "withdrawn")
+}
+
+func (a *amountWithdrawn) Key() string {
+	return a.AccountID
+}
+
+func (a *amountWithdrawn) Bytes() ([]byte, error) {
+	return json.Marshal(a)
+}
+
+func (a *amountWithdrawn) BytesContentType() transport.MimeType {
+	return transport.MimeTypeJSON
+}
+
+func (a *amountWithdrawn) Source() string {
+	return "/acme-corp/accounts"
+}
+
+func (a *amountWithdrawn) Subject() string {
+	return a.AccountID
+}
+
+func (a *amountWithdrawn) OccurrenceTime() time.Time {
+	return time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+}
+
+func (a *amountWithdrawn) SchemaSource() string {
+	return ""
+}
+
+type account struct {
+	eventstore.Base
+	ID      string `json:"id"`
+	Balance int64  `json:"balance"`
+}
+
+var _ eventstore.Aggregate = (*account)(nil)
+
+func (a *account) AggregateID() string {
+	return a.ID
+}
+
+func (a *account) Apply(e event.Event) error {
+	switch ev := e.(type) {
+	case amountDeposited:
+		if ev.Amount <= 0 {
+			return errors.New("invalid amount")
+		}
+		a.ID = ev.AccountID
+		a.Balance += ev.Amount
+		return nil
+	case *amountWithdrawn:
+		a.Balance -= ev.Amount
+		return nil
+	default:
+		return errors.New("unknown event")
+	}
+}
+
+func TestRaise(t *testing.T) {
+	acc := &account{}
+	assert.True(t, acc.IsNew())
+	require.NoError(t, eventstore.Raise(acc, amountDeposited{AccountID: "123", Amount: 10}))
+	require.NoError(t, eventstore.Raise(acc, amountDeposited{AccountID: "123", Amount: 5}))
+	assert.Error(t, eventstore.Raise(acc, amountDeposited{AccountID: "123", Amount: -1}))
+	assert.Equal(t, int64(15), acc.Balance)
+	assert.Equal(t, "123", acc.AggregateID())
+
+	assert.Len(t, acc.PullEvents(), 2)
+	assert.Empty(t, acc.PullEvents())
+
+	acc.SetAggregateVersion(2)
+	assert.Equal(t, int64(2), acc.AggregateVersion())
+	assert.False(t, acc.IsNew())
+}
+
+func TestMigrations(t *testing.T) {
+	files, err := fs.Glob(eventstore.Migrations, "migration/*.sql")
+	require.NoError(t, err)
+	assert.NotEmpty(t, files)
+}
@@ -0,0 +1,31 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS event_store (
+    aggregate_id VARCHAR(255) NOT NULL,
+    version BIGINT NOT NULL,
+    event_type VARCHAR(255) NOT NULL,
+    content_type VARCHAR(255) NOT NULL,
+    data BYTEA,
+    occurrence_time TIMESTAMPTZ NOT NULL,
+    create_time TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (aggregate_id, version)
+);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS event_snapshot (
+    aggregate_id VARCHAR(255) PRIMARY KEY,
+    version BIGINT NOT NULL,
+    data BYTEA NOT NULL,
+    create_time TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS event_snapshot;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+DROP TABLE IF EXISTS event_store;
+-- +goose StatementEnd
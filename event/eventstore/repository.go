@@ -0,0 +1,330 @@
+package eventstore
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/samber/lo"
+
+	"github.com/bosonicalio/geck/event"
+	"github.com/bosonicalio/geck/persistence"
+	gecksql "github.com/bosonicalio/geck/persistence/sql"
+	"github.com/bosonicalio/geck/transport"
+)
+
+// Migrations contains the [goose] SQL migration scripts (Postgres dialect) creating the default event and snapshot
+// tables used by [Repository].
+//
+// Scripts are located under the `migration` directory, e.g.
+//
+//	sqltest.RunMigrations(ctx, "postgres", db, eventstore.Migrations, "migration")
+//
+//go:embed migration/*.sql
+var Migrations embed.FS
+
+const (
+	_defaultEventTable    = "event_store"
+	_defaultSnapshotTable = "event_snapshot"
+)
+
+// -- Error(s) --
+
+var (
+	// ErrAggregateNotFound is returned when no events were persisted for an aggregate.
+	ErrAggregateNotFound = errors.New("aggregate not found")
+	// ErrVersionConflict is returned when the aggregate event stream was modified concurrently, i.e. the stored
+	// version differs from the expected one.
+	ErrVersionConflict = errors.New("aggregate version conflict")
+)
+
+// Repository is a component persisting [Aggregate] instances as append-only event streams in a SQL database.
+//
+// Aggregates are rehydrated by replaying their events (decoded using an [event.Registry]) through
+// [Aggregate.Apply]. Event streams are protected using optimistic concurrency; saving an aggregate which version
+// differs from the stored one returns [ErrVersionConflict].
+//
+// Optionally, snapshots of the aggregate state (serialized using [encoding/json]) may be taken every N events
+// (see [WithSnapshotFrequency]), so only the events persisted after the latest snapshot are replayed.
+//
+// SQL statements are written using the Postgres dialect.
+type Repository[T Aggregate] struct {
+	db       gecksql.DB
+	registry *event.Registry
+	newFunc  func() T
+	options  repositoryOptions
+}
+
+// NewRepository creates a new [Repository] instance. `newFunc` allocates empty aggregates to be rehydrated.
+// `db` is wrapped using [gecksql.NewDBTxPropagator].
+func NewRepository[T Aggregate](db gecksql.DB, registry *event.Registry, newFunc func() T,
+	opts ...RepositoryOption) Repository[T] {
+	options := repositoryOptions{}
+	for _, opt := range opts {
+		opt(&options)
+	}
+	options.eventTable = lo.CoalesceOrEmpty(options.eventTable, _defaultEventTable)
+	options.snapshotTable = lo.CoalesceOrEmpty(options.snapshotTable, _defaultSnapshotTable)
+	return Repository[T]{
+		db:       gecksql.NewDBTxPropagator(db),
+		registry: registry,
+		newFunc:  newFunc,
+		options:  options,
+	}
+}
+
+// Load rehydrates the aggregate identified by `id` from its latest snapshot (if any) and event stream.
+//
+// If a [gecksql.TxWrapper] is found in `ctx`, events are read using such transaction, so aggregates loaded,
+// modified and saved within a unit of work are consistent. Event types implementing [event.Event] using pointer
+// receivers are supported.
+//
+// Returns [ErrAggregateNotFound] if no events were persisted for the aggregate.
+func (r Repository[T]) Load(ctx context.Context, id string) (T, error) {
+	aggregate := r.newFunc()
+	if r.options.snapshotFrequency > 0 {
+		if err := r.loadSnapshot(ctx, id, aggregate); err != nil {
+			return aggregate, err
+		}
+	}
+
+	rows, err := r.db.QueryContext(ctx, "SELECT version, event_type, content_type, data FROM "+
+		r.options.eventTable+" WHERE aggregate_id = $1 AND version > $2 ORDER BY version",
+		id, aggregate.AggregateVersion())
+	if err != nil {
+		return aggregate, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var (
+			version     int64
+			eventType   string
+			contentType string
+			data        []byte
+		)
+		if err = rows.Scan(&version, &eventType, &contentType, &data); err != nil {
+			return aggregate, err
+		}
+		decoded, errDecode := r.registry.DecodeData(eventType, transport.NewMimeType(contentType), data)
+		if errDecode != nil {
+			return aggregate, errDecode
+		}
+		e, ok := toEvent(decoded)
+		if !ok {
+			return aggregate, fmt.Errorf("eventstore: type registered for %s does not implement event.Event", eventType)
+		}
+		if err = aggregate.Apply(e); err != nil {
+			return aggregate, err
+		}
+		aggregate.SetAggregateVersion(version)
+	}
+	if err = rows.Err(); err != nil {
+		return aggregate, err
+	}
+	if aggregate.AggregateVersion() == 0 {
+		return aggregate, fmt.Errorf("%w: %s", ErrAggregateNotFound, id)
+	}
+	return aggregate, nil
+}
+
+// toEvent returns `v` as an [event.Event]. If `v` does not implement [event.Event], a pointer to `v` is tried
+// (i.e. events implementing it using pointer receivers).
+func toEvent(v any) (event.Event, bool) {
+	if e, ok := v.(event.Event); ok {
+		return e, true
+	} else if v == nil {
+		return nil, false
+	}
+	ptr := reflect.New(reflect.TypeOf(v))
+	ptr.Elem().Set(reflect.ValueOf(v))
+	e, ok := ptr.Interface().(event.Event)
+	return e, ok
+}
+
+func (r Repository[T]) loadSnapshot(ctx context.Context, id string, aggregate T) error {
+	var (
+		version int64
+		data    []byte
+	)
+	err := r.db.QueryRowContext(ctx, "SELECT version, data FROM "+r.options.snapshotTable+
+		" WHERE aggregate_id = $1", id).Scan(&version, &data)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	if err = json.Unmarshal(data, aggregate); err != nil {
+		return err
+	}
+	aggregate.SetAggregateVersion(version)
+	return nil
+}
+
+// Save appends the pending events of `aggregate` (see [Aggregate.PendingEvents]) to its event stream, expecting
+// the stream to be at the aggregate version. Once the events are committed, they are removed from the aggregate
+// and the aggregate version is increased by the number of appended events. Hence, the aggregate is left untouched
+// if saving fails (e.g. [ErrVersionConflict]), so it can be saved again.
+//
+// If a [gecksql.TxWrapper] is found in `ctx`, events are written using such transaction and the aggregate is
+// updated once the unit of work commits (see [persistence.OnCommit]). Otherwise, a new transaction is created.
+func (r Repository[T]) Save(ctx context.Context, aggregate T) error {
+	events := aggregate.PendingEvents()
+	if len(events) == 0 {
+		return nil
+	}
+
+	tx, isOwned, err := r.getTx(ctx)
+	if err != nil {
+		return err
+	}
+	newVersion, err := r.append(ctx, tx, aggregate, events)
+	if isOwned {
+		if err != nil {
+			return errors.Join(err, tx.Rollback())
+		} else if err = tx.Commit(); err != nil {
+			return err
+		}
+		markSaved(aggregate, len(events), newVersion)
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	err = persistence.OnCommit(ctx, func(_ context.Context) error {
+		markSaved(aggregate, len(events), newVersion)
+		return nil
+	})
+	if errors.Is(err, persistence.ErrInvalidTxContext) {
+		// transaction not managed by a unit of work (see persistence.ExecInTx), completed by the caller
+		markSaved(aggregate, len(events), newVersion)
+		return nil
+	}
+	return err
+}
+
+// append writes `events` to the event stream of `aggregate` using `tx`, returning the new aggregate version.
+func (r Repository[T]) append(ctx context.Context, tx *sql.Tx, aggregate T, events []event.Event) (int64, error) {
+	const totalColumns = 6
+	expVersion := aggregate.AggregateVersion()
+	query := strings.Builder{}
+	query.WriteString("INSERT INTO " + r.options.eventTable +
+		" (aggregate_id, version, event_type, content_type, data, occurrence_time) VALUES ")
+	args := make([]any, 0, len(events)*totalColumns)
+	for i, e := range events {
+		data, errBytes := e.Bytes()
+		if errBytes != nil {
+			return 0, errBytes
+		}
+		if i > 0 {
+			query.WriteString(", ")
+		}
+		query.WriteString("(")
+		for j := range totalColumns {
+			if j > 0 {
+				query.WriteString(", ")
+			}
+			query.WriteString("$" + strconv.Itoa(len(args)+j+1))
+		}
+		query.WriteString(")")
+		args = append(args, aggregate.AggregateID(), expVersion+int64(i)+1, e.Topic().String(),
+			e.BytesContentType().String(), data, e.OccurrenceTime().UTC())
+	}
+	query.WriteString(" ON CONFLICT (aggregate_id, version) DO NOTHING")
+	res, err := tx.ExecContext(ctx, query.String(), args...)
+	if err != nil {
+		return 0, err
+	}
+	if affected, errAffected := res.RowsAffected(); errAffected != nil {
+		return 0, errAffected
+	} else if affected != int64(len(events)) {
+		return 0, fmt.Errorf("%w: %s (expected version %d)", ErrVersionConflict, aggregate.AggregateID(),
+			expVersion)
+	}
+
+	newVersion := expVersion + int64(len(events))
+	if freq := int64(r.options.snapshotFrequency); freq > 0 && newVersion/freq > expVersion/freq {
+		if err = r.saveSnapshot(ctx, tx, aggregate, newVersion); err != nil {
+			return 0, err
+		}
+	}
+	return newVersion, nil
+}
+
+// markSaved removes the first `count` pending events of `aggregate`, the events saved, and sets its version.
+// Events registered after saving are kept.
+func markSaved(aggregate Aggregate, count int, version int64) {
+	pending := aggregate.PullEvents()
+	if len(pending) > count {
+		aggregate.RegisterEvents(pending[count:]...)
+	}
+	aggregate.SetAggregateVersion(version)
+}
+
+func (r Repository[T]) saveSnapshot(ctx context.Context, tx *sql.Tx, aggregate T, version int64) error {
+	data, err := json.Marshal(aggregate)
+	if err != nil {
+		return err
+	}
+	_, err = tx.ExecContext(ctx, "INSERT INTO "+r.options.snapshotTable+
+		" (aggregate_id, version, data, create_time) VALUES ($1, $2, $3, $4)"+
+		" ON CONFLICT (aggregate_id) DO UPDATE SET version = EXCLUDED.version, data = EXCLUDED.data,"+
+		" create_time = EXCLUDED.create_time",
+		aggregate.AggregateID(), version, data, time.Now().UTC())
+	return err
+}
+
+// getTx returns the transaction found in `ctx` or a new one. `isOwned` indicates the transaction was created
+// by this routine, hence, it must be committed or rolled back by the caller.
+func (r Repository[T]) getTx(ctx context.Context) (tx *sql.Tx, isOwned bool, err error) {
+	txIface, found := persistence.FromTxContext(ctx, gecksql.TxDriver)
+	if !found {
+		tx, err = r.db.BeginTx(ctx, nil)
+		return tx, true, err
+	}
+	sqlTx, ok := txIface.(gecksql.TxWrapper)
+	if !ok {
+		return nil, false, persistence.ErrInvalidTxContext
+	}
+	return sqlTx.ParentTx(), false, nil
+}
+
+// -- Options --
+
+type repositoryOptions struct {
+	eventTable        string
+	snapshotTable     string
+	snapshotFrequency int
+}
+
+// RepositoryOption is a routine used to set up [Repository] optional configuration.
+type RepositoryOption func(*repositoryOptions)
+
+// WithEventTable sets the name of the event table used by a [Repository].
+func WithEventTable(table string) RepositoryOption {
+	return func(o *repositoryOptions) {
+		o.eventTable = table
+	}
+}
+
+// WithSnapshotTable sets the name of the snapshot table used by a [Repository].
+func WithSnapshotTable(table string) RepositoryOption {
+	return func(o *repositoryOptions) {
+		o.snapshotTable = table
+	}
+}
+
+// WithSnapshotFrequency enables aggregate snapshots, taking a snapshot every `n` persisted events.
+//
+// Snapshots are disabled by default.
+func WithSnapshotFrequency(n int) RepositoryOption {
+	return func(o *repositoryOptions) {
+		o.snapshotFrequency = n
+	}
+}
@@ -0,0 +1,344 @@
+package eventstore_test
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bosonicalio/geck/event"
+	"github.com/bosonicalio/geck/event/eventstore"
+	"github.com/bosonicalio/geck/persistence"
+	gecksql "github.com/bosonicalio/geck/persistence/sql"
+)
+
+// -- Fake driver --
+
+type storedEvent struct {
+	version     int64
+	eventType   string
+	contentType string
+	data        []byte
+}
+
+type storedSnapshot struct {
+	version int64
+	data    []byte
+}
+
+// fakeStore is a [driver.Connector] emulating the statements written by [eventstore.Repository] in memory.
+// Writes done within transactions are applied on commit.
+type fakeStore struct {
+	mu        sync.Mutex
+	events    map[string][]storedEvent
+	snapshots map[string]storedSnapshot
+	commitErr error
+	// txQueries is the number of queries executed within transactions
+	txQueries int
+}
+
+func newFakeStore() *fakeStore {
+	return &fakeStore{
+		events:    make(map[string][]storedEvent),
+		snapshots: make(map[string]storedSnapshot),
+	}
+}
+
+func (s *fakeStore) Connect(_ context.Context) (driver.Conn, error) {
+	return &fakeConn{store: s}, nil
+}
+
+func (s *fakeStore) Driver() driver.Driver {
+	return nil
+}
+
+type fakeConn struct {
+	store   *fakeStore
+	pending []func()
+	inTx    bool
+}
+
+func (c *fakeConn) Prepare(_ string) (driver.Stmt, error) {
+	return nil, errors.New("not supported")
+}
+
+func (c *fakeConn) Close() error {
+	return nil
+}
+
+func (c *fakeConn) Begin() (driver.Tx, error) {
+	c.inTx = true
+	return c, nil
+}
+
+func (c *fakeConn) Commit() error {
+	defer c.reset()
+	c.store.mu.Lock()
+	defer c.store.mu.Unlock()
+	if c.store.commitErr != nil {
+		return c.store.commitErr
+	}
+	for _, fn := range c.pending {
+		fn()
+	}
+	return nil
+}
+
+func (c *fakeConn) Rollback() error {
+	c.reset()
+	return nil
+}
+
+func (c *fakeConn) reset() {
+	c.inTx = false
+	c.pending = nil
+}
+
+func (c *fakeConn) ExecContext(_ context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	c.store.mu.Lock()
+	defer c.store.mu.Unlock()
+	var (
+		apply    func()
+		affected int64
+	)
+	switch {
+	case strings.HasPrefix(query, "INSERT INTO event_store"):
+		inserted := make([]storedEvent, 0, len(args)/6)
+		id := args[0].Value.(string)
+		for i := 0; i < len(args); i += 6 {
+			version := args[i+1].Value.(int64)
+			if int64(len(c.store.events[id])) >= version {
+				continue // ON CONFLICT DO NOTHING
+			}
+			inserted = append(inserted, storedEvent{
+				version:     version,
+				eventType:   args[i+2].Value.(string),
+				contentType: args[i+3].Value.(string),
+				data:        args[i+4].Value.([]byte),
+			})
+		}
+		affected = int64(len(inserted))
+		apply = func() {
+			c.store.events[id] = append(c.store.events[id], inserted...)
+		}
+	case strings.HasPrefix(query, "INSERT INTO event_snapshot"):
+		id := args[0].Value.(string)
+		snapshot := storedSnapshot{version: args[1].Value.(int64), data: args[2].Value.([]byte)}
+		affected = 1
+		apply = func() {
+			c.store.snapshots[id] = snapshot
+		}
+	default:
+		return nil, errors.New("unexpected statement: " + query)
+	}
+	if c.inTx {
+		c.pending = append(c.pending, apply)
+	} else {
+		apply()
+	}
+	return driver.RowsAffected(affected), nil
+}
+
+func (c *fakeConn) QueryContext(_ context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	c.store.mu.Lock()
+	defer c.store.mu.Unlock()
+	if c.inTx {
+		c.store.txQueries++
+	}
+	id := args[0].Value.(string)
+	switch {
+	case strings.HasPrefix(query, "SELECT version, event_type"):
+		rows := &fakeRows{columns: []string{"version", "event_type", "content_type", "data"}}
+		for _, e := range c.store.events[id] {
+			if e.version > args[1].Value.(int64) {
+				rows.rows = append(rows.rows, []driver.Value{e.version, e.eventType, e.contentType, e.data})
+			}
+		}
+		return rows, nil
+	case strings.HasPrefix(query, "SELECT version, data"):
+		rows := &fakeRows{columns: []string{"version", "data"}}
+		if snapshot, ok := c.store.snapshots[id]; ok {
+			rows.rows = append(rows.rows, []driver.Value{snapshot.version, snapshot.data})
+		}
+		return rows, nil
+	default:
+		return nil, errors.New("unexpected query: " + query)
+	}
+}
+
+type fakeRows struct {
+	columns []string
+	rows    [][]driver.Value
+}
+
+func (r *fakeRows) Columns() []string {
+	return r.columns
+}
+
+func (r *fakeRows) Close() error {
+	return nil
+}
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if len(r.rows) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.rows[0])
+	r.rows = r.rows[1:]
+	return nil
+}
+
+// -- Tests --
+
+func newAccountRepository(store *fakeStore, opts ...eventstore.RepositoryOption) eventstore.Repository[*account] {
+	registry := event.NewRegistry()
+	event.MustRegister[amountDeposited](registry, amountDeposited{}.Topic())
+	event.MustRegister[amountWithdrawn](registry, (&amountWithdrawn{}).Topic())
+	return eventstore.NewRepository(sql.OpenDB(store), registry, func() *account {
+		return &account{}
+	}, opts...)
+}
+
+func deposit(t *testing.T, acc *account, amounts ...int64) {
+	for _, amount := range amounts {
+		require.NoError(t, eventstore.Raise(acc, amountDeposited{AccountID: "123", Amount: amount}))
+	}
+}
+
+func TestRepository_Save(t *testing.T) {
+	ctx := context.Background()
+	store := newFakeStore()
+	repo := newAccountRepository(store)
+
+	_, err := repo.Load(ctx, "123")
+	assert.ErrorIs(t, err, eventstore.ErrAggregateNotFound)
+
+	acc := &account{}
+	deposit(t, acc, 10, 5)
+	require.NoError(t, repo.Save(ctx, acc))
+	assert.Equal(t, int64(2), acc.AggregateVersion())
+	assert.Empty(t, acc.PendingEvents())
+
+	loaded, err := repo.Load(ctx, "123")
+	require.NoError(t, err)
+	assert.Equal(t, int64(15), loaded.Balance)
+	assert.Equal(t, int64(2), loaded.AggregateVersion())
+
+	t.Run("version conflict", func(t *testing.T) {
+		stale, err := repo.Load(ctx, "123")
+		require.NoError(t, err)
+		deposit(t, loaded, 1)
+		require.NoError(t, repo.Save(ctx, loaded))
+
+		deposit(t, stale, 2)
+		assert.ErrorIs(t, repo.Save(ctx, stale), eventstore.ErrVersionConflict)
+		// the aggregate is left untouched so the caller can retry
+		assert.Len(t, stale.PendingEvents(), 1)
+		assert.Equal(t, int64(2), stale.AggregateVersion())
+	})
+
+	t.Run("commit failed", func(t *testing.T) {
+		acc, err := repo.Load(ctx, "123")
+		require.NoError(t, err)
+		version := acc.AggregateVersion()
+		deposit(t, acc, 3)
+		store.commitErr = errors.New("commit failed")
+		assert.ErrorIs(t, repo.Save(ctx, acc), store.commitErr)
+		assert.Len(t, acc.PendingEvents(), 1)
+		assert.Equal(t, version, acc.AggregateVersion())
+
+		store.commitErr = nil
+		require.NoError(t, repo.Save(ctx, acc))
+		assert.Equal(t, version+1, acc.AggregateVersion())
+	})
+}
+
+func TestRepository_Save_UnitOfWork(t *testing.T) {
+	ctx := context.Background()
+	store := newFakeStore()
+	repo := newAccountRepository(store)
+	factory := gecksql.NewTxFactory(sql.OpenDB(store), nil)
+
+	acc := &account{}
+	deposit(t, acc, 10)
+	errFn := errors.New("some error")
+	err := persistence.ExecInTx(ctx, factory, func(ctx context.Context) error {
+		require.NoError(t, repo.Save(ctx, acc))
+		return errFn
+	})
+	require.ErrorIs(t, err, errFn)
+	assert.Len(t, acc.PendingEvents(), 1)
+	assert.Zero(t, acc.AggregateVersion())
+
+	err = persistence.ExecInTx(ctx, factory, func(ctx context.Context) error {
+		require.NoError(t, repo.Save(ctx, acc))
+		// the aggregate is updated once the transaction is committed
+		assert.Zero(t, acc.AggregateVersion())
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Empty(t, acc.PendingEvents())
+	assert.Equal(t, int64(1), acc.AggregateVersion())
+}
+
+func TestRepository_Load(t *testing.T) {
+	ctx := context.Background()
+	store := newFakeStore()
+	repo := newAccountRepository(store)
+
+	acc := &account{}
+	deposit(t, acc, 10)
+	require.NoError(t, eventstore.Raise(acc, &amountWithdrawn{AccountID: "123", Amount: 4}))
+	require.NoError(t, repo.Save(ctx, acc))
+
+	t.Run("pointer receivers", func(t *testing.T) {
+		loaded, err := repo.Load(ctx, "123")
+		require.NoError(t, err)
+		assert.Equal(t, int64(6), loaded.Balance)
+		assert.Equal(t, int64(2), loaded.AggregateVersion())
+	})
+
+	t.Run("unit of work", func(t *testing.T) {
+		factory := gecksql.NewTxFactory(sql.OpenDB(store), nil)
+		err := persistence.ExecInTx(ctx, factory, func(ctx context.Context) error {
+			loaded, err := repo.Load(ctx, "123")
+			require.NoError(t, err)
+			// events are read using the transaction found in the context
+			assert.Equal(t, 1, store.txQueries)
+			deposit(t, loaded, 1)
+			return repo.Save(ctx, loaded)
+		})
+		require.NoError(t, err)
+		loaded, err := repo.Load(ctx, "123")
+		require.NoError(t, err)
+		assert.Equal(t, int64(7), loaded.Balance)
+	})
+}
+
+func TestRepository_Snapshot(t *testing.T) {
+	ctx := context.Background()
+	store := newFakeStore()
+	repo := newAccountRepository(store, eventstore.WithSnapshotFrequency(2))
+
+	acc := &account{}
+	deposit(t, acc, 10, 5, 1)
+	require.NoError(t, repo.Save(ctx, acc))
+	require.Contains(t, store.snapshots, "123")
+	assert.Equal(t, int64(3), store.snapshots["123"].version)
+
+	deposit(t, acc, 4)
+	require.NoError(t, repo.Save(ctx, acc))
+	// events covered by the snapshot are not replayed
+	store.events["123"] = store.events["123"][3:]
+	loaded, err := repo.Load(ctx, "123")
+	require.NoError(t, err)
+	assert.Equal(t, int64(20), loaded.Balance)
+	assert.Equal(t, int64(4), loaded.AggregateVersion())
+}
@@ -0,0 +1,24 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS event_outbox (
+    outbox_id BIGSERIAL PRIMARY KEY,
+    event_id VARCHAR(64) NOT NULL UNIQUE,
+    topic VARCHAR(255) NOT NULL,
+    message_key VARCHAR(255),
+    header JSONB NOT NULL,
+    data BYTEA,
+    create_time TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    send_time TIMESTAMPTZ,
+    fail_time TIMESTAMPTZ,
+    fail_reason TEXT
+);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE INDEX IF NOT EXISTS event_outbox_pending_idx ON event_outbox (outbox_id) WHERE send_time IS NULL AND fail_time IS NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS event_outbox;
+-- +goose StatementEnd
@@ -0,0 +1,516 @@
+package event
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/samber/lo"
+
+	"github.com/bosonicalio/geck/persistence"
+	"github.com/bosonicalio/geck/persistence/identifier"
+	gecksql "github.com/bosonicalio/geck/persistence/sql"
+	"github.com/bosonicalio/geck/transport/stream"
+)
+
+// OutboxMigrations contains the [goose] SQL migration scripts (Postgres dialect) creating the default outbox
+// table used by [OutboxPublisher] and [OutboxRelay].
+//
+// Scripts are located under the `migration/outbox` directory, e.g.
+//
+//	sqltest.RunMigrations(ctx, "postgres", db, event.OutboxMigrations, "migration/outbox")
+//
+//go:embed migration/outbox/*.sql
+var OutboxMigrations embed.FS
+
+const _defaultOutboxTable = "event_outbox"
+
+// -- Error(s) --
+
+var (
+	// ErrOutboxRelayClosed is returned when the outbox relay is closed.
+	ErrOutboxRelayClosed = errors.New("outbox relay is closed")
+	// ErrOutboxRelayAlreadyStarted is returned when the outbox relay is already started.
+	ErrOutboxRelayAlreadyStarted = errors.New("outbox relay already started")
+	// ErrMalformedOutboxRow is reported by [OutboxRelay] when an outbox row cannot be decoded. Such rows are marked
+	// as failed (i.e. `fail_time` and `fail_reason` columns) and are not relayed anymore.
+	ErrMalformedOutboxRow = errors.New("malformed outbox row")
+)
+
+// - Publisher -
+
+// OutboxPublisher is a [Publisher] implementation following the transactional outbox pattern.
+//
+// Instead of propagating events to a stream directly, this component writes the serialized events (along with their
+// Cloud Events headers) into an outbox table using the SQL transaction found in the context
+// (see [gecksql.TxDriver] and [persistence.ExecInTx]). Thus, events are persisted atomically with the rest of
+// the unit of work and discarded if the transaction is rolled back.
+//
+// Use [OutboxRelay] to forward the persisted events to a [stream.Writer].
+//
+// SQL statements are written using the Postgres dialect.
+type OutboxPublisher struct {
+	idFactory identifier.Factory
+	table     string
+	mode      ContentMode
+}
+
+// compile-time assertion(s)
+var _ Publisher = (*OutboxPublisher)(nil)
+
+// NewOutboxPublisher creates a new [OutboxPublisher] instance.
+func NewOutboxPublisher(factory identifier.Factory, opts ...OutboxOption) OutboxPublisher {
+	options := outboxOptions{}
+	for _, opt := range opts {
+		opt(&options)
+	}
+	return OutboxPublisher{
+		idFactory: factory,
+		table:     lo.CoalesceOrEmpty(options.table, _defaultOutboxTable),
+		mode:      options.mode,
+	}
+}
+
+// Publish writes the given events into the outbox table.
+//
+// This routine requires a [gecksql.TxWrapper] in `ctx`, otherwise it returns [persistence.ErrInvalidTxContext].
+func (p OutboxPublisher) Publish(ctx context.Context, events []Event) error {
+	if len(events) == 0 {
+		return nil
+	}
+	txIface, found := persistence.FromTxContext(ctx, gecksql.TxDriver)
+	if !found {
+		return persistence.ErrInvalidTxContext
+	}
+	tx, ok := txIface.(gecksql.TxWrapper)
+	if !ok {
+		return persistence.ErrInvalidTxContext
+	}
+
+	const totalColumns = 5
+	query := strings.Builder{}
+	query.WriteString("INSERT INTO " + p.table + " (event_id, topic, message_key, header, data) VALUES ")
+	args := make([]any, 0, len(events)*totalColumns)
+	for i, event := range events {
+		id, err := p.idFactory.NewID()
+		if err != nil {
+			return err
+		}
+		msg, err := newStreamMessage(id, event, p.mode)
+		if err != nil {
+			return err
+		}
+		header, err := json.Marshal(msg.Header)
+		if err != nil {
+			return err
+		}
+		if i > 0 {
+			query.WriteString(", ")
+		}
+		query.WriteString("(")
+		for j := range totalColumns {
+			if j > 0 {
+				query.WriteString(", ")
+			}
+			query.WriteString("$" + strconv.Itoa(len(args)+j+1))
+		}
+		query.WriteString(")")
+		args = append(args, id, event.Topic().String(), msg.Key, string(header), msg.Data)
+	}
+
+	_, err := tx.ParentTx().ExecContext(ctx, query.String(), args...)
+	return err
+}
+
+// -- Options --
+
+type outboxOptions struct {
+	table string
+	mode  ContentMode
+}
+
+// OutboxOption is a routine used to set up [OutboxPublisher] optional configuration.
+type OutboxOption func(*outboxOptions)
+
+// WithOutboxTable sets the name of the outbox table for an [OutboxPublisher].
+func WithOutboxTable(table string) OutboxOption {
+	return func(o *outboxOptions) {
+		o.table = table
+	}
+}
+
+// WithOutboxContentMode sets the [ContentMode] used by an [OutboxPublisher] to serialize events.
+// Defaults to [ContentModeHeader].
+func WithOutboxContentMode(mode ContentMode) OutboxOption {
+	return func(o *outboxOptions) {
+		o.mode = mode
+	}
+}
+
+// - Relay -
+
+// OutboxRelay is a background worker forwarding events stored by [OutboxPublisher] to a [stream.Writer].
+//
+// The relay polls the outbox table periodically, locking a batch of pending rows (ordered by insertion)
+// within a database transaction, writes them into the stream and marks them as sent. Rows are locked using
+// `FOR UPDATE SKIP LOCKED`, so several relay instances may run concurrently without blocking each other.
+// Nevertheless, events sharing the same key are only guaranteed to be written in order by a single relay instance.
+//
+// If the stream write operation fails for a topic, the rows of such topic remain pending and the topic is
+// excluded from the following polling cycles using exponential backoff (see [WithOutboxRelayMaxBackoff]), so
+// failing rows do not block the rows of other topics. Likewise, the relay waits before polling again
+// whenever a relay cycle fails.
+//
+// Rows which cannot be decoded (e.g. a corrupted header) are marked as failed, recording the failure reason in
+// the `fail_reason` column, and reported using [ErrMalformedOutboxRow] (see [WithOutboxRelayErrorHandler]).
+// Failed rows are never relayed, hence, they do not block the following rows of their topic.
+type OutboxRelay struct {
+	db      gecksql.DB
+	writer  stream.Writer
+	options outboxRelayOptions
+
+	mu            sync.Mutex
+	topicFailures map[string]outboxTopicFailure
+
+	alreadyStarted atomic.Bool
+	isClosed       atomic.Bool
+	inFlightProcs  sync.WaitGroup
+	ctxBase        context.Context
+	ctxCancelFunc  context.CancelFunc
+}
+
+// outboxTopicFailure holds the failure state of a topic whose rows could not be forwarded.
+type outboxTopicFailure struct {
+	attempts  int
+	retryTime time.Time
+}
+
+// NewOutboxRelay creates a new [OutboxRelay] instance.
+func NewOutboxRelay(db gecksql.DB, w stream.Writer, opts ...OutboxRelayOption) *OutboxRelay {
+	options := outboxRelayOptions{}
+	for _, opt := range opts {
+		opt(&options)
+	}
+	options.table = lo.CoalesceOrEmpty(options.table, _defaultOutboxTable)
+	options.batchSize = lo.CoalesceOrEmpty(options.batchSize, 100)
+	options.pollInterval = lo.CoalesceOrEmpty(options.pollInterval, 500*time.Millisecond)
+	options.maxBackoff = max(lo.CoalesceOrEmpty(options.maxBackoff, 30*time.Second), options.pollInterval)
+	return &OutboxRelay{
+		db:            db,
+		writer:        w,
+		options:       options,
+		topicFailures: make(map[string]outboxTopicFailure),
+	}
+}
+
+// Start starts the relay. It begins polling the outbox table in the background and forwarding pending events.
+//
+// The relay will run until it is closed ([OutboxRelay.Close]).
+func (r *OutboxRelay) Start() error {
+	if r.alreadyStarted.Load() {
+		return ErrOutboxRelayAlreadyStarted
+	} else if r.isClosed.Load() {
+		return ErrOutboxRelayClosed
+	}
+	r.alreadyStarted.Store(true)
+	r.ctxBase, r.ctxCancelFunc = context.WithCancel(context.Background())
+	r.inFlightProcs.Add(1)
+	go r.startPoller()
+	return nil
+}
+
+func (r *OutboxRelay) startPoller() {
+	defer r.inFlightProcs.Done()
+	failures := 0
+	for {
+		select {
+		case <-r.ctxBase.Done():
+			return
+		default:
+		}
+
+		sent, err := r.Relay(r.ctxBase)
+		if err != nil && !errors.Is(err, context.Canceled) && r.options.errorHandler != nil {
+			r.options.errorHandler(r.ctxBase, err)
+		}
+		interval := r.options.pollInterval
+		switch {
+		case err != nil:
+			failures++
+			interval = r.backoff(failures)
+		case sent >= r.options.batchSize:
+			failures = 0
+			continue // pending rows may remain, skip waiting
+		default:
+			failures = 0
+		}
+
+		timer := time.NewTimer(interval)
+		select {
+		case <-r.ctxBase.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+	}
+}
+
+// backoff computes the delay following `attempts` consecutive failures using exponential backoff, starting
+// from the poll interval.
+func (r *OutboxRelay) backoff(attempts int) time.Duration {
+	delay := r.options.pollInterval
+	for i := 1; i < attempts && delay < r.options.maxBackoff; i++ {
+		delay *= 2
+	}
+	return min(delay, r.options.maxBackoff)
+}
+
+type outboxRow struct {
+	id     int64
+	topic  string
+	key    sql.NullString
+	header []byte
+	data   []byte
+}
+
+// Relay executes a single relay cycle, forwarding a batch of pending events to the stream.
+//
+// Returns the number of events forwarded to the stream. Rows of topics failing to be written are kept pending
+// and their errors are returned once the forwarded rows are marked as sent. Rows failing to be decoded are
+// marked as failed and reported using [ErrMalformedOutboxRow], without blocking the rest of their topic.
+func (r *OutboxRelay) Relay(ctx context.Context) (int, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	sentIDs, errWrite := r.relay(ctx, tx)
+	if sentIDs == nil && errWrite != nil {
+		return 0, errors.Join(errWrite, tx.Rollback())
+	}
+	if err = tx.Commit(); err != nil {
+		return 0, errors.Join(errWrite, err)
+	}
+	return len(sentIDs), errWrite
+}
+
+// relay forwards the pending rows locked by `tx`, marking them as sent.
+//
+// Returns the identifiers of the rows marked as sent along with the errors of failed topics and rows. A nil slice
+// indicates the transaction must be rolled back.
+func (r *OutboxRelay) relay(ctx context.Context, tx *sql.Tx) ([]int64, error) {
+	buf, err := r.lockRows(ctx, tx)
+	if err != nil {
+		return nil, err
+	}
+
+	// decode every row before writing anything, keeping topic order of appearance to write batches
+	// deterministically
+	topics := make([]string, 0)
+	topicRows := make(map[string][]outboxRow)
+	topicMessages := make(map[string][]stream.Message)
+	errs := make([]error, 0)
+	for _, row := range buf {
+		header := stream.Header{}
+		if errUnmarshal := json.Unmarshal(row.header, &header); errUnmarshal != nil {
+			// undecodable rows would never be forwarded, set them apart so the rest of their topic keeps flowing
+			errFail := fmt.Errorf("%w: outbox row %d: %w", ErrMalformedOutboxRow, row.id, errUnmarshal)
+			if err = r.markRowFailed(ctx, tx, row.id, errFail); err != nil {
+				return nil, err
+			}
+			errs = append(errs, errFail)
+			continue
+		}
+		if _, ok := topicRows[row.topic]; !ok {
+			topics = append(topics, row.topic)
+		}
+		topicRows[row.topic] = append(topicRows[row.topic], row)
+		topicMessages[row.topic] = append(topicMessages[row.topic], stream.Message{
+			Key:    row.key.String,
+			Header: header,
+			Data:   row.data,
+		})
+	}
+
+	sentIDs := make([]int64, 0, len(buf))
+	for _, topic := range topics {
+		if _, errTopic := r.writer.WriteBatch(ctx, topic, topicMessages[topic]); errTopic != nil {
+			r.markTopicFailed(topic)
+			errs = append(errs, fmt.Errorf("topic %s: %w", topic, errTopic))
+			continue
+		}
+		r.markTopicSent(topic)
+		for _, row := range topicRows[topic] {
+			sentIDs = append(sentIDs, row.id)
+		}
+	}
+
+	if len(sentIDs) > 0 {
+		placeholders := make([]string, 0, len(sentIDs))
+		args := make([]any, 0, len(sentIDs)+1)
+		args = append(args, time.Now().UTC())
+		for _, id := range sentIDs {
+			args = append(args, id)
+			placeholders = append(placeholders, "$"+strconv.Itoa(len(args)))
+		}
+		_, errExec := tx.ExecContext(ctx, fmt.Sprintf("UPDATE %s SET send_time = $1 WHERE outbox_id IN (%s)",
+			r.options.table, strings.Join(placeholders, ", ")), args...)
+		if errExec != nil {
+			return nil, errExec
+		}
+	}
+	return sentIDs, errors.Join(errs...)
+}
+
+// markRowFailed sets the row identified by `id` as failed using `tx`, recording `reason`. Failed rows are no
+// longer relayed.
+func (r *OutboxRelay) markRowFailed(ctx context.Context, tx *sql.Tx, id int64, reason error) error {
+	_, err := tx.ExecContext(ctx, "UPDATE "+r.options.table+
+		" SET fail_time = $1, fail_reason = $2 WHERE outbox_id = $3", time.Now().UTC(), reason.Error(), id)
+	return err
+}
+
+// lockRows reads and locks a batch of pending rows, skipping rows locked by other relay instances and rows of
+// topics backing off.
+func (r *OutboxRelay) lockRows(ctx context.Context, tx *sql.Tx) ([]outboxRow, error) {
+	args := []any{r.options.batchSize}
+	query := strings.Builder{}
+	query.WriteString("SELECT outbox_id, topic, message_key, header, data FROM " + r.options.table +
+		" WHERE send_time IS NULL AND fail_time IS NULL")
+	if topics := r.backingOffTopics(); len(topics) > 0 {
+		placeholders := make([]string, 0, len(topics))
+		for _, topic := range topics {
+			args = append(args, topic)
+			placeholders = append(placeholders, "$"+strconv.Itoa(len(args)))
+		}
+		query.WriteString(" AND topic NOT IN (" + strings.Join(placeholders, ", ") + ")")
+	}
+	query.WriteString(" ORDER BY outbox_id LIMIT $1 FOR UPDATE SKIP LOCKED")
+
+	rows, err := tx.QueryContext(ctx, query.String(), args...)
+	if err != nil {
+		return nil, err
+	}
+	buf := make([]outboxRow, 0, r.options.batchSize)
+	for rows.Next() {
+		row := outboxRow{}
+		if err = rows.Scan(&row.id, &row.topic, &row.key, &row.header, &row.data); err != nil {
+			_ = rows.Close()
+			return nil, err
+		}
+		buf = append(buf, row)
+	}
+	if err = errors.Join(rows.Err(), rows.Close()); err != nil {
+		return nil, err
+	}
+	return buf, nil
+}
+
+func (r *OutboxRelay) backingOffTopics() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	now := time.Now()
+	topics := make([]string, 0, len(r.topicFailures))
+	for topic, failure := range r.topicFailures {
+		if now.Before(failure.retryTime) {
+			topics = append(topics, topic)
+		}
+	}
+	slices.Sort(topics)
+	return topics
+}
+
+func (r *OutboxRelay) markTopicFailed(topic string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	failure := r.topicFailures[topic]
+	failure.attempts++
+	failure.retryTime = time.Now().Add(r.backoff(failure.attempts))
+	r.topicFailures[topic] = failure
+}
+
+func (r *OutboxRelay) markTopicSent(topic string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.topicFailures, topic)
+}
+
+// Close stops the relay, waiting for the in-flight relay cycle to complete for a graceful shutdown.
+func (r *OutboxRelay) Close(ctx context.Context) error {
+	if r.isClosed.Load() {
+		return ErrOutboxRelayClosed
+	}
+	r.isClosed.Store(true)
+	if !r.alreadyStarted.Load() {
+		return nil
+	}
+	r.ctxCancelFunc()
+	done := make(chan struct{})
+	go func() {
+		r.inFlightProcs.Wait()
+		close(done)
+	}()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-done:
+		return nil
+	}
+}
+
+// -- Options --
+
+type outboxRelayOptions struct {
+	table        string
+	batchSize    int
+	pollInterval time.Duration
+	maxBackoff   time.Duration
+	errorHandler func(context.Context, error)
+}
+
+// OutboxRelayOption is a routine used to set up [OutboxRelay] optional configuration.
+type OutboxRelayOption func(*outboxRelayOptions)
+
+// WithOutboxRelayTable sets the name of the outbox table for an [OutboxRelay].
+func WithOutboxRelayTable(table string) OutboxRelayOption {
+	return func(o *outboxRelayOptions) {
+		o.table = table
+	}
+}
+
+// WithOutboxRelayBatchSize sets the maximum number of rows forwarded on each relay cycle.
+func WithOutboxRelayBatchSize(size int) OutboxRelayOption {
+	return func(o *outboxRelayOptions) {
+		o.batchSize = size
+	}
+}
+
+// WithOutboxRelayPollInterval sets the interval for polling the outbox table.
+func WithOutboxRelayPollInterval(interval time.Duration) OutboxRelayOption {
+	return func(o *outboxRelayOptions) {
+		o.pollInterval = interval
+	}
+}
+
+// WithOutboxRelayMaxBackoff sets the upper bound of the delay applied after failures, both before polling again
+// and before retrying the rows of a failing topic. Delays start from the poll interval. Defaults to 30 seconds.
+func WithOutboxRelayMaxBackoff(backoff time.Duration) OutboxRelayOption {
+	return func(o *outboxRelayOptions) {
+		o.maxBackoff = backoff
+	}
+}
+
+// WithOutboxRelayErrorHandler sets the error handler function for the [OutboxRelay].
+func WithOutboxRelayErrorHandler(handler func(context.Context, error)) OutboxRelayOption {
+	return func(o *outboxRelayOptions) {
+		o.errorHandler = handler
+	}
+}
@@ -0,0 +1,450 @@
+package event_test
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/fs"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bosonicalio/geck/event"
+	"github.com/bosonicalio/geck/persistence"
+	"github.com/bosonicalio/geck/persistence/identifier"
+	gecksql "github.com/bosonicalio/geck/persistence/sql"
+	"github.com/bosonicalio/geck/transport"
+	"github.com/bosonicalio/geck/transport/stream"
+	"github.com/bosonicalio/geck/transport/stream/memstream"
+)
+
+type userCreated struct {
+	UserID string `json:"user_id"`
+	Name   string `json:"name"`
+}
+
+var _ event.Event = (*userCreated)(nil)
+
+func (u userCreated) Topic() event.Topic {
+	return event.NewTopic("acme-corp", "user", "created")
+}
+
+func (u userCreated) Key() string {
+	return u.UserID
+}
+
+func (u userCreated) Bytes() ([]byte, error) {
+	return json.Marshal(u)
+}
+
+func (u userCreated) BytesContentType() transport.MimeType {
+	return transport.MimeTypeJSON
+}
+
+func (u userCreated) Source() string {
+	return "/acme-corp/users"
+}
+
+func (u userCreated) Subject() string {
+	return u.UserID
+}
+
+func (u userCreated) OccurrenceTime() time.Time {
+	return time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+}
+
+func (u userCreated) SchemaSource() string {
+	return ""
+}
+
+func TestOutboxMigrations(t *testing.T) {
+	files, err := fs.Glob(event.OutboxMigrations, "migration/outbox/*.sql")
+	require.NoError(t, err)
+	assert.NotEmpty(t, files)
+}
+
+func TestOutboxPublisher_Publish(t *testing.T) {
+	publisher := event.NewOutboxPublisher(identifier.FactoryKSUID{})
+
+	// no events, no-op
+	err := publisher.Publish(context.Background(), nil)
+	assert.NoError(t, err)
+
+	// missing transaction
+	err = publisher.Publish(context.Background(), []event.Event{userCreated{UserID: "user-1", Name: "User One"}})
+	assert.ErrorIs(t, err, persistence.ErrInvalidTxContext)
+}
+
+func TestOutboxPublisher_Publish_UnitOfWork(t *testing.T) {
+	ctx := context.Background()
+	outbox := newFakeOutbox()
+	factory := gecksql.NewTxFactory(sql.OpenDB(outbox), nil)
+	publisher := event.NewOutboxPublisher(identifier.FactoryKSUID{}, event.WithOutboxContentMode(event.ContentModeBinary))
+	events := []event.Event{
+		userCreated{UserID: "user-1", Name: "User One"},
+		userCreated{UserID: "user-2", Name: "User Two"},
+	}
+
+	errFn := errors.New("some error")
+	err := persistence.ExecInTx(ctx, factory, func(ctx context.Context) error {
+		require.NoError(t, publisher.Publish(ctx, events))
+		return errFn
+	})
+	require.ErrorIs(t, err, errFn)
+	assert.Empty(t, outbox.snapshot())
+
+	err = persistence.ExecInTx(ctx, factory, func(ctx context.Context) error {
+		return publisher.Publish(ctx, events)
+	})
+	require.NoError(t, err)
+	rows := outbox.snapshot()
+	require.Len(t, rows, 2)
+	for i, row := range rows {
+		assert.Equal(t, "acme-corp.user.created", row.topic)
+		assert.Equal(t, events[i].Key(), row.key)
+		header := stream.Header{}
+		require.NoError(t, json.Unmarshal(row.header, &header))
+		assert.Equal(t, []string{"acme-corp.user.created"}, header[event.HeaderCloudEventsType])
+		assert.JSONEq(t, `{"user_id":"`+events[i].Key()+`","name":"`+events[i].(userCreated).Name+`"}`, string(row.data))
+	}
+}
+
+func TestOutboxRelay_Relay(t *testing.T) {
+	ctx := context.Background()
+	const (
+		topicUsers  = "acme-corp.user.created"
+		topicOrders = "acme-corp.order.placed"
+	)
+
+	t.Run("forward pending rows", func(t *testing.T) {
+		outbox := newFakeOutbox()
+		outbox.add(topicUsers, "user-1", `{"ce_id":["1"]}`)
+		outbox.add(topicOrders, "order-1", `{"ce_id":["2"]}`)
+		outbox.add(topicUsers, "user-1", `{"ce_id":["3"]}`)
+		broker := memstream.NewBroker()
+		relay := event.NewOutboxRelay(sql.OpenDB(outbox), broker, event.WithOutboxRelayBatchSize(2))
+
+		sent, err := relay.Relay(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, 2, sent)
+		assert.Len(t, broker.Records(topicUsers), 1)
+		assert.Len(t, broker.Records(topicOrders), 1)
+
+		sent, err = relay.Relay(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, 1, sent)
+		records := broker.Records(topicUsers)
+		require.Len(t, records, 2)
+		assert.Equal(t, []string{"3"}, records[1].Header["ce_id"])
+
+		sent, err = relay.Relay(ctx)
+		require.NoError(t, err)
+		assert.Zero(t, sent)
+		for _, row := range outbox.snapshot() {
+			assert.True(t, row.isSent)
+		}
+		assert.Contains(t, outbox.lastQuery(), "FOR UPDATE SKIP LOCKED")
+	})
+
+	t.Run("failing topic", func(t *testing.T) {
+		outbox := newFakeOutbox()
+		outbox.add(topicUsers, "user-1", `{"ce_id":["1"]}`)
+		outbox.add(topicOrders, "order-1", `{"ce_id":["2"]}`)
+		outbox.add(topicUsers, "user-1", `{"ce_id":["3"]}`)
+		broker := memstream.NewBroker()
+		errWrite := errors.New("write failed")
+		writer := &failingWriter{Writer: broker, topic: topicUsers, err: errWrite}
+		relay := event.NewOutboxRelay(sql.OpenDB(outbox), writer, event.WithOutboxRelayBatchSize(1),
+			event.WithOutboxRelayPollInterval(time.Hour))
+
+		sent, err := relay.Relay(ctx)
+		assert.ErrorIs(t, err, errWrite)
+		assert.Zero(t, sent)
+
+		// the failing topic is backing off, rows of other topics are not blocked
+		sent, err = relay.Relay(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, 1, sent)
+		assert.Len(t, broker.Records(topicOrders), 1)
+		memstream.RequireNoRecords(t, broker, topicUsers)
+
+		sent, err = relay.Relay(ctx)
+		require.NoError(t, err)
+		assert.Zero(t, sent)
+		rows := outbox.snapshot()
+		assert.False(t, rows[0].isSent)
+		assert.False(t, rows[2].isSent)
+	})
+
+	t.Run("malformed row", func(t *testing.T) {
+		outbox := newFakeOutbox()
+		outbox.add(topicUsers, "user-1", `{"ce_id":["1"]}`)
+		outbox.add(topicUsers, "user-2", `not json`)
+		outbox.add(topicOrders, "order-1", `{"ce_id":["2"]}`)
+		outbox.add(topicUsers, "user-3", `{"ce_id":["3"]}`)
+		broker := memstream.NewBroker()
+		relay := event.NewOutboxRelay(sql.OpenDB(outbox), broker)
+
+		sent, err := relay.Relay(ctx)
+		assert.ErrorIs(t, err, event.ErrMalformedOutboxRow)
+		assert.Equal(t, 3, sent)
+		// the malformed row does not block the following rows of its topic
+		records := broker.Records(topicUsers)
+		require.Len(t, records, 2)
+		assert.Equal(t, []string{"1"}, records[0].Header["ce_id"])
+		assert.Equal(t, []string{"3"}, records[1].Header["ce_id"])
+		assert.Len(t, broker.Records(topicOrders), 1)
+		rows := outbox.snapshot()
+		assert.False(t, rows[1].isSent)
+		assert.Contains(t, rows[1].failReason, "outbox row 2")
+
+		// failed rows are not relayed again, neither their topic backs off
+		outbox.add(topicUsers, "user-4", `{"ce_id":["4"]}`)
+		sent, err = relay.Relay(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, 1, sent)
+		assert.Len(t, broker.Records(topicUsers), 3)
+	})
+
+	t.Run("commit failed", func(t *testing.T) {
+		outbox := newFakeOutbox()
+		outbox.add(topicUsers, "user-1", `{"ce_id":["1"]}`)
+		outbox.commitErr = errors.New("commit failed")
+		relay := event.NewOutboxRelay(sql.OpenDB(outbox), memstream.NewBroker())
+
+		sent, err := relay.Relay(ctx)
+		assert.ErrorIs(t, err, outbox.commitErr)
+		assert.Zero(t, sent)
+		assert.False(t, outbox.snapshot()[0].isSent)
+	})
+}
+
+func TestOutboxRelay_Start(t *testing.T) {
+	outbox := newFakeOutbox()
+	outbox.add("acme-corp.user.created", "user-1", `{"ce_id":["1"]}`)
+	errWrite := errors.New("write failed")
+	writer := &failingWriter{Writer: memstream.NewBroker(), topic: "acme-corp.user.created", err: errWrite}
+	failures := atomic.Int32{}
+	relay := event.NewOutboxRelay(sql.OpenDB(outbox), writer,
+		event.WithOutboxRelayBatchSize(1),
+		event.WithOutboxRelayPollInterval(10*time.Millisecond),
+		event.WithOutboxRelayErrorHandler(func(_ context.Context, err error) {
+			assert.ErrorIs(t, err, errWrite)
+			failures.Add(1)
+		}),
+	)
+	require.NoError(t, relay.Start())
+	assert.ErrorIs(t, relay.Start(), event.ErrOutboxRelayAlreadyStarted)
+	time.Sleep(150 * time.Millisecond)
+	require.NoError(t, relay.Close(context.Background()))
+	assert.ErrorIs(t, relay.Close(context.Background()), event.ErrOutboxRelayClosed)
+
+	// the relay backs off after failures instead of polling continuously
+	assert.Positive(t, failures.Load())
+	assert.Less(t, failures.Load(), int32(6))
+	assert.False(t, outbox.snapshot()[0].isSent)
+}
+
+type failingWriter struct {
+	stream.Writer
+	topic string
+	err   error
+}
+
+func (w *failingWriter) WriteBatch(ctx context.Context, name string, messages []stream.Message) (int, error) {
+	if name == w.topic {
+		return 0, w.err
+	}
+	return w.Writer.WriteBatch(ctx, name, messages)
+}
+
+// -- Fake driver --
+
+type outboxRow struct {
+	id         int64
+	topic      string
+	key        string
+	header     []byte
+	data       []byte
+	isSent     bool
+	failReason string
+}
+
+// fakeOutbox is a [driver.Connector] emulating the statements written by [event.OutboxPublisher] and
+// [event.OutboxRelay] in memory. Writes done within transactions are applied on commit.
+type fakeOutbox struct {
+	mu        sync.Mutex
+	rows      []outboxRow
+	queries   []string
+	commitErr error
+}
+
+func newFakeOutbox() *fakeOutbox {
+	return &fakeOutbox{}
+}
+
+func (o *fakeOutbox) add(topic, key, header string) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.rows = append(o.rows, outboxRow{
+		id:     int64(len(o.rows) + 1),
+		topic:  topic,
+		key:    key,
+		header: []byte(header),
+		data:   []byte(`{}`),
+	})
+}
+
+func (o *fakeOutbox) snapshot() []outboxRow {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return slices.Clone(o.rows)
+}
+
+func (o *fakeOutbox) lastQuery() string {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if len(o.queries) == 0 {
+		return ""
+	}
+	return o.queries[len(o.queries)-1]
+}
+
+func (o *fakeOutbox) Connect(_ context.Context) (driver.Conn, error) {
+	return &fakeOutboxConn{outbox: o}, nil
+}
+
+func (o *fakeOutbox) Driver() driver.Driver {
+	return nil
+}
+
+type fakeOutboxConn struct {
+	outbox  *fakeOutbox
+	pending []func()
+}
+
+func (c *fakeOutboxConn) Prepare(_ string) (driver.Stmt, error) {
+	return nil, errors.New("not supported")
+}
+
+func (c *fakeOutboxConn) Close() error {
+	return nil
+}
+
+func (c *fakeOutboxConn) Begin() (driver.Tx, error) {
+	return c, nil
+}
+
+func (c *fakeOutboxConn) Commit() error {
+	defer c.Rollback()
+	c.outbox.mu.Lock()
+	defer c.outbox.mu.Unlock()
+	if c.outbox.commitErr != nil {
+		return c.outbox.commitErr
+	}
+	for _, fn := range c.pending {
+		fn()
+	}
+	return nil
+}
+
+func (c *fakeOutboxConn) Rollback() error {
+	c.pending = nil
+	return nil
+}
+
+func (c *fakeOutboxConn) ExecContext(_ context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	c.outbox.mu.Lock()
+	defer c.outbox.mu.Unlock()
+	switch {
+	case strings.HasPrefix(query, "INSERT INTO event_outbox"):
+		c.pending = append(c.pending, func() {
+			for i := 0; i < len(args); i += 5 {
+				c.outbox.rows = append(c.outbox.rows, outboxRow{
+					id:     int64(len(c.outbox.rows) + 1),
+					topic:  args[i+1].Value.(string),
+					key:    args[i+2].Value.(string),
+					header: []byte(args[i+3].Value.(string)),
+					data:   args[i+4].Value.([]byte),
+				})
+			}
+		})
+	case strings.HasPrefix(query, "UPDATE event_outbox SET send_time"):
+		ids := make([]int64, 0, len(args)-1)
+		for _, arg := range args[1:] {
+			ids = append(ids, arg.Value.(int64))
+		}
+		c.pending = append(c.pending, func() {
+			for i := range c.outbox.rows {
+				if slices.Contains(ids, c.outbox.rows[i].id) {
+					c.outbox.rows[i].isSent = true
+				}
+			}
+		})
+	case strings.HasPrefix(query, "UPDATE event_outbox SET fail_time"):
+		reason, id := args[1].Value.(string), args[2].Value.(int64)
+		c.pending = append(c.pending, func() {
+			for i := range c.outbox.rows {
+				if c.outbox.rows[i].id == id {
+					c.outbox.rows[i].failReason = reason
+				}
+			}
+		})
+	default:
+		return nil, errors.New("unexpected statement: " + query)
+	}
+	return driver.RowsAffected(1), nil
+}
+
+func (c *fakeOutboxConn) QueryContext(_ context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	c.outbox.mu.Lock()
+	defer c.outbox.mu.Unlock()
+	if !strings.HasPrefix(query, "SELECT outbox_id, topic, message_key, header, data FROM event_outbox") {
+		return nil, errors.New("unexpected query: " + query)
+	}
+	c.outbox.queries = append(c.outbox.queries, query)
+	excluded := make([]string, 0, len(args)-1)
+	for _, arg := range args[1:] {
+		excluded = append(excluded, arg.Value.(string))
+	}
+	rows := &fakeOutboxRows{}
+	for _, row := range c.outbox.rows {
+		if row.isSent || row.failReason != "" || slices.Contains(excluded, row.topic) {
+			continue
+		} else if int64(len(rows.rows)) >= args[0].Value.(int64) {
+			break
+		}
+		rows.rows = append(rows.rows, []driver.Value{row.id, row.topic, row.key, row.header, row.data})
+	}
+	return rows, nil
+}
+
+type fakeOutboxRows struct {
+	rows [][]driver.Value
+}
+
+func (r *fakeOutboxRows) Columns() []string {
+	return []string{"outbox_id", "topic", "message_key", "header", "data"}
+}
+
+func (r *fakeOutboxRows) Close() error {
+	return nil
+}
+
+func (r *fakeOutboxRows) Next(dest []driver.Value) error {
+	if len(r.rows) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.rows[0])
+	r.rows = r.rows[1:]
+	return nil
+}
@@ -2,7 +2,6 @@ package event
 
 import (
 	"context"
-	"time"
 
 	"github.com/bosonicalio/geck/persistence/identifier"
 	"github.com/bosonicalio/geck/transport/stream"
@@ -20,48 +19,41 @@ type Publisher interface {
 // StreamPublisher is a [Publisher] implementation that propagates events to a stream.
 //
 // This component publishes events into a stream in a synchronous-way using stream write batch APIs.
+//
+// Cloud Events attributes are written using the [ContentModeHeader] mode by default, use [WithContentMode]
+// to change this behavior.
 type StreamPublisher struct {
 	writer    stream.Writer
 	idFactory identifier.Factory
+	mode      ContentMode
 }
 
 // compile-time assertion(s)
 var _ Publisher = (*StreamPublisher)(nil)
 
 // NewStreamPublisher creates a new [StreamPublisher] instance.
-func NewStreamPublisher(w stream.Writer, factory identifier.Factory) StreamPublisher {
-	return StreamPublisher{writer: w, idFactory: factory}
+func NewStreamPublisher(w stream.Writer, factory identifier.Factory, opts ...StreamPublisherOption) StreamPublisher {
+	options := streamPublisherOptions{}
+	for _, opt := range opts {
+		opt(&options)
+	}
+	return StreamPublisher{writer: w, idFactory: factory, mode: options.mode}
 }
 
 // Publish propagates the given events.
 func (p StreamPublisher) Publish(ctx context.Context, events []Event) error {
-	const totalHeaders = 8
 	topicMessages := make(map[string][]stream.Message)
 	for _, event := range events {
 		id, err := p.idFactory.NewID()
 		if err != nil {
 			return err
 		}
-		topic := event.Topic().String()
-		msg, err := event.Bytes()
+		msg, err := newStreamMessage(id, event, p.mode)
 		if err != nil {
 			return err
 		}
-
-		header := make(stream.Header, totalHeaders)
-		header.Add(HeaderEventID, id)
-		header.Add(HeaderSource, event.Source())
-		header.Add(HeaderSpecVersion, CloudEventsCurrentSpecVersion)
-		header.Add(HeaderEventType, event.Topic().String())
-		header.Add(HeaderDataContentType, event.BytesContentType().String())
-		header.Add(HeaderDataSchema, event.SchemaSource())
-		header.Add(HeaderSubject, event.Subject())
-		header.Add(HeaderEventTime, event.OccurrenceTime().Format(time.RFC3339))
-		topicMessages[topic] = append(topicMessages[topic], stream.Message{
-			Key:    event.Key(),
-			Data:   msg,
-			Header: header,
-		})
+		topic := event.Topic().String()
+		topicMessages[topic] = append(topicMessages[topic], msg)
 	}
 
 	for topic, messages := range topicMessages {
@@ -71,3 +63,19 @@ func (p StreamPublisher) Publish(ctx context.Context, events []Event) error {
 	}
 	return nil
 }
+
+// -- Options --
+
+type streamPublisherOptions struct {
+	mode ContentMode
+}
+
+// StreamPublisherOption is a routine used to set up [StreamPublisher] optional configuration.
+type StreamPublisherOption func(*streamPublisherOptions)
+
+// WithContentMode sets the [ContentMode] used by a [StreamPublisher] to write events.
+func WithContentMode(mode ContentMode) StreamPublisherOption {
+	return func(o *streamPublisherOptions) {
+		o.mode = mode
+	}
+}
@@ -0,0 +1,202 @@
+package event
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/vmihailenco/msgpack/v5"
+
+	"github.com/bosonicalio/geck/transport"
+	"github.com/bosonicalio/geck/transport/stream"
+)
+
+// -- Error(s) --
+
+var (
+	// ErrTopicNotRegistered is returned when no type was registered for a topic.
+	ErrTopicNotRegistered = errors.New("event topic not registered")
+	// ErrTopicAlreadyRegistered is returned when a type was already registered for a topic.
+	ErrTopicAlreadyRegistered = errors.New("event topic already registered")
+	// ErrTypeMismatch is returned when the type registered for a topic differs from the requested one.
+	ErrTypeMismatch = errors.New("event type mismatch")
+	// ErrUnsupportedContentType is returned when no decoder was registered for the event data content type.
+	ErrUnsupportedContentType = errors.New("unsupported event data content type")
+	// ErrMissingAttribute is returned when a required Cloud Events attribute is missing.
+	ErrMissingAttribute = errors.New("missing event attribute")
+)
+
+// Attributes are the Cloud Events context attributes of a received event.
+type Attributes struct {
+	// ID identifies the event.
+	ID string
+	// Source identifies the context in which the event was produced.
+	Source string
+	// SpecVersion is the version of the Cloud Events specification the event uses.
+	SpecVersion string
+	// Type is the type of the event, the [Topic] name.
+	Type string
+	// DataContentType is the content MIME type of the event data.
+	DataContentType transport.MimeType
+	// DataSchema is the schema source of the event data.
+	DataSchema string
+	// Subject is the subject of the event in the context of the event producer.
+	Subject string
+	// Time is the occurrence time of the event.
+	Time time.Time
+}
+
+// Envelope is a received event along with its Cloud Events attributes.
+type Envelope[T any] struct {
+	Attributes
+	// Key is the key of the message holding the event.
+	Key string
+	// Data is the decoded event.
+	Data T
+}
+
+// Decoder is a routine decoding event data into `v`, a pointer.
+type Decoder func(data []byte, v any) error
+
+// - Registry -
+
+// Registry is a component mapping [Topic] values to Go types, decoding received events based on their data
+// content type (see [WithRegistryDecoder]).
+//
+// JSON and MessagePack decoders are registered by default.
+type Registry struct {
+	decoders map[transport.MimeType]Decoder
+
+	mu    sync.RWMutex
+	types map[string]reflect.Type
+}
+
+// NewRegistry creates a new [Registry] instance.
+func NewRegistry(opts ...RegistryOption) *Registry {
+	options := registryOptions{
+		decoders: map[transport.MimeType]Decoder{
+			transport.MimeTypeJSON:    json.Unmarshal,
+			transport.MimeTypeMsgPack: msgpack.Unmarshal,
+		},
+	}
+	for _, opt := range opts {
+		opt(&options)
+	}
+	return &Registry{
+		decoders: options.decoders,
+		types:    make(map[string]reflect.Type),
+	}
+}
+
+// Register maps `topic` to the type `T` in `r`.
+func Register[T any](r *Registry, topic Topic) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.types[topic.String()]; ok {
+		return fmt.Errorf("%w: %s", ErrTopicAlreadyRegistered, topic)
+	}
+	r.types[topic.String()] = reflect.TypeFor[T]()
+	return nil
+}
+
+// MustRegister maps `topic` to the type `T` in `r`.
+//
+// This routine will panic if any error occurs.
+func MustRegister[T any](r *Registry, topic Topic) {
+	if err := Register[T](r, topic); err != nil {
+		panic(err)
+	}
+}
+
+// Decode decodes `msg` (written using any [ContentMode]) into an [Envelope] holding a value of the type
+// registered for the event type.
+func (r *Registry) Decode(msg stream.Message) (Envelope[any], error) {
+	attrs, data, err := ParseMessage(msg)
+	if err != nil {
+		return Envelope[any]{}, err
+	}
+	v, err := r.DecodeData(attrs.Type, attrs.DataContentType, data)
+	if err != nil {
+		return Envelope[any]{}, err
+	}
+	return Envelope[any]{
+		Attributes: attrs,
+		Key:        msg.Key,
+		Data:       v,
+	}, nil
+}
+
+// DecodeData decodes `data` into a value of the type registered for `eventType`, using the [Decoder] registered
+// for `contentType`.
+func (r *Registry) DecodeData(eventType string, contentType transport.MimeType, data []byte) (any, error) {
+	typ, err := r.lookup(eventType)
+	if err != nil {
+		return nil, err
+	}
+	v := reflect.New(typ)
+	if err = r.decode(contentType, data, v.Interface()); err != nil {
+		return nil, err
+	}
+	return v.Elem().Interface(), nil
+}
+
+// DecodeEnvelope decodes `msg` (written using any [ContentMode]) into an [Envelope] of type `T`.
+//
+// Returns [ErrTypeMismatch] if the type registered for the event type is not `T`.
+func DecodeEnvelope[T any](r *Registry, msg stream.Message) (Envelope[T], error) {
+	attrs, data, err := ParseMessage(msg)
+	if err != nil {
+		return Envelope[T]{}, err
+	}
+	typ, err := r.lookup(attrs.Type)
+	if err != nil {
+		return Envelope[T]{}, err
+	} else if exp := reflect.TypeFor[T](); typ != exp {
+		return Envelope[T]{}, fmt.Errorf("%w: expected %s, got %s", ErrTypeMismatch, exp, typ)
+	}
+	envelope := Envelope[T]{
+		Attributes: attrs,
+		Key:        msg.Key,
+	}
+	if err = r.decode(attrs.DataContentType, data, &envelope.Data); err != nil {
+		return Envelope[T]{}, err
+	}
+	return envelope, nil
+}
+
+func (r *Registry) lookup(eventType string) (reflect.Type, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	typ, ok := r.types[eventType]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrTopicNotRegistered, eventType)
+	}
+	return typ, nil
+}
+
+func (r *Registry) decode(contentType transport.MimeType, data []byte, v any) error {
+	decoder, ok := r.decoders[contentType]
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrUnsupportedContentType, contentType)
+	}
+	return decoder(data, v)
+}
+
+// -- Options --
+
+type registryOptions struct {
+	decoders map[transport.MimeType]Decoder
+}
+
+// RegistryOption is a routine used to set up [Registry] optional configuration.
+type RegistryOption func(*registryOptions)
+
+// WithRegistryDecoder sets the [Decoder] used by a [Registry] for events with the `mimeType` data content type.
+func WithRegistryDecoder(mimeType transport.MimeType, decoder Decoder) RegistryOption {
+	return func(o *registryOptions) {
+		o.decoders[mimeType] = decoder
+	}
+}
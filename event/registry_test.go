@@ -0,0 +1,82 @@
+package event_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vmihailenco/msgpack/v5"
+
+	"github.com/bosonicalio/geck/event"
+	"github.com/bosonicalio/geck/persistence/identifier"
+	"github.com/bosonicalio/geck/transport"
+	"github.com/bosonicalio/geck/transport/stream"
+	"github.com/bosonicalio/geck/transport/stream/memstream"
+)
+
+func TestRegistry(t *testing.T) {
+	ctx := context.Background()
+	broker := memstream.NewBroker()
+	publisher := event.NewStreamPublisher(broker, identifier.FactoryKSUID{})
+	require.NoError(t, publisher.Publish(ctx, []event.Event{userCreated{UserID: "123", Name: "John"}}))
+	records := memstream.RequireRecords(t, broker, "acme-corp.user.created", 1, time.Second)
+	msg := stream.Message{
+		Key:    records[0].Key,
+		Header: records[0].Header,
+		Data:   records[0].Data,
+	}
+
+	registry := event.NewRegistry()
+	_, err := event.DecodeEnvelope[userCreated](registry, msg)
+	assert.ErrorIs(t, err, event.ErrTopicNotRegistered)
+
+	event.MustRegister[userCreated](registry, userCreated{}.Topic())
+	assert.ErrorIs(t, event.Register[userCreated](registry, userCreated{}.Topic()), event.ErrTopicAlreadyRegistered)
+
+	t.Run("typed", func(t *testing.T) {
+		envelope, errDecode := event.DecodeEnvelope[userCreated](registry, msg)
+		require.NoError(t, errDecode)
+		assert.Equal(t, userCreated{UserID: "123", Name: "John"}, envelope.Data)
+		assert.Equal(t, "123", envelope.Key)
+		assert.NotEmpty(t, envelope.ID)
+		assert.Equal(t, "acme-corp.user.created", envelope.Type)
+		assert.Equal(t, "/acme-corp/users", envelope.Source)
+		assert.Equal(t, "123", envelope.Subject)
+		assert.Equal(t, event.CloudEventsCurrentSpecVersion, envelope.SpecVersion)
+		assert.Equal(t, transport.MimeTypeJSON, envelope.DataContentType)
+		assert.Equal(t, userCreated{}.OccurrenceTime(), envelope.Time)
+
+		_, errDecode = event.DecodeEnvelope[string](registry, msg)
+		assert.ErrorIs(t, errDecode, event.ErrTypeMismatch)
+	})
+
+	t.Run("untyped", func(t *testing.T) {
+		envelope, errDecode := registry.Decode(msg)
+		require.NoError(t, errDecode)
+		assert.Equal(t, userCreated{UserID: "123", Name: "John"}, envelope.Data)
+	})
+
+	t.Run("msgpack", func(t *testing.T) {
+		data, errMarshal := msgpack.Marshal(userCreated{UserID: "123", Name: "John"})
+		require.NoError(t, errMarshal)
+		header := make(stream.Header)
+		for k, v := range msg.Header {
+			header[k] = v
+		}
+		header.Set(event.HeaderDataContentType, transport.MimeTypeMsgPack.String())
+		envelope, errDecode := event.DecodeEnvelope[userCreated](registry, stream.Message{Header: header, Data: data})
+		require.NoError(t, errDecode)
+		assert.Equal(t, "John", envelope.Data.Name)
+
+		header.Set(event.HeaderDataContentType, transport.MimeTypeXML.String())
+		_, errDecode = event.DecodeEnvelope[userCreated](registry, stream.Message{Header: header, Data: data})
+		assert.ErrorIs(t, errDecode, event.ErrUnsupportedContentType)
+	})
+
+	t.Run("missing attributes", func(t *testing.T) {
+		_, errDecode := registry.Decode(stream.Message{Header: stream.Header{}})
+		assert.ErrorIs(t, errDecode, event.ErrMissingAttribute)
+	})
+}
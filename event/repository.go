@@ -0,0 +1,153 @@
+package event
+
+import (
+	"context"
+	"errors"
+
+	"github.com/bosonicalio/geck/persistence"
+)
+
+// StorableAggregator is an [Aggregator] which can be stored into a persistence system.
+type StorableAggregator interface {
+	persistence.Storable
+	Aggregator
+}
+
+// PublishingRepository is a [persistence.WriteRepository] decorator publishing the events of aggregators
+// (see [Aggregator.PullEvents]) once they are saved successfully.
+//
+// If `ctx` holds a unit of work (see [persistence.ExecInTx] and [persistence.TxManager.Execute]), events are
+// published after the transactions are committed (see [persistence.OnCommit]); hence, events are discarded if
+// transactions are rolled back. Otherwise, events are published right after saving. Use
+// [WithPublishOnCommit] to publish events within the transaction instead (e.g. when using [OutboxPublisher]).
+//
+// Batch operations are delegated to the decorated repository if it implements [persistence.WriteBatchRepository],
+// otherwise, entities are written one by one.
+type PublishingRepository[K comparable, T StorableAggregator] struct {
+	next      persistence.WriteRepository[K, T]
+	publisher Publisher
+	options   publishingRepositoryOptions
+}
+
+// compile-time assertion(s)
+var (
+	_ persistence.WriteRepository[string, StorableAggregator]      = (*PublishingRepository[string, StorableAggregator])(nil)
+	_ persistence.WriteBatchRepository[string, StorableAggregator] = (*PublishingRepository[string, StorableAggregator])(nil)
+)
+
+// NewPublishingRepository creates a new [PublishingRepository] instance.
+func NewPublishingRepository[K comparable, T StorableAggregator](next persistence.WriteRepository[K, T],
+	publisher Publisher, opts ...PublishingRepositoryOption) PublishingRepository[K, T] {
+	options := publishingRepositoryOptions{
+		publishOnCommit: true,
+	}
+	for _, opt := range opts {
+		opt(&options)
+	}
+	return PublishingRepository[K, T]{
+		next:      next,
+		publisher: publisher,
+		options:   options,
+	}
+}
+
+// Save saves `entity` using the decorated repository, then, publishes its events.
+func (r PublishingRepository[K, T]) Save(ctx context.Context, entity T) error {
+	if err := r.next.Save(ctx, entity); err != nil {
+		return err
+	}
+	return r.publish(ctx, entity.PullEvents())
+}
+
+// DeleteByKey deletes the entity identified by `key` using the decorated repository.
+func (r PublishingRepository[K, T]) DeleteByKey(ctx context.Context, key K) error {
+	return r.next.DeleteByKey(ctx, key)
+}
+
+// Delete deletes `entity` using the decorated repository.
+func (r PublishingRepository[K, T]) Delete(ctx context.Context, entity T) error {
+	return r.next.Delete(ctx, entity)
+}
+
+// SaveAll saves `entities` using the decorated repository, then, publishes their events.
+func (r PublishingRepository[K, T]) SaveAll(ctx context.Context, entities []T) error {
+	if batchRepo, ok := r.next.(persistence.WriteBatchRepository[K, T]); ok {
+		if err := batchRepo.SaveAll(ctx, entities); err != nil {
+			return err
+		}
+	} else {
+		for _, entity := range entities {
+			if err := r.next.Save(ctx, entity); err != nil {
+				return err
+			}
+		}
+	}
+
+	events := make([]Event, 0, len(entities))
+	for _, entity := range entities {
+		events = append(events, entity.PullEvents()...)
+	}
+	return r.publish(ctx, events)
+}
+
+// DeleteAll deletes `entities` using the decorated repository.
+func (r PublishingRepository[K, T]) DeleteAll(ctx context.Context, entities []T) error {
+	if batchRepo, ok := r.next.(persistence.WriteBatchRepository[K, T]); ok {
+		return batchRepo.DeleteAll(ctx, entities)
+	}
+	for _, entity := range entities {
+		if err := r.next.Delete(ctx, entity); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// DeleteAllByKeys deletes the entities identified by `keys` using the decorated repository.
+func (r PublishingRepository[K, T]) DeleteAllByKeys(ctx context.Context, keys []K) error {
+	if batchRepo, ok := r.next.(persistence.WriteBatchRepository[K, T]); ok {
+		return batchRepo.DeleteAllByKeys(ctx, keys)
+	}
+	for _, key := range keys {
+		if err := r.next.DeleteByKey(ctx, key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r PublishingRepository[K, T]) publish(ctx context.Context, events []Event) error {
+	if len(events) == 0 {
+		return nil
+	} else if !r.options.publishOnCommit {
+		return r.publisher.Publish(ctx, events)
+	}
+
+	err := persistence.OnCommit(ctx, func(ctx context.Context) error {
+		return r.publisher.Publish(ctx, events)
+	})
+	if errors.Is(err, persistence.ErrInvalidTxContext) {
+		// no unit of work found
+		return r.publisher.Publish(ctx, events)
+	}
+	return err
+}
+
+// -- Options --
+
+type publishingRepositoryOptions struct {
+	publishOnCommit bool
+}
+
+// PublishingRepositoryOption is a routine used to set up [PublishingRepository] optional configuration.
+type PublishingRepositoryOption func(*publishingRepositoryOptions)
+
+// WithPublishOnCommit indicates whether a [PublishingRepository] defers publishing until transactions found in
+// the context are committed. Enabled by default.
+//
+// Disable it for publishers writing into the same transaction (e.g. [OutboxPublisher]).
+func WithPublishOnCommit(enabled bool) PublishingRepositoryOption {
+	return func(o *publishingRepositoryOptions) {
+		o.publishOnCommit = enabled
+	}
+}
@@ -0,0 +1,127 @@
+package event_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"github.com/bosonicalio/geck/event"
+	"github.com/bosonicalio/geck/eventmock"
+	"github.com/bosonicalio/geck/persistence"
+	"github.com/bosonicalio/geck/persistencemock"
+)
+
+type user struct {
+	persistence.NoopStorable
+	event.AggregatorTemplate
+	ID string
+}
+
+func newUser(id string) *user {
+	u := &user{ID: id}
+	u.RegisterEvents(userCreated{UserID: id, Name: "John"})
+	return u
+}
+
+func TestAggregatorTemplate_PullEvents(t *testing.T) {
+	u := newUser("123")
+	assert.Len(t, u.PullEvents(), 1)
+	assert.Empty(t, u.PullEvents())
+}
+
+func TestPublishingRepository_Save(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("without transaction", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		repo := persistencemock.NewMockWriteRepository[string, *user](ctrl)
+		publisher := eventmock.NewMockPublisher(ctrl)
+		u := newUser("123")
+		gomock.InOrder(
+			repo.EXPECT().Save(ctx, u).Return(nil),
+			publisher.EXPECT().Publish(ctx, []event.Event{userCreated{UserID: "123", Name: "John"}}).Return(nil),
+		)
+
+		pubRepo := event.NewPublishingRepository[string, *user](repo, publisher)
+		require.NoError(t, pubRepo.Save(ctx, u))
+		assert.Empty(t, u.PullEvents())
+	})
+
+	t.Run("save failed", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		repo := persistencemock.NewMockWriteRepository[string, *user](ctrl)
+		publisher := eventmock.NewMockPublisher(ctrl)
+		u := newUser("123")
+		errSave := errors.New("save failed")
+		repo.EXPECT().Save(ctx, u).Return(errSave)
+
+		pubRepo := event.NewPublishingRepository[string, *user](repo, publisher)
+		assert.ErrorIs(t, pubRepo.Save(ctx, u), errSave)
+	})
+
+	t.Run("deferred until commit", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		repo := persistencemock.NewMockWriteRepository[string, *user](ctrl)
+		publisher := eventmock.NewMockPublisher(ctrl)
+		tx := persistencemock.NewMockTransaction(ctrl)
+		factory := persistencemock.NewMockTxFactory(ctrl)
+		factory.EXPECT().Driver().Return(persistence.TxDriver("mock")).AnyTimes()
+		factory.EXPECT().NewTx(ctx).Return(tx, nil)
+		u := newUser("123")
+		repo.EXPECT().Save(gomock.Any(), u).Return(nil)
+		gomock.InOrder(
+			tx.EXPECT().Commit(gomock.Any()).Return(nil),
+			publisher.EXPECT().Publish(ctx, gomock.Len(1)).Return(nil),
+		)
+
+		pubRepo := event.NewPublishingRepository[string, *user](repo, publisher)
+		err := persistence.ExecInTx(ctx, factory, func(ctx context.Context) error {
+			return pubRepo.Save(ctx, u)
+		})
+		require.NoError(t, err)
+	})
+
+	t.Run("discarded on rollback", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		repo := persistencemock.NewMockWriteRepository[string, *user](ctrl)
+		publisher := eventmock.NewMockPublisher(ctrl)
+		tx := persistencemock.NewMockTransaction(ctrl)
+		factory := persistencemock.NewMockTxFactory(ctrl)
+		factory.EXPECT().Driver().Return(persistence.TxDriver("mock")).AnyTimes()
+		factory.EXPECT().NewTx(ctx).Return(tx, nil)
+		tx.EXPECT().Rollback(gomock.Any()).Return(nil)
+		u := newUser("123")
+		repo.EXPECT().Save(gomock.Any(), u).Return(nil)
+
+		pubRepo := event.NewPublishingRepository[string, *user](repo, publisher)
+		errFn := errors.New("some error")
+		err := persistence.ExecInTx(ctx, factory, func(ctx context.Context) error {
+			if errSave := pubRepo.Save(ctx, u); errSave != nil {
+				return errSave
+			}
+			return errFn
+		})
+		assert.ErrorIs(t, err, errFn)
+	})
+}
+
+func TestPublishingRepository_SaveAll(t *testing.T) {
+	ctx := context.Background()
+	ctrl := gomock.NewController(t)
+	repo := persistencemock.NewMockWriteRepository[string, *user](ctrl)
+	publisher := eventmock.NewMockPublisher(ctrl)
+	users := []*user{newUser("123"), newUser("456")}
+	repo.EXPECT().Save(ctx, users[0]).Return(nil)
+	repo.EXPECT().Save(ctx, users[1]).Return(nil)
+	publisher.EXPECT().Publish(ctx, []event.Event{
+		userCreated{UserID: "123", Name: "John"},
+		userCreated{UserID: "456", Name: "John"},
+	}).Return(nil)
+
+	pubRepo := event.NewPublishingRepository[string, *user](repo, publisher, event.WithPublishOnCommit(false))
+	require.NoError(t, pubRepo.SaveAll(ctx, users))
+}
@@ -0,0 +1,63 @@
+package health
+
+import (
+	"context"
+	"errors"
+)
+
+// -- Error(s) --
+
+var (
+	// ErrNotReady is returned when a component is not ready to accept calls.
+	ErrNotReady = errors.New("component is not ready")
+)
+
+// A Checker is a component verifying the health of a dependency (e.g. databases, brokers, storage systems) or
+// the system itself.
+type Checker interface {
+	// Check verifies the health of the underlying component. Returns a non-nil error if the component
+	// is not healthy.
+	Check(ctx context.Context) error
+}
+
+// CheckerFunc is a routine implementing [Checker].
+type CheckerFunc func(ctx context.Context) error
+
+// compile-time assertion
+var _ Checker = (*CheckerFunc)(nil)
+
+// Check verifies the health of the underlying component.
+func (f CheckerFunc) Check(ctx context.Context) error {
+	return f(ctx)
+}
+
+// -- Built-in --
+
+// Pinger is a component able to verify a connection to a remote system is still alive (e.g. [database/sql.DB]).
+type Pinger interface {
+	// PingContext verifies a connection to the remote system is still alive, establishing a connection if
+	// necessary.
+	PingContext(ctx context.Context) error
+}
+
+// NewSQLChecker creates a [Checker] pinging a SQL database (e.g. [database/sql.DB] returned by
+// Postgres connection pools).
+func NewSQLChecker(db Pinger) Checker {
+	return CheckerFunc(db.PingContext)
+}
+
+// ReadinessProbe is a component exposing its readiness state (e.g. application.Runner).
+type ReadinessProbe interface {
+	// IsReady indicates whether the component is ready to accept calls.
+	IsReady() bool
+}
+
+// NewReadinessChecker creates a [Checker] returning [ErrNotReady] when `probe` is not ready.
+func NewReadinessChecker(probe ReadinessProbe) Checker {
+	return CheckerFunc(func(_ context.Context) error {
+		if !probe.IsReady() {
+			return ErrNotReady
+		}
+		return nil
+	})
+}
@@ -0,0 +1,80 @@
+package health
+
+import (
+	"net/http"
+	"sort"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/bosonicalio/geck/syserr"
+	"github.com/bosonicalio/geck/transport"
+	geckhttp "github.com/bosonicalio/geck/transport/http"
+)
+
+// Controller is a [geckhttp.Controller] exposing the results of a [Registry] through HTTP endpoints:
+//
+//   - GET /healthz: executes all checks.
+//   - GET /readyz: executes [KindReadiness] checks.
+//   - GET /livez: executes [KindLiveness] checks.
+//
+// Healthy reports are returned with a 200 status code wrapped in a [transport.DataContainer]. Unhealthy reports
+// are returned with a 503 status code using the [geckhttp.Errors] format, where each failing check is an entry
+// of [geckhttp.Error] holding the check name in its metadata.
+type Controller struct {
+	registry *Registry
+}
+
+// compile-time assertion
+var _ geckhttp.Controller = (*Controller)(nil)
+
+// NewController creates a new [Controller] instance.
+func NewController(registry *Registry) Controller {
+	return Controller{registry: registry}
+}
+
+// SetEndpoints takes `e` and sets health endpoints.
+func (c Controller) SetEndpoints(e *echo.Echo) {
+	e.GET("/healthz", c.newHandler(KindAll))
+	e.GET("/readyz", c.newHandler(KindReadiness))
+	e.GET("/livez", c.newHandler(KindLiveness))
+}
+
+// SetVersionedEndpoints is a no-op as health endpoints are not versioned.
+func (c Controller) SetVersionedEndpoints(_ *echo.Group) {}
+
+func (c Controller) newHandler(kind Kind) echo.HandlerFunc {
+	return func(ctx echo.Context) error {
+		report := c.registry.Check(ctx.Request().Context(), kind)
+		if report.IsUp() {
+			return ctx.JSON(http.StatusOK, transport.DataContainer[Report]{Data: report})
+		}
+
+		names := make([]string, 0, len(report.Checks))
+		for name, result := range report.Checks {
+			if result.Status != StatusUp {
+				names = append(names, name)
+			}
+		}
+		sort.Strings(names)
+		errs := make([]geckhttp.Error, 0, len(names))
+		for _, name := range names {
+			result := report.Checks[name]
+			errs = append(errs, geckhttp.Error{
+				Kind:         syserr.Unavailable.String(),
+				Code:         http.StatusServiceUnavailable,
+				InternalCode: "HEALTH_CHECK_FAILED",
+				Message:      result.Error,
+				Metadata: map[string]string{
+					"check": name,
+					"took":  result.Took,
+				},
+			})
+		}
+		return ctx.JSON(http.StatusServiceUnavailable, echo.Map{
+			"error": geckhttp.Errors{
+				Code:   http.StatusServiceUnavailable,
+				Errors: errs,
+			},
+		})
+	}
+}
@@ -0,0 +1,55 @@
+package health_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bosonicalio/geck/observability/health"
+	geckhttp "github.com/bosonicalio/geck/transport/http"
+)
+
+func TestController(t *testing.T) {
+	registry := health.NewRegistry()
+	registry.MustRegister("process", health.CheckerFunc(func(_ context.Context) error {
+		return nil
+	}), health.WithCheckKind(health.KindLiveness))
+	registry.MustRegister("database", health.CheckerFunc(func(_ context.Context) error {
+		return errors.New("connection refused")
+	}))
+
+	e := echo.New()
+	health.NewController(registry).SetEndpoints(e)
+
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/livez", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	okBody := struct {
+		Data health.Report `json:"data"`
+	}{}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &okBody))
+	assert.Equal(t, health.StatusUp, okBody.Data.Status)
+	assert.Contains(t, okBody.Data.Checks, "process")
+
+	for _, path := range []string{"/readyz", "/healthz"} {
+		rec = httptest.NewRecorder()
+		e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+		errBody := struct {
+			Error geckhttp.Errors `json:"error"`
+		}{}
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &errBody))
+		assert.Equal(t, http.StatusServiceUnavailable, errBody.Error.Code)
+		require.Len(t, errBody.Error.Errors, 1)
+		assert.Equal(t, "UNAVAILABLE", errBody.Error.Errors[0].Kind)
+		assert.Equal(t, "connection refused", errBody.Error.Errors[0].Message)
+		assert.Equal(t, "database", errBody.Error.Errors[0].Metadata["check"])
+	}
+}
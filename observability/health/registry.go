@@ -0,0 +1,277 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/samber/lo"
+)
+
+// -- Error(s) --
+
+var (
+	// ErrCheckAlreadyRegistered is returned when a check is already registered with the same name.
+	ErrCheckAlreadyRegistered = errors.New("health check already registered")
+)
+
+// Kind is the kind of health check, used to group checks by their purpose. Kinds may be combined
+// (e.g. KindReadiness | KindLiveness).
+type Kind uint8
+
+const (
+	// KindReadiness the check verifies the system is ready to accept calls (e.g. dependencies are reachable).
+	KindReadiness Kind = 1 << iota
+	// KindLiveness the check verifies the system is running and not in an unrecoverable state.
+	KindLiveness
+	// KindAll matches every kind of check.
+	KindAll = KindReadiness | KindLiveness
+)
+
+// Status is the health status of a check (or a set of checks).
+type Status string
+
+const (
+	// StatusUp the component is healthy.
+	StatusUp Status = "UP"
+	// StatusDown the component is not healthy.
+	StatusDown Status = "DOWN"
+)
+
+// CheckResult is the result of a single health check execution.
+type CheckResult struct {
+	Status    Status    `json:"status"`
+	Error     string    `json:"error,omitempty"`
+	Took      string    `json:"took"`
+	CheckTime time.Time `json:"check_time"`
+
+	err error
+}
+
+// Err returns the error returned by the check, if any.
+func (r CheckResult) Err() error {
+	return r.err
+}
+
+// Report is an aggregation of [CheckResult] entries.
+type Report struct {
+	Status Status                 `json:"status"`
+	Checks map[string]CheckResult `json:"checks"`
+}
+
+// IsUp indicates whether every check of the report is healthy.
+func (r Report) IsUp() bool {
+	return r.Status == StatusUp
+}
+
+// - Registry -
+
+// Registry is a component holding a set of named [Checker] instances.
+//
+// Checks are executed concurrently, each of them using its own timeout (see [WithCheckTimeout]). Results may be
+// cached (see [WithCheckCacheTTL]) to avoid overwhelming dependencies when health endpoints are called frequently.
+type Registry struct {
+	options registryOptions
+
+	mu     sync.RWMutex
+	checks []*registeredCheck
+}
+
+type registeredCheck struct {
+	name     string
+	checker  Checker
+	kind     Kind
+	timeout  time.Duration
+	cacheTTL time.Duration
+
+	cacheMu    sync.Mutex
+	lastResult *CheckResult
+}
+
+// NewRegistry creates a new [Registry] instance.
+func NewRegistry(opts ...RegistryOption) *Registry {
+	options := registryOptions{}
+	for _, opt := range opts {
+		opt(&options)
+	}
+	options.timeout = lo.CoalesceOrEmpty(options.timeout, 5*time.Second)
+	return &Registry{
+		options: options,
+		checks:  make([]*registeredCheck, 0),
+	}
+}
+
+// Register registers a [Checker] identified by `name`.
+//
+// Checks are registered as [KindReadiness] by default, use [WithCheckKind] to change this behavior.
+func (r *Registry) Register(name string, checker Checker, opts ...CheckOption) error {
+	options := checkOptions{
+		kind:     KindReadiness,
+		timeout:  r.options.timeout,
+		cacheTTL: r.options.cacheTTL,
+	}
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, c := range r.checks {
+		if c.name == name {
+			return fmt.Errorf("%w: %s", ErrCheckAlreadyRegistered, name)
+		}
+	}
+	r.checks = append(r.checks, &registeredCheck{
+		name:     name,
+		checker:  checker,
+		kind:     options.kind,
+		timeout:  options.timeout,
+		cacheTTL: options.cacheTTL,
+	})
+	return nil
+}
+
+// MustRegister registers a [Checker] identified by `name`.
+//
+// This routine will panic if any error occurs.
+func (r *Registry) MustRegister(name string, checker Checker, opts ...CheckOption) {
+	if err := r.Register(name, checker, opts...); err != nil {
+		panic(err)
+	}
+}
+
+// Check executes all registered checks matching `kind` concurrently and aggregates their results.
+func (r *Registry) Check(ctx context.Context, kind Kind) Report {
+	r.mu.RLock()
+	checks := lo.Filter(r.checks, func(c *registeredCheck, _ int) bool {
+		return c.kind&kind != 0
+	})
+	r.mu.RUnlock()
+
+	report := Report{
+		Status: StatusUp,
+		Checks: make(map[string]CheckResult, len(checks)),
+	}
+	results := make([]CheckResult, len(checks))
+	wg := sync.WaitGroup{}
+	wg.Add(len(checks))
+	for i := range checks {
+		go func(i int) {
+			defer wg.Done()
+			results[i] = checks[i].run(ctx)
+		}(i)
+	}
+	wg.Wait()
+	for i, result := range results {
+		report.Checks[checks[i].name] = result
+		if result.Status != StatusUp {
+			report.Status = StatusDown
+		}
+	}
+	return report
+}
+
+func (c *registeredCheck) run(ctx context.Context) CheckResult {
+	// the lock is not held while probing so slow checkers do not block concurrent runs
+	if result, ok := c.cachedResult(); ok {
+		return result
+	}
+
+	scopedCtx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+	start := time.Now()
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- c.checker.Check(scopedCtx)
+	}()
+	var err error
+	select {
+	case err = <-errCh:
+	case <-scopedCtx.Done():
+		// do not wait for checkers ignoring context cancellation
+		err = scopedCtx.Err()
+	}
+
+	result := CheckResult{
+		Status:    StatusUp,
+		Took:      time.Since(start).String(),
+		CheckTime: start,
+	}
+	if err != nil {
+		result.Status = StatusDown
+		result.Error = err.Error()
+		result.err = err
+	}
+	c.cacheMu.Lock()
+	c.lastResult = &result
+	c.cacheMu.Unlock()
+	return result
+}
+
+// cachedResult returns the last result of the check if it has not expired yet.
+func (c *registeredCheck) cachedResult() (CheckResult, bool) {
+	c.cacheMu.Lock()
+	defer c.cacheMu.Unlock()
+	if c.lastResult != nil && c.cacheTTL > 0 && time.Since(c.lastResult.CheckTime) < c.cacheTTL {
+		return *c.lastResult, true
+	}
+	return CheckResult{}, false
+}
+
+// -- Options --
+
+type registryOptions struct {
+	timeout  time.Duration
+	cacheTTL time.Duration
+}
+
+// RegistryOption is a routine used to set up [Registry] optional configuration.
+type RegistryOption func(*registryOptions)
+
+// WithRegistryTimeout sets the default timeout of every check registered in a [Registry]. Defaults to 5 seconds.
+func WithRegistryTimeout(timeout time.Duration) RegistryOption {
+	return func(o *registryOptions) {
+		o.timeout = timeout
+	}
+}
+
+// WithRegistryCacheTTL sets the default time-to-live of cached results of every check registered in a [Registry].
+//
+// Results are not cached by default.
+func WithRegistryCacheTTL(ttl time.Duration) RegistryOption {
+	return func(o *registryOptions) {
+		o.cacheTTL = ttl
+	}
+}
+
+type checkOptions struct {
+	kind     Kind
+	timeout  time.Duration
+	cacheTTL time.Duration
+}
+
+// CheckOption is a routine used to set up optional configuration of a [Checker] registered in a [Registry].
+type CheckOption func(*checkOptions)
+
+// WithCheckKind sets the [Kind] of the check.
+func WithCheckKind(kind Kind) CheckOption {
+	return func(o *checkOptions) {
+		o.kind = kind
+	}
+}
+
+// WithCheckTimeout sets the timeout of the check.
+func WithCheckTimeout(timeout time.Duration) CheckOption {
+	return func(o *checkOptions) {
+		o.timeout = timeout
+	}
+}
+
+// WithCheckCacheTTL sets the time-to-live of the cached check result.
+func WithCheckCacheTTL(ttl time.Duration) CheckOption {
+	return func(o *checkOptions) {
+		o.cacheTTL = ttl
+	}
+}
@@ -0,0 +1,91 @@
+package health_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"github.com/bosonicalio/geck/observability/health"
+	"github.com/bosonicalio/geck/observabilitymock/healthmock"
+)
+
+func TestRegistry_Check(t *testing.T) {
+	ctx := context.Background()
+	ctrl := gomock.NewController(t)
+	errPing := errors.New("connection refused")
+	database := healthmock.NewMockPinger(ctrl)
+	database.EXPECT().PingContext(gomock.Any()).Return(errPing).Times(1)
+	probe := healthmock.NewMockReadinessProbe(ctrl)
+	probe.EXPECT().IsReady().Return(true).Times(3)
+
+	registry := health.NewRegistry()
+	registry.MustRegister("database", health.NewSQLChecker(database), health.WithCheckCacheTTL(time.Minute))
+	registry.MustRegister("runner", health.NewReadinessChecker(probe), health.WithCheckKind(health.KindAll))
+	err := registry.Register("runner", health.NewReadinessChecker(probe))
+	assert.ErrorIs(t, err, health.ErrCheckAlreadyRegistered)
+
+	report := registry.Check(ctx, health.KindReadiness)
+	assert.False(t, report.IsUp())
+	require.Len(t, report.Checks, 2)
+	assert.Equal(t, health.StatusDown, report.Checks["database"].Status)
+	assert.ErrorIs(t, report.Checks["database"].Err(), errPing)
+	assert.Equal(t, health.StatusUp, report.Checks["runner"].Status)
+
+	// cached result, database is not pinged again
+	report = registry.Check(ctx, health.KindReadiness)
+	assert.False(t, report.IsUp())
+	assert.Equal(t, errPing.Error(), report.Checks["database"].Error)
+
+	report = registry.Check(ctx, health.KindLiveness)
+	assert.True(t, report.IsUp())
+	assert.Len(t, report.Checks, 1)
+}
+
+func TestRegistry_Check_Timeout(t *testing.T) {
+	registry := health.NewRegistry(health.WithRegistryTimeout(10 * time.Millisecond))
+	block := make(chan struct{})
+	defer close(block)
+	registry.MustRegister("stuck", health.CheckerFunc(func(_ context.Context) error {
+		<-block // ignores context cancellation
+		return nil
+	}))
+
+	report := registry.Check(context.Background(), health.KindAll)
+	assert.False(t, report.IsUp())
+	assert.ErrorIs(t, report.Checks["stuck"].Err(), context.DeadlineExceeded)
+}
+
+func TestRegistry_Check_Concurrent(t *testing.T) {
+	registry := health.NewRegistry()
+	running := make(chan struct{}, 2)
+	release := make(chan struct{})
+	registry.MustRegister("slow", health.CheckerFunc(func(_ context.Context) error {
+		running <- struct{}{}
+		<-release
+		return nil
+	}), health.WithCheckCacheTTL(time.Minute))
+
+	reports := make(chan health.Report, 2)
+	for range 2 {
+		go func() {
+			reports <- registry.Check(context.Background(), health.KindAll)
+		}()
+	}
+	// concurrent runs probe the checker in parallel instead of waiting for each other
+	for range 2 {
+		select {
+		case <-running:
+		case <-time.After(time.Second):
+			t.Fatal("checker was not run concurrently")
+		}
+	}
+	close(release)
+	for range 2 {
+		assert.True(t, (<-reports).IsUp())
+	}
+}
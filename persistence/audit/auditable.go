@@ -17,14 +17,16 @@ const _defaultPrincipalUsername = "unknown"
 // Embed this structure into your entities/aggregates to enhance and control write operations.
 // Call [New] routine to create an instance with default values.
 //
+// Fields are tagged with their default column names (`db` struct tags) for SQL mappers.
+//
 // Implements [persistence.Storable] interface.
 type Auditable struct {
-	CreateTime     time.Time
-	CreateBy       string
-	LastUpdateTime time.Time
-	LastUpdateBy   string
-	Version        int64
-	IsDeleted      bool
+	CreateTime     time.Time `db:"create_time"`
+	CreateBy       string    `db:"create_by"`
+	LastUpdateTime time.Time `db:"last_update_time"`
+	LastUpdateBy   string    `db:"last_update_by"`
+	Version        int64     `db:"version"`
+	IsDeleted      bool      `db:"is_deleted"`
 
 	loc *time.Location
 }
@@ -0,0 +1,192 @@
+package persistence
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/bosonicalio/geck/persistence/paging"
+)
+
+// FilterOperator is the comparison applied by a [Filter].
+type FilterOperator uint8
+
+const (
+	// OperatorEq matches values equal to the [Filter] value.
+	OperatorEq FilterOperator = iota + 1
+	// OperatorIn matches values equal to any of the [Filter] values.
+	OperatorIn
+	// OperatorRange matches values within the [Filter] bounds: from (inclusive) and to (exclusive).
+	// A nil bound indicates the range is unbounded on that side.
+	OperatorRange
+	// OperatorPrefix matches string values starting with the [Filter] value.
+	OperatorPrefix
+)
+
+// Filter is a condition on a field of the items retrieved using a [Criteria].
+//
+// Use [Eq], [In], [Range] and [Prefix] to create filters.
+type Filter struct {
+	Field    string
+	Operator FilterOperator
+	Values   []any
+}
+
+// Eq creates a [Filter] matching items which `field` equals `v`.
+func Eq(field string, v any) Filter {
+	return Filter{Field: field, Operator: OperatorEq, Values: []any{v}}
+}
+
+// In creates a [Filter] matching items which `field` equals any of `values`.
+func In(field string, values ...any) Filter {
+	return Filter{Field: field, Operator: OperatorIn, Values: values}
+}
+
+// Range creates a [Filter] matching items which `field` is greater than or equal to `from` and less than `to`.
+// Pass nil to leave a bound open.
+func Range(field string, from, to any) Filter {
+	return Filter{Field: field, Operator: OperatorRange, Values: []any{from, to}}
+}
+
+// Prefix creates a [Filter] matching items which `field` starts with `prefix`.
+func Prefix(field, prefix string) Filter {
+	return Filter{Field: field, Operator: OperatorPrefix, Values: []any{prefix}}
+}
+
+// SortOrder is the order of the items retrieved using a [Criteria] based on a field.
+type SortOrder struct {
+	Field        string
+	IsDescending bool
+}
+
+// Asc creates an ascending [SortOrder] for `field`.
+func Asc(field string) SortOrder {
+	return SortOrder{Field: field}
+}
+
+// Desc creates a descending [SortOrder] for `field`.
+func Desc(field string) SortOrder {
+	return SortOrder{Field: field, IsDescending: true}
+}
+
+// Criteria is the specification of the items to retrieve from a [QueryRepository].
+//
+// Filters are combined using a logical AND. Field names are defined by each [QueryRepository] implementation
+// (e.g. column names).
+type Criteria struct {
+	Filters []Filter
+	Sorts   []SortOrder
+	// Limit is the maximum number of items per page. Defaults to 100.
+	Limit int
+}
+
+// QueryRepository component used to retrieve T instances matching a [Criteria] from a storage system.
+//
+// Implementations accept [paging.WithPageToken] to retrieve the pages following or preceding a previous
+// call; page tokens are bound to the [Criteria] they were created for, hence, callers must pass the same
+// `criteria` along with them. Use [ResolveCriteria] and [NewCriteriaPage] to handle page tokens consistently.
+type QueryRepository[T any] interface {
+	Find(ctx context.Context, criteria Criteria, opts ...paging.Option) (*paging.Page[T], error)
+}
+
+// -- Paging --
+
+const _defaultCriteriaLimit = 100
+
+var (
+	// ErrInvalidPageToken is returned when a page token cannot be parsed into a [CriteriaPageToken] or when it
+	// was created for a [Criteria] other than the given one.
+	ErrInvalidPageToken = errors.New("geck.persistence: invalid page token")
+)
+
+// CriteriaPageToken is the content of page tokens produced by [NewCriteriaPage].
+//
+// Page tokens do not hold the [Criteria] filters and sorts (their values would lose their concrete types once
+// decoded) but a hash of them, so tokens cannot be used with a [Criteria] other than the one they were created for.
+type CriteriaPageToken struct {
+	// Criteria is the [Criteria] used to retrieve items. Not held by page tokens.
+	Criteria     Criteria `msgpack:"-"`
+	Offset       int
+	Limit        int
+	CriteriaHash string
+}
+
+// ResolveCriteria resolves the [Criteria] and offset to use by a [QueryRepository] from `criteria` and
+// `opts`.
+//
+// If a page token is set (see [paging.WithPageToken]), offset and limit are parsed from it. Returns
+// [ErrInvalidPageToken] if the token was created for a [Criteria] other than `criteria`. The limit set
+// using [paging.WithLimit] (if any) overrides the criteria limit.
+func ResolveCriteria(codec paging.TokenCodec, criteria Criteria,
+	opts ...paging.Option) (CriteriaPageToken, error) {
+	options := paging.Options{}
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	criteriaHash, err := hashCriteria(criteria)
+	if err != nil {
+		return CriteriaPageToken{}, err
+	}
+	token := CriteriaPageToken{Limit: criteria.Limit}
+	if options.HasPageToken() {
+		if err = codec.DecodeToken(options.PageToken(), &token); err != nil {
+			return CriteriaPageToken{}, errors.Join(ErrInvalidPageToken, err)
+		} else if token.CriteriaHash != criteriaHash {
+			return CriteriaPageToken{}, fmt.Errorf("%w: token was created for another criteria", ErrInvalidPageToken)
+		}
+	}
+	token.Criteria = criteria
+	token.CriteriaHash = criteriaHash
+	token.Criteria.Limit = token.Limit
+	if options.Limit() > 0 {
+		token.Criteria.Limit = options.Limit()
+	}
+	if token.Criteria.Limit <= 0 {
+		token.Criteria.Limit = _defaultCriteriaLimit
+	}
+	token.Limit = token.Criteria.Limit
+	token.Offset = max(token.Offset, 0)
+	return token, nil
+}
+
+// hashCriteria hashes the filters and sorts of `criteria`.
+func hashCriteria(criteria Criteria) (string, error) {
+	audience, err := paging.NewTokenAudience("", struct {
+		Filters []Filter
+		Sorts   []SortOrder
+	}{Filters: criteria.Filters, Sorts: criteria.Sorts})
+	if err != nil {
+		return "", err
+	}
+	return audience.QueryHash, nil
+}
+
+// NewCriteriaPage creates a [paging.Page] holding `items`, the items retrieved using `token` (see
+// [ResolveCriteria]). `hasMore` indicates whether more items are available after `items`.
+//
+// Next and previous page tokens are produced from `token`, so subsequent calls using the same [Criteria] keep
+// the same limit.
+func NewCriteriaPage[T any](codec paging.TokenCodec, token CriteriaPageToken, items []T,
+	hasMore bool) (*paging.Page[T], error) {
+	page := &paging.Page[T]{
+		Items: items,
+	}
+	token.Limit = token.Criteria.Limit
+	var err error
+	if hasMore {
+		next := token
+		next.Offset = token.Offset + len(items)
+		if page.NextPageToken, err = codec.EncodeToken(next); err != nil {
+			return nil, err
+		}
+	}
+	if token.Offset > 0 {
+		prev := token
+		prev.Offset = max(token.Offset-token.Limit, 0)
+		if page.PreviousPageToken, err = codec.EncodeToken(prev); err != nil {
+			return nil, err
+		}
+	}
+	return page, nil
+}
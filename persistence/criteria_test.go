@@ -0,0 +1,83 @@
+package persistence_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bosonicalio/geck/persistence"
+	"github.com/bosonicalio/geck/persistence/paging"
+)
+
+func TestCriteriaPage(t *testing.T) {
+	key := paging.TokenCipherKey("0123456789abcdef")
+	criteria := persistence.Criteria{
+		Filters: []persistence.Filter{persistence.Eq("status", "active"), persistence.Prefix("name", "Jo")},
+		Sorts:   []persistence.SortOrder{persistence.Desc("create_time")},
+		Limit:   2,
+	}
+
+	token, err := persistence.ResolveCriteria(key, criteria)
+	require.NoError(t, err)
+	assert.Equal(t, criteria, token.Criteria)
+	assert.Zero(t, token.Offset)
+	assert.NotEmpty(t, token.CriteriaHash)
+
+	page, err := persistence.NewCriteriaPage(key, token, []string{"a", "b"}, true)
+	require.NoError(t, err)
+	assert.Empty(t, page.PreviousPageToken)
+	require.NotEmpty(t, page.NextPageToken)
+
+	next, err := persistence.ResolveCriteria(key, criteria, paging.WithPageToken(page.NextPageToken))
+	require.NoError(t, err)
+	assert.Equal(t, 2, next.Offset)
+	assert.Equal(t, criteria, next.Criteria)
+
+	page, err = persistence.NewCriteriaPage(key, next, []string{"c"}, false)
+	require.NoError(t, err)
+	assert.Empty(t, page.NextPageToken)
+	prev, err := persistence.ResolveCriteria(key, criteria, paging.WithPageToken(page.PreviousPageToken),
+		paging.WithLimit(10))
+	require.NoError(t, err)
+	assert.Equal(t, 0, prev.Offset)
+	assert.Equal(t, 10, prev.Criteria.Limit)
+
+	_, err = persistence.ResolveCriteria(key, criteria, paging.WithPageToken("invalid"))
+	assert.ErrorIs(t, err, persistence.ErrInvalidPageToken)
+
+	// page tokens are bound to the criteria they were created for
+	_, err = persistence.ResolveCriteria(key, persistence.Criteria{}, paging.WithPageToken(page.PreviousPageToken))
+	assert.ErrorIs(t, err, persistence.ErrInvalidPageToken)
+}
+
+func TestCriteriaPage_TypedValues(t *testing.T) {
+	key := paging.TokenCipherKey("0123456789abcdef")
+	id := uuid.New()
+	since := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	criteria := persistence.Criteria{
+		Filters: []persistence.Filter{persistence.Eq("id", id), persistence.Range("create_time", since, nil)},
+		Limit:   1,
+	}
+
+	token, err := persistence.ResolveCriteria(key, criteria)
+	require.NoError(t, err)
+	page, err := persistence.NewCriteriaPage(key, token, []string{"a"}, true)
+	require.NoError(t, err)
+
+	// filter values keep their concrete types on the following pages
+	next, err := persistence.ResolveCriteria(key, criteria, paging.WithPageToken(page.NextPageToken))
+	require.NoError(t, err)
+	assert.Equal(t, 1, next.Offset)
+	require.Len(t, next.Criteria.Filters, 2)
+	assert.IsType(t, uuid.UUID{}, next.Criteria.Filters[0].Values[0])
+	assert.Equal(t, id, next.Criteria.Filters[0].Values[0])
+	assert.Equal(t, since, next.Criteria.Filters[1].Values[0])
+
+	other := criteria
+	other.Filters = []persistence.Filter{persistence.Eq("id", uuid.New()), persistence.Range("create_time", since, nil)}
+	_, err = persistence.ResolveCriteria(key, other, paging.WithPageToken(page.NextPageToken))
+	assert.ErrorIs(t, err, persistence.ErrInvalidPageToken)
+}
@@ -0,0 +1,224 @@
+package paging
+
+import (
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// ErrInvalidCursor is returned when a [Cursor] does not match the [Keyset] it is used with.
+var ErrInvalidCursor = errors.New("geck.paging: invalid cursor")
+
+// - Cursor -
+
+// Cursor is a keyset (seek) pagination position. It holds the sort key values of the item bounding a page
+// and the traversal direction.
+//
+// Forward cursors point to the items following the item holding Values while backward cursors point to the items
+// preceding it. A cursor without values points to the first page (forward) or the last page (backward).
+//
+// Use [NewCursorPage] and [ParseCursor] to exchange cursors through page tokens. As cursors do not hold filters,
+// prefer a [TokenKeyring] bound to an audience hashing them (see [NewTokenAudience]).
+//
+// Values held by page tokens are [driver.Value] instances (e.g. UUIDs implementing [driver.Valuer] are held as
+// strings), so they are usable as SQL arguments once parsed.
+type Cursor struct {
+	Values     []any
+	IsBackward bool
+}
+
+// ParseCursor resolves the [Cursor] to fetch a page with from `opts`.
+//
+// If a page token is set (see [WithPageToken]), the cursor is parsed from it. Otherwise, the cursor points to the
+// first page, or to the last page if [WithReverse] is set (e.g. by an [Iterator] in reverse mode).
+func ParseCursor(codec TokenCodec, opts ...Option) (Cursor, error) {
+	options := Options{}
+	for _, opt := range opts {
+		opt(&options)
+	}
+	if !options.HasPageToken() {
+		return Cursor{IsBackward: options.IsReverse()}, nil
+	}
+	cursor := Cursor{}
+	if err := codec.DecodeToken(options.PageToken(), &cursor); err != nil {
+		return Cursor{}, errors.Join(ErrInvalidCursor, err)
+	}
+	var err error
+	if cursor.Values, err = driverValues(cursor.Values); err != nil {
+		return Cursor{}, errors.Join(ErrInvalidCursor, err)
+	}
+	return cursor, nil
+}
+
+// NewCursorPage creates a [Page] holding `items`, the items fetched using `cursor` and the clauses built by
+// [Keyset.Clauses]. `hasMore` indicates whether more items are available in the traversal direction.
+// `keyFunc` returns the sort key values of an item, in the same order as the [Keyset] columns.
+//
+// Items fetched using backward cursors are reversed, so pages always hold items in keyset order.
+func NewCursorPage[T any](codec TokenCodec, cursor Cursor, items []T, hasMore bool,
+	keyFunc func(T) []any) (*Page[T], error) {
+	if cursor.IsBackward {
+		slices.Reverse(items)
+	}
+	page := &Page[T]{
+		Items: items,
+	}
+	if len(items) == 0 {
+		return page, nil
+	}
+
+	// a cursor with values indicates items were found in the opposite direction
+	hasNext, hasPrevious := hasMore, len(cursor.Values) > 0
+	if cursor.IsBackward {
+		hasNext, hasPrevious = hasPrevious, hasMore
+	}
+	if hasNext {
+		values, err := driverValues(keyFunc(items[len(items)-1]))
+		if err != nil {
+			return nil, err
+		}
+		if page.NextPageToken, err = codec.EncodeToken(Cursor{Values: values}); err != nil {
+			return nil, err
+		}
+	}
+	if hasPrevious {
+		values, err := driverValues(keyFunc(items[0]))
+		if err != nil {
+			return nil, err
+		}
+		if page.PreviousPageToken, err = codec.EncodeToken(Cursor{Values: values, IsBackward: true}); err != nil {
+			return nil, err
+		}
+	}
+	return page, nil
+}
+
+// driverValues converts `values` into [driver.Value] instances, so they keep a type usable as SQL arguments once
+// encoded into page tokens (e.g. integers decoded using the smallest type are widened to int64).
+func driverValues(values []any) ([]any, error) {
+	if len(values) == 0 {
+		return values, nil
+	}
+	converted := make([]any, 0, len(values))
+	for _, v := range values {
+		value, err := driver.DefaultParameterConverter.ConvertValue(v)
+		if err != nil {
+			return nil, err
+		}
+		converted = append(converted, value)
+	}
+	return converted, nil
+}
+
+// - Keyset -
+
+// KeysetColumn is a column of the sort key used by a [Keyset].
+type KeysetColumn struct {
+	// Name is the column name, written as-is into clauses (i.e. quote it if required).
+	Name         string
+	IsDescending bool
+}
+
+// Keyset is a SQL builder for keyset (seek) pagination. Columns MUST form a unique sort key (e.g. ending with
+// the primary key) so pages neither skip nor repeat items.
+type Keyset struct {
+	Columns []KeysetColumn
+	// Placeholder returns the bind parameter placeholder for the n-th argument (starting from 1).
+	// Defaults to `?`.
+	Placeholder func(n int) string
+}
+
+// KeysetClauses are the SQL clauses built by [Keyset.Clauses].
+type KeysetClauses struct {
+	// Where holds the predicate selecting the items after (or before) the cursor, without the WHERE keyword.
+	// Empty if the cursor has no values.
+	Where string
+	// OrderBy holds the sort orders matching the cursor direction, without the ORDER BY keyword.
+	OrderBy string
+	// Args holds the arguments of the placeholders written into Where.
+	Args []any
+}
+
+// Clauses builds the clauses fetching the page pointed by `cursor`. Apply the page size using a LIMIT clause.
+//
+// Backward cursors reverse the sort orders, so items are fetched starting from the cursor; [NewCursorPage]
+// restores their order.
+//
+// Predicates use row value comparisons (e.g. `(a, b) > ($1, $2)`) when every column is sorted in the same
+// direction, otherwise, comparisons are expanded (e.g. `a > $1 OR (a = $2 AND b < $3)`). Placeholders are
+// numbered starting from `argOffset` + 1, so clauses can be appended to statements already holding arguments.
+func (k Keyset) Clauses(cursor Cursor, argOffset int) (KeysetClauses, error) {
+	if len(k.Columns) == 0 || (len(cursor.Values) > 0 && len(cursor.Values) != len(k.Columns)) {
+		return KeysetClauses{}, fmt.Errorf("%w: expected %d values, got %d", ErrInvalidCursor, len(k.Columns),
+			len(cursor.Values))
+	}
+	placeholderFunc := k.Placeholder
+	if placeholderFunc == nil {
+		placeholderFunc = func(_ int) string {
+			return "?"
+		}
+	}
+
+	clauses := KeysetClauses{}
+	orders := make([]string, 0, len(k.Columns))
+	// isGreater indicates whether items after the cursor hold greater values, per column
+	isGreater := make([]bool, 0, len(k.Columns))
+	for _, col := range k.Columns {
+		isAscending := col.IsDescending == cursor.IsBackward
+		isGreater = append(isGreater, isAscending)
+		if isAscending {
+			orders = append(orders, col.Name)
+		} else {
+			orders = append(orders, col.Name+" DESC")
+		}
+	}
+	clauses.OrderBy = strings.Join(orders, ", ")
+	if len(cursor.Values) == 0 {
+		return clauses, nil
+	}
+
+	placeholder := func(v any) string {
+		clauses.Args = append(clauses.Args, v)
+		return placeholderFunc(argOffset + len(clauses.Args))
+	}
+	operator := func(i int) string {
+		if isGreater[i] {
+			return " > "
+		}
+		return " < "
+	}
+
+	if !slices.Contains(isGreater, !isGreater[0]) {
+		if len(k.Columns) == 1 {
+			clauses.Where = k.Columns[0].Name + operator(0) + placeholder(cursor.Values[0])
+			return clauses, nil
+		}
+		names := make([]string, 0, len(k.Columns))
+		placeholders := make([]string, 0, len(k.Columns))
+		for i, col := range k.Columns {
+			names = append(names, col.Name)
+			placeholders = append(placeholders, placeholder(cursor.Values[i]))
+		}
+		clauses.Where = "(" + strings.Join(names, ", ") + ")" + operator(0) + "(" + strings.Join(placeholders, ", ") +
+			")"
+		return clauses, nil
+	}
+
+	terms := make([]string, 0, len(k.Columns))
+	for i := range k.Columns {
+		conditions := make([]string, 0, i+1)
+		for j := range i {
+			conditions = append(conditions, k.Columns[j].Name+" = "+placeholder(cursor.Values[j]))
+		}
+		conditions = append(conditions, k.Columns[i].Name+operator(i)+placeholder(cursor.Values[i]))
+		if len(conditions) == 1 {
+			terms = append(terms, conditions[0])
+			continue
+		}
+		terms = append(terms, "("+strings.Join(conditions, " AND ")+")")
+	}
+	clauses.Where = "(" + strings.Join(terms, " OR ") + ")"
+	return clauses, nil
+}
@@ -0,0 +1,163 @@
+package paging_test
+
+import (
+	"context"
+	"io"
+	"slices"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bosonicalio/geck/persistence/paging"
+)
+
+func TestKeyset_Clauses(t *testing.T) {
+	placeholder := func(n int) string {
+		return "$" + strconv.Itoa(n)
+	}
+
+	t.Run("same direction", func(t *testing.T) {
+		keyset := paging.Keyset{
+			Columns:     []paging.KeysetColumn{{Name: "create_time"}, {Name: "id"}},
+			Placeholder: placeholder,
+		}
+		clauses, err := keyset.Clauses(paging.Cursor{Values: []any{"2025-01-01", "123"}}, 1)
+		require.NoError(t, err)
+		assert.Equal(t, "(create_time, id) > ($2, $3)", clauses.Where)
+		assert.Equal(t, "create_time, id", clauses.OrderBy)
+		assert.Equal(t, []any{"2025-01-01", "123"}, clauses.Args)
+
+		clauses, err = keyset.Clauses(paging.Cursor{Values: []any{"2025-01-01", "123"}, IsBackward: true}, 0)
+		require.NoError(t, err)
+		assert.Equal(t, "(create_time, id) < ($1, $2)", clauses.Where)
+		assert.Equal(t, "create_time DESC, id DESC", clauses.OrderBy)
+
+		clauses, err = keyset.Clauses(paging.Cursor{IsBackward: true}, 0)
+		require.NoError(t, err)
+		assert.Empty(t, clauses.Where)
+		assert.Equal(t, "create_time DESC, id DESC", clauses.OrderBy)
+
+		_, err = keyset.Clauses(paging.Cursor{Values: []any{"123"}}, 0)
+		assert.ErrorIs(t, err, paging.ErrInvalidCursor)
+	})
+
+	t.Run("mixed directions", func(t *testing.T) {
+		keyset := paging.Keyset{
+			Columns: []paging.KeysetColumn{{Name: "score", IsDescending: true}, {Name: "id"}},
+		}
+		clauses, err := keyset.Clauses(paging.Cursor{Values: []any{10, "123"}}, 0)
+		require.NoError(t, err)
+		assert.Equal(t, "(score < ? OR (score = ? AND id > ?))", clauses.Where)
+		assert.Equal(t, "score DESC, id", clauses.OrderBy)
+		assert.Equal(t, []any{10, 10, "123"}, clauses.Args)
+	})
+}
+
+// fetchKeyset simulates a keyset paginated source of sorted integers.
+func fetchKeyset(t *testing.T, key paging.TokenCipherKey, source []int) paging.FetchFunc[int] {
+	return func(_ context.Context, opts ...paging.Option) (*paging.Page[int], error) {
+		options := paging.Options{}
+		for _, opt := range opts {
+			opt(&options)
+		}
+		cursor, err := paging.ParseCursor(key, opts...)
+		require.NoError(t, err)
+
+		items := slices.Clone(source)
+		if cursor.IsBackward {
+			slices.Reverse(items)
+		}
+		if len(cursor.Values) > 0 {
+			// integers are decoded as driver values
+			bound, ok := cursor.Values[0].(int64)
+			require.True(t, ok)
+			items = slices.DeleteFunc(items, func(v int) bool {
+				if cursor.IsBackward {
+					return v >= int(bound)
+				}
+				return v <= int(bound)
+			})
+		}
+		hasMore := len(items) > options.Limit()
+		items = items[:min(len(items), options.Limit())]
+		return paging.NewCursorPage(key, cursor, items, hasMore, func(v int) []any {
+			return []any{v}
+		})
+	}
+}
+
+func TestIterator_Keyset(t *testing.T) {
+	key := paging.TokenCipherKey("0123456789abcdef")
+	source := []int{1, 2, 3, 4, 5}
+
+	collect := func(iterator *paging.Iterator[int]) []int {
+		items := make([]int, 0)
+		for iterator.HasNext() {
+			item, err := iterator.Next()
+			if err == io.EOF {
+				break
+			}
+			require.NoError(t, err)
+			items = append(items, item)
+		}
+		return items
+	}
+
+	forward := paging.NewIterator(context.Background(), fetchKeyset(t, key, source), paging.WithIteratorPageSize(2))
+	assert.Equal(t, []int{1, 2, 3, 4, 5}, collect(forward))
+
+	reverse := paging.NewIterator(context.Background(), fetchKeyset(t, key, source), paging.WithIteratorPageSize(2),
+		paging.WithIteratorReverse(true))
+	assert.Equal(t, []int{5, 4, 3, 2, 1}, collect(reverse))
+}
+
+func TestCursorPage_TypedValues(t *testing.T) {
+	type event struct {
+		CreateTime time.Time
+		ID         uuid.UUID
+	}
+	key := paging.TokenCipherKey("0123456789abcdef")
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	events := []event{
+		{CreateTime: start, ID: uuid.New()},
+		{CreateTime: start.Add(time.Minute), ID: uuid.New()},
+		{CreateTime: start.Add(time.Hour), ID: uuid.New()},
+	}
+	keyFunc := func(e event) []any {
+		return []any{e.CreateTime, e.ID}
+	}
+	keyset := paging.Keyset{Columns: []paging.KeysetColumn{{Name: "create_time"}, {Name: "id"}}}
+
+	page, err := paging.NewCursorPage(key, paging.Cursor{}, events[:2], true, keyFunc)
+	require.NoError(t, err)
+	require.NotEmpty(t, page.NextPageToken)
+
+	// key values are decoded using types usable as SQL arguments
+	cursor, err := paging.ParseCursor(key, paging.WithPageToken(page.NextPageToken))
+	require.NoError(t, err)
+	clauses, err := keyset.Clauses(cursor, 0)
+	require.NoError(t, err)
+	assert.Equal(t, "(create_time, id) > (?, ?)", clauses.Where)
+	require.Len(t, clauses.Args, 2)
+	createTime, ok := clauses.Args[0].(time.Time)
+	require.True(t, ok)
+	assert.True(t, events[1].CreateTime.Equal(createTime))
+	assert.Equal(t, events[1].ID.String(), clauses.Args[1])
+
+	page, err = paging.NewCursorPage(key, cursor, events[2:], false, keyFunc)
+	require.NoError(t, err)
+	assert.Empty(t, page.NextPageToken)
+	cursor, err = paging.ParseCursor(key, paging.WithPageToken(page.PreviousPageToken))
+	require.NoError(t, err)
+	assert.True(t, cursor.IsBackward)
+	assert.Equal(t, events[2].ID.String(), cursor.Values[1])
+
+	_, err = paging.NewCursorPage(key, paging.Cursor{}, events[:1], true, func(e event) []any {
+		return []any{e} // not convertible into a driver value
+	})
+	assert.Error(t, err)
+}
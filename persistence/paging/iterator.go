@@ -13,7 +13,12 @@
 package paging
 
 import (
+	"context"
+	"errors"
 	"io"
+	"iter"
+	"slices"
+	"sync/atomic"
 )
 
 // - Iterator -
@@ -23,12 +28,15 @@ const _defaultPageSize = 100
 // Iterator is a generic iterator for paginated data.
 // It fetches data using a provided [FetchFunc] and allows iteration over the items.
 // The iterator supports both forward and reverse pagination, controlled by the [WithIteratorReverse] option.
+// In reverse mode, the iterator follows previous page tokens and returns the items of each page from last to first;
+// fetch functions are called with [WithReverse], so keyset paginated sources (see [ParseCursor]) start from the
+// last page.
 //
 // Usage example:
 // ```go
 // ctx := context.Background()
 //
-//	fetchFunc := func(opts ...paging.Option) (*paging.Page[YourType], error) {
+//	fetchFunc := func(ctx context.Context, opts ...paging.Option) (*paging.Page[YourType], error) {
 //	    // Implement your fetching logic here, e.g., querying a database or an API.
 //	    return yourPage, nil
 //	}
@@ -38,16 +46,14 @@ const _defaultPageSize = 100
 //	paging.WithIteratorPageSize(50), // Optional: set custom page size
 //	paging.WithIteratorReverse(false), // Optional: set reverse pagination
 //	paging.WithIteratorPageToken("your-page-token"), // Optional: set initial page token
+//	paging.WithIteratorPrefetch(1), // Optional: fetch the next page in the background
 //
 // )
+// defer iterator.Close()
 //
-//	for iterator.HasNext() {
-//	    item, err := iterator.Next()
+//	for item, err := range iterator.All() {
 //	    if err != nil {
-//	        if err == io.EOF {
-//	            break // No more items to iterate
-//	        }
-//	        // Handle other errors
+//	        // Handle errors
 //	        panic(err)
 //	    }
 //	    // Process the item
@@ -56,7 +62,10 @@ const _defaultPageSize = 100
 //
 // The iterator will automatically handle pagination, fetching new pages as needed.
 // The items are returned in the order they are fetched, and the iterator will stop when there are no more items to fetch.
+//
+// Pages are fetched using the context passed to [NewIterator], so iterations can be cancelled.
 type Iterator[T any] struct {
+	ctx          context.Context
 	fetchFunc    FetchFunc[T]
 	currentIndex int
 	items        []T
@@ -64,24 +73,41 @@ type Iterator[T any] struct {
 	isReverse     bool
 	pageSize      int
 	lastPageToken string
+
+	prefetchDepth int
+	pages         chan pageResult[T]
+	// cancel stops background fetches, nil if prefetching is disabled.
+	cancel   context.CancelFunc
+	isClosed atomic.Bool
 }
 
 // FetchFunc is a function type that defines how to fetch a page of items.
-type FetchFunc[T any] func(opts ...Option) (*Page[T], error)
+type FetchFunc[T any] func(ctx context.Context, opts ...Option) (*Page[T], error)
+
+type pageResult[T any] struct {
+	page *Page[T]
+	err  error
+}
 
 // NewIterator creates a new Iterator instance with the provided fetch function and options.
-func NewIterator[T any](fetchFunc FetchFunc[T], opts ...IteratorOption) *Iterator[T] {
+func NewIterator[T any](ctx context.Context, fetchFunc FetchFunc[T], opts ...IteratorOption) *Iterator[T] {
 	options := &iteratorOptions{}
 	for _, opt := range opts {
 		opt(options)
 	}
 	options.setDefaults()
-	return &Iterator[T]{
+	iterator := &Iterator[T]{
+		ctx:           ctx,
 		fetchFunc:     fetchFunc,
 		isReverse:     options.isReverse,
 		pageSize:      options.pageSize,
 		lastPageToken: options.pageToken,
+		prefetchDepth: options.prefetchDepth,
 	}
+	if iterator.prefetchDepth > 0 {
+		iterator.ctx, iterator.cancel = context.WithCancel(ctx)
+	}
+	return iterator
 }
 
 // HasNext checks if there are more items to iterate over.
@@ -102,6 +128,34 @@ func (i *Iterator[T]) Next() (T, error) {
 	return item, nil
 }
 
+// All returns an iterator over the remaining items, to be used with range-over-func loops.
+//
+// Iteration stops once there are no more items to fetch (no error is yielded) or after yielding an error.
+func (i *Iterator[T]) All() iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		for i.HasNext() {
+			item, err := i.Next()
+			if errors.Is(err, io.EOF) {
+				return
+			} else if !yield(item, err) || err != nil {
+				return
+			}
+		}
+	}
+}
+
+// Close stops the iterator, cancelling in-flight fetches. Pages are no longer fetched afterward; loading a page
+// returns [context.Canceled].
+//
+// Close is required when prefetching (see [WithIteratorPrefetch]) and the iteration stops before every page was
+// fetched, so background fetches are released. Otherwise, calling it is optional.
+func (i *Iterator[T]) Close() {
+	i.isClosed.Store(true)
+	if i.cancel != nil {
+		i.cancel()
+	}
+}
+
 func (i *Iterator[T]) hasNextPage() bool {
 	return i.lastPageToken != ""
 }
@@ -111,12 +165,13 @@ func (i *Iterator[T]) loadNextPage() error {
 		return io.EOF
 	}
 
-	opts := make([]Option, 0, 2)
-	if i.lastPageToken != "" {
-		opts = append(opts, WithPageToken(i.lastPageToken))
+	var page *Page[T]
+	var err error
+	if i.prefetchDepth > 0 {
+		page, err = i.receivePage()
+	} else {
+		page, err = i.fetchPage(i.lastPageToken)
 	}
-	opts = append(opts, WithLimit(i.pageSize))
-	page, err := i.fetchFunc(opts...)
 	if err != nil {
 		return err
 	} else if page == nil || len(page.Items) == 0 {
@@ -128,22 +183,130 @@ func (i *Iterator[T]) loadNextPage() error {
 		i.items = i.items[:0] // Reset the result buffer to reuse it, but keep the capacity to avoid reallocating
 	}
 
+	i.lastPageToken = i.nextPageToken(page)
+	i.items = append(i.items, page.Items...)
 	if i.isReverse {
-		i.lastPageToken = page.PreviousPageToken
-	} else {
-		i.lastPageToken = page.NextPageToken
+		slices.Reverse(i.items)
 	}
-	i.items = append(i.items, page.Items...)
 	i.currentIndex = 0 // Reset the current index to the start of the new items
 	return nil
 }
 
+func (i *Iterator[T]) fetchPage(pageToken string) (*Page[T], error) {
+	if i.isClosed.Load() {
+		return nil, context.Canceled
+	} else if err := i.ctx.Err(); err != nil {
+		return nil, err
+	}
+	opts := make([]Option, 0, 3)
+	if pageToken != "" {
+		opts = append(opts, WithPageToken(pageToken))
+	}
+	opts = append(opts, WithLimit(i.pageSize))
+	if i.isReverse {
+		opts = append(opts, WithReverse(true))
+	}
+	return i.fetchFunc(i.ctx, opts...)
+}
+
+func (i *Iterator[T]) nextPageToken(page *Page[T]) string {
+	if i.isReverse {
+		return page.PreviousPageToken
+	}
+	return page.NextPageToken
+}
+
+// receivePage receives the next page fetched in the background, starting the background fetches on first call.
+func (i *Iterator[T]) receivePage() (*Page[T], error) {
+	if i.pages == nil {
+		i.pages = make(chan pageResult[T], i.prefetchDepth)
+		go i.prefetch(i.lastPageToken)
+	}
+	select {
+	case result, ok := <-i.pages:
+		if !ok {
+			// background fetches also stop when the iterator is cancelled, which is not the end of the pages
+			if err := i.ctx.Err(); err != nil {
+				return nil, err
+			}
+			return nil, io.EOF
+		}
+		return result.page, result.err
+	case <-i.ctx.Done():
+		return nil, i.ctx.Err()
+	}
+}
+
+// prefetch fetches pages until there are no more pages, an error occurs or the iterator is closed.
+func (i *Iterator[T]) prefetch(pageToken string) {
+	defer close(i.pages)
+	for {
+		page, err := i.fetchPage(pageToken)
+		select {
+		case i.pages <- pageResult[T]{page: page, err: err}:
+		case <-i.ctx.Done():
+			// best effort, receivePage reports the context error once the channel is closed anyway
+			select {
+			case i.pages <- pageResult[T]{err: i.ctx.Err()}:
+			default:
+			}
+			return
+		}
+		if err != nil || page == nil || len(page.Items) == 0 {
+			return
+		}
+		if pageToken = i.nextPageToken(page); pageToken == "" {
+			return
+		}
+	}
+}
+
+// - Helpers -
+
+// Collect fetches every item using `fetchFunc`. Use it for bounded data sets only as items are held in memory.
+func Collect[T any](ctx context.Context, fetchFunc FetchFunc[T], opts ...IteratorOption) ([]T, error) {
+	iterator := NewIterator(ctx, fetchFunc, opts...)
+	defer iterator.Close()
+	items := make([]T, 0)
+	for item, err := range iterator.All() {
+		if err != nil {
+			return items, err
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+// ForEachBatch fetches every page using `fetchFunc`, calling `fn` with the items of each page (in iteration order).
+// Stops at the first error returned by either `fetchFunc` or `fn`.
+//
+// The batch passed to `fn` is reused between calls, so it MUST NOT be retained.
+func ForEachBatch[T any](ctx context.Context, fetchFunc FetchFunc[T], fn func(ctx context.Context, batch []T) error,
+	opts ...IteratorOption) error {
+	iterator := NewIterator(ctx, fetchFunc, opts...)
+	defer iterator.Close()
+	for iterator.HasNext() {
+		err := iterator.loadNextPage()
+		if errors.Is(err, io.EOF) {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		if err = fn(ctx, iterator.items); err != nil {
+			return err
+		}
+		iterator.currentIndex = len(iterator.items)
+	}
+	return nil
+}
+
 // -- Options --
 
 type iteratorOptions struct {
-	pageSize  int
-	pageToken string
-	isReverse bool
+	pageSize      int
+	pageToken     string
+	isReverse     bool
+	prefetchDepth int
 }
 
 func (i *iteratorOptions) setDefaults() {
@@ -174,3 +337,11 @@ func WithIteratorPageToken(token string) IteratorOption {
 		opts.pageToken = token
 	}
 }
+
+// WithIteratorPrefetch sets the number of pages the iterator fetches in the background, ahead of the page being
+// iterated. Disabled (zero) by default, so pages are fetched on demand.
+func WithIteratorPrefetch(depth int) IteratorOption {
+	return func(opts *iteratorOptions) {
+		opts.prefetchDepth = depth
+	}
+}
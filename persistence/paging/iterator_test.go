@@ -13,9 +13,12 @@
 package paging_test
 
 import (
+	"context"
+	"errors"
 	"io"
 	"strconv"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -25,8 +28,8 @@ import (
 
 func TestIterator_Multipage(t *testing.T) {
 	iterCount := 0
-	iterator := paging.NewIterator(
-		func(opts ...paging.Option) (*paging.Page[string], error) {
+	iterator := paging.NewIterator(context.Background(),
+		func(_ context.Context, opts ...paging.Option) (*paging.Page[string], error) {
 			if iterCount == 2 {
 				return nil, nil
 			}
@@ -63,8 +66,8 @@ func TestIterator_Multipage(t *testing.T) {
 
 func TestIterator_Single_Page(t *testing.T) {
 	iterCount := 0
-	iterator := paging.NewIterator(
-		func(opts ...paging.Option) (*paging.Page[string], error) {
+	iterator := paging.NewIterator(context.Background(),
+		func(_ context.Context, opts ...paging.Option) (*paging.Page[string], error) {
 			if iterCount == 1 {
 				return nil, nil
 			}
@@ -92,3 +95,143 @@ func TestIterator_Single_Page(t *testing.T) {
 	assert.ErrorIs(t, err, io.EOF)
 	assert.Empty(t, item)
 }
+
+// fetchNumbers returns a [paging.FetchFunc] splitting numbers from 0 to `total` (excluded) into pages.
+func fetchNumbers(total int) paging.FetchFunc[int] {
+	return func(ctx context.Context, opts ...paging.Option) (*paging.Page[int], error) {
+		options := paging.Options{}
+		for _, opt := range opts {
+			opt(&options)
+		}
+		offset := 0
+		if options.HasPageToken() {
+			offset, _ = strconv.Atoi(options.PageToken())
+		}
+		end := min(offset+options.Limit(), total)
+		page := &paging.Page[int]{}
+		for i := offset; i < end; i++ {
+			page.Items = append(page.Items, i)
+		}
+		if end < total {
+			page.NextPageToken = strconv.Itoa(end)
+		}
+		return page, ctx.Err()
+	}
+}
+
+func TestIterator_All(t *testing.T) {
+	tests := []struct {
+		name string
+		opts []paging.IteratorOption
+	}{
+		{name: "on demand", opts: []paging.IteratorOption{paging.WithIteratorPageSize(3)}},
+		{name: "prefetch", opts: []paging.IteratorOption{paging.WithIteratorPageSize(3), paging.WithIteratorPrefetch(2)}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			iterator := paging.NewIterator(context.Background(), fetchNumbers(10), tt.opts...)
+			defer iterator.Close()
+			items := make([]int, 0, 10)
+			for item, err := range iterator.All() {
+				require.NoError(t, err)
+				items = append(items, item)
+			}
+			assert.Equal(t, []int{0, 1, 2, 3, 4, 5, 6, 7, 8, 9}, items)
+		})
+	}
+}
+
+func TestIterator_Cancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	iterator := paging.NewIterator(ctx, fetchNumbers(10), paging.WithIteratorPageSize(2),
+		paging.WithIteratorPrefetch(1))
+	defer iterator.Close()
+
+	count := 0
+	var lastErr error
+	for _, err := range iterator.All() {
+		if err != nil {
+			lastErr = err
+			break
+		}
+		if count++; count == 3 {
+			cancel()
+		}
+	}
+	assert.ErrorIs(t, lastErr, context.Canceled)
+	assert.Less(t, count, 10)
+}
+
+func TestCollect(t *testing.T) {
+	items, err := paging.Collect(context.Background(), fetchNumbers(5), paging.WithIteratorPageSize(2))
+	require.NoError(t, err)
+	assert.Equal(t, []int{0, 1, 2, 3, 4}, items)
+
+	errFetch := errors.New("fetch failed")
+	_, err = paging.Collect(context.Background(), func(_ context.Context, _ ...paging.Option) (*paging.Page[int], error) {
+		return nil, errFetch
+	})
+	assert.ErrorIs(t, err, errFetch)
+}
+
+func TestForEachBatch(t *testing.T) {
+	batches := make([][]int, 0)
+	err := paging.ForEachBatch(context.Background(), fetchNumbers(5),
+		func(_ context.Context, batch []int) error {
+			batches = append(batches, append([]int(nil), batch...))
+			return nil
+		},
+		paging.WithIteratorPageSize(2), paging.WithIteratorPrefetch(1),
+	)
+	require.NoError(t, err)
+	assert.Equal(t, [][]int{{0, 1}, {2, 3}, {4}}, batches)
+
+	errBatch := errors.New("batch failed")
+	calls := 0
+	err = paging.ForEachBatch(context.Background(), fetchNumbers(5),
+		func(_ context.Context, _ []int) error {
+			calls++
+			return errBatch
+		},
+		paging.WithIteratorPageSize(2),
+	)
+	assert.ErrorIs(t, err, errBatch)
+	assert.Equal(t, 1, calls)
+}
+
+func TestIterator_Cancel_Prefetching(t *testing.T) {
+	// per-item work lets background fetches observe the cancellation before the consumer does
+	for range 100 {
+		ctx, cancel := context.WithCancel(context.Background())
+		iterator := paging.NewIterator(ctx, fetchNumbers(10), paging.WithIteratorPageSize(2),
+			paging.WithIteratorPrefetch(1))
+
+		count := 0
+		var lastErr error
+		for _, err := range iterator.All() {
+			if err != nil {
+				lastErr = err
+				break
+			}
+			if count++; count == 3 {
+				cancel()
+			}
+			time.Sleep(20 * time.Microsecond)
+		}
+		iterator.Close()
+		cancel()
+		require.ErrorIs(t, lastErr, context.Canceled)
+	}
+}
+
+func TestIterator_Close(t *testing.T) {
+	iterator := paging.NewIterator(context.Background(), fetchNumbers(10), paging.WithIteratorPageSize(2))
+	_, err := iterator.Next()
+	require.NoError(t, err)
+	iterator.Close()
+	_, err = iterator.Next()
+	require.NoError(t, err) // items of the current page are still available
+	_, err = iterator.Next()
+	assert.ErrorIs(t, err, context.Canceled)
+}
@@ -0,0 +1,181 @@
+package paging
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/vmihailenco/msgpack/v5"
+
+	"github.com/bosonicalio/geck/security/cryptox"
+)
+
+var (
+	// ErrMalformedToken is returned when a page token is not a valid envelope produced by a [TokenKeyring].
+	ErrMalformedToken = errors.New("geck.paging: malformed token")
+	// ErrUnsupportedTokenVersion is returned when a page token envelope version is not supported.
+	ErrUnsupportedTokenVersion = errors.New("geck.paging: unsupported token version")
+	// ErrUnknownTokenKey is returned when a page token was encrypted using a key missing from the [TokenKeyring].
+	ErrUnknownTokenKey = errors.New("geck.paging: unknown token key")
+	// ErrTokenExpired is returned when a page token is used after its expiry.
+	ErrTokenExpired = errors.New("geck.paging: token expired")
+	// ErrTokenAudienceMismatch is returned when a page token is used against an audience other than the one it
+	// was issued for.
+	ErrTokenAudienceMismatch = errors.New("geck.paging: token audience mismatch")
+)
+
+// _tokenVersion is the current version of the page token envelope.
+const _tokenVersion byte = 1
+
+// - Audience -
+
+// TokenAudience is the target page tokens are bound to, so tokens issued by an endpoint cannot be replayed against
+// another.
+type TokenAudience struct {
+	// Resource is the name of the resource tokens are issued for (e.g. a table or an API path).
+	Resource string
+	// QueryHash is the hash of the query parameters tokens are issued for (see [NewTokenAudience]).
+	QueryHash string
+}
+
+// NewTokenAudience creates a [TokenAudience] for `resource`, hashing `query` (e.g. filters not held by tokens).
+// A nil query is not hashed. Map keys are sorted before hashing, so equal queries always produce the same hash.
+//
+// It is important to make sure that `query` is serializable (fields are exported and serializable as well).
+func NewTokenAudience(resource string, query any) (TokenAudience, error) {
+	audience := TokenAudience{Resource: resource}
+	if query == nil {
+		return audience, nil
+	}
+	buf := bytes.Buffer{}
+	enc := msgpack.NewEncoder(&buf)
+	enc.SetSortMapKeys(true)
+	if err := enc.Encode(query); err != nil {
+		return TokenAudience{}, err
+	}
+	hash := sha256.Sum256(buf.Bytes())
+	audience.QueryHash = hex.EncodeToString(hash[:])
+	return audience, nil
+}
+
+func (a TokenAudience) String() string {
+	if a.QueryHash == "" {
+		return a.Resource
+	}
+	return a.Resource + "#" + a.QueryHash
+}
+
+// - Keyring -
+
+// TokenKeyring is a [TokenCodec] producing versioned, expiring and audience-bound page tokens.
+//
+// Tokens are encrypted using the active key and hold its ID, so keys can be rotated without breaking outstanding
+// tokens: add the new key, set it as active and keep previous keys until their tokens expire.
+//
+// Like [TokenCipherKey], keys must be 16, 24, or 32 bytes long.
+type TokenKeyring struct {
+	// ActiveKeyID is the ID of the key used to encrypt tokens.
+	ActiveKeyID string
+	// Keys holds the keys used to decrypt tokens, indexed by ID (at most 255 bytes long).
+	Keys map[string]TokenCipherKey
+	// TTL is the duration tokens are valid for after being issued. Zero indicates tokens never expire.
+	TTL time.Duration
+	// Audience is the target tokens are bound to. Tokens issued for other audiences are rejected.
+	Audience TokenAudience
+}
+
+// tokenClaims is the encrypted content of page tokens produced by [TokenKeyring].
+type tokenClaims struct {
+	IssueTime  int64              `msgpack:"iat"`
+	ExpireTime int64              `msgpack:"exp,omitempty"`
+	Audience   string             `msgpack:"aud,omitempty"`
+	Value      msgpack.RawMessage `msgpack:"val"`
+}
+
+// WithAudience returns a copy of the keyring bound to `audience`.
+func (k TokenKeyring) WithAudience(audience TokenAudience) TokenKeyring {
+	k.Audience = audience
+	return k
+}
+
+// EncodeToken creates a token from `v` using the active key.
+//
+// Tokens are URL-safe base64 encoded envelopes holding a version, the key ID and the encrypted claims (issue time,
+// expiry, audience and `v`). The version and key ID are authenticated along with the claims.
+func (k TokenKeyring) EncodeToken(v any) (string, error) {
+	key, ok := k.Keys[k.ActiveKeyID]
+	if !ok {
+		return "", fmt.Errorf("%w: %q", ErrUnknownTokenKey, k.ActiveKeyID)
+	} else if len(k.ActiveKeyID) > 255 {
+		return "", fmt.Errorf("geck.paging: key ID %q is too long", k.ActiveKeyID)
+	}
+
+	value, err := msgpack.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	now := time.Now()
+	claims := tokenClaims{
+		IssueTime: now.UnixMilli(),
+		Audience:  k.Audience.String(),
+		Value:     value,
+	}
+	if k.TTL > 0 {
+		claims.ExpireTime = now.Add(k.TTL).UnixMilli()
+	}
+	serialized, err := msgpack.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+	prefix := make([]byte, 0, 2+len(k.ActiveKeyID))
+	prefix = append(prefix, _tokenVersion, byte(len(k.ActiveKeyID)))
+	prefix = append(prefix, k.ActiveKeyID...)
+	encrypted, err := cryptox.EncryptWithAAD(serialized, key, prefix)
+	if err != nil {
+		return "", err
+	}
+	return base64.URLEncoding.EncodeToString(append(prefix, encrypted...)), nil
+}
+
+// DecodeToken parses `encoded` into `v` using the key the token was encrypted with.
+//
+// Returns [ErrTokenExpired] if the token expired and [ErrTokenAudienceMismatch] if the token was issued for an
+// audience other than the keyring audience.
+func (k TokenKeyring) DecodeToken(encoded string, v any) error {
+	envelope, err := base64.URLEncoding.DecodeString(encoded)
+	if err != nil {
+		return errors.Join(ErrMalformedToken, err)
+	} else if len(envelope) < 2 {
+		return ErrMalformedToken
+	} else if envelope[0] != _tokenVersion {
+		return fmt.Errorf("%w: %d", ErrUnsupportedTokenVersion, envelope[0])
+	}
+	keyIDLen := int(envelope[1])
+	if len(envelope) < 2+keyIDLen {
+		return ErrMalformedToken
+	}
+	keyID := string(envelope[2 : 2+keyIDLen])
+	key, ok := k.Keys[keyID]
+	if !ok {
+		return fmt.Errorf("%w: %q", ErrUnknownTokenKey, keyID)
+	}
+
+	serialized, err := cryptox.DecryptWithAAD(envelope[2+keyIDLen:], key, envelope[:2+keyIDLen])
+	if err != nil {
+		return errors.Join(ErrMalformedToken, err)
+	}
+	claims := tokenClaims{}
+	if err = msgpack.Unmarshal(serialized, &claims); err != nil {
+		return errors.Join(ErrMalformedToken, err)
+	}
+	if claims.ExpireTime > 0 && time.Now().UnixMilli() >= claims.ExpireTime {
+		return ErrTokenExpired
+	} else if claims.Audience != k.Audience.String() {
+		return ErrTokenAudienceMismatch
+	}
+	return msgpack.Unmarshal(claims.Value, v)
+}
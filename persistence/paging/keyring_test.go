@@ -0,0 +1,97 @@
+package paging_test
+
+import (
+	"encoding/base64"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bosonicalio/geck/persistence/paging"
+)
+
+type tokenQuery struct {
+	Name   string
+	Offset int
+}
+
+func TestTokenKeyring(t *testing.T) {
+	oldKey := paging.TokenCipherKey("0123456789abcdef")
+	newKey := paging.TokenCipherKey("fedcba9876543210")
+	audience, err := paging.NewTokenAudience("users", map[string]string{"status": "active", "role": "admin"})
+	require.NoError(t, err)
+	keyring := paging.TokenKeyring{
+		ActiveKeyID: "v1",
+		Keys:        map[string]paging.TokenCipherKey{"v1": oldKey},
+		Audience:    audience,
+	}
+
+	token, err := keyring.EncodeToken(tokenQuery{Name: "john", Offset: 10})
+	require.NoError(t, err)
+
+	t.Run("key rotation", func(t *testing.T) {
+		rotated := keyring
+		rotated.ActiveKeyID = "v2"
+		rotated.Keys = map[string]paging.TokenCipherKey{"v1": oldKey, "v2": newKey}
+		query := tokenQuery{}
+		require.NoError(t, rotated.DecodeToken(token, &query))
+		assert.Equal(t, tokenQuery{Name: "john", Offset: 10}, query)
+
+		rotatedToken, err := rotated.EncodeToken(query)
+		require.NoError(t, err)
+		assert.ErrorIs(t, keyring.DecodeToken(rotatedToken, &query), paging.ErrUnknownTokenKey)
+	})
+
+	t.Run("audience mismatch", func(t *testing.T) {
+		other, err := paging.NewTokenAudience("users", map[string]string{"status": "inactive"})
+		require.NoError(t, err)
+		assert.ErrorIs(t, keyring.WithAudience(other).DecodeToken(token, &tokenQuery{}),
+			paging.ErrTokenAudienceMismatch)
+	})
+
+	t.Run("expired", func(t *testing.T) {
+		expiring := keyring
+		expiring.TTL = time.Millisecond
+		expiringToken, err := expiring.EncodeToken(tokenQuery{})
+		require.NoError(t, err)
+		time.Sleep(5 * time.Millisecond)
+		assert.ErrorIs(t, expiring.DecodeToken(expiringToken, &tokenQuery{}), paging.ErrTokenExpired)
+	})
+
+	t.Run("malformed", func(t *testing.T) {
+		assert.ErrorIs(t, keyring.DecodeToken("AQ==", &tokenQuery{}), paging.ErrMalformedToken)
+		assert.ErrorIs(t, keyring.DecodeToken("AgA=", &tokenQuery{}), paging.ErrUnsupportedTokenVersion)
+		legacy, err := paging.NewToken(oldKey, tokenQuery{})
+		require.NoError(t, err)
+		assert.Error(t, keyring.DecodeToken(legacy, &tokenQuery{}))
+	})
+}
+
+func TestNewTokenAudience(t *testing.T) {
+	query := map[string]any{"status": "active", "role": "admin", "country": "IT", "age": 30}
+	expected, err := paging.NewTokenAudience("users", query)
+	require.NoError(t, err)
+	assert.NotEmpty(t, expected.QueryHash)
+	for range 100 {
+		audience, err := paging.NewTokenAudience("users", query)
+		require.NoError(t, err)
+		require.Equal(t, expected, audience)
+	}
+}
+
+func TestTokenKeyring_TamperedKeyID(t *testing.T) {
+	key := paging.TokenCipherKey("0123456789abcdef")
+	keyring := paging.TokenKeyring{
+		ActiveKeyID: "k1",
+		Keys:        map[string]paging.TokenCipherKey{"k1": key, "k2": key},
+	}
+	token, err := keyring.EncodeToken(tokenQuery{Name: "john"})
+	require.NoError(t, err)
+
+	envelope, err := base64.URLEncoding.DecodeString(token)
+	require.NoError(t, err)
+	envelope[3] = '2' // k1 -> k2, which holds the same key
+	tampered := base64.URLEncoding.EncodeToString(envelope)
+	assert.ErrorIs(t, keyring.DecodeToken(tampered, &tokenQuery{}), paging.ErrMalformedToken)
+}
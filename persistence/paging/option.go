@@ -4,6 +4,7 @@ package paging
 type Options struct {
 	limit     int
 	pageToken string
+	isReverse bool
 }
 
 // Option represents an option for pagination.
@@ -23,6 +24,14 @@ func WithPageToken(token string) Option {
 	}
 }
 
+// WithReverse indicates the caller traverses pages in reverse order, so the last page should be fetched if no
+// page token is set (see [ParseCursor]).
+func WithReverse(isReverse bool) Option {
+	return func(o *Options) {
+		o.isReverse = isReverse
+	}
+}
+
 // Limit returns the limit option.
 func (o Options) Limit() int {
 	return o.limit
@@ -37,3 +46,8 @@ func (o Options) PageToken() string {
 func (o Options) HasPageToken() bool {
 	return o.pageToken != ""
 }
+
+// IsReverse returns the reverse option.
+func (o Options) IsReverse() bool {
+	return o.isReverse
+}
@@ -60,3 +60,31 @@ func ParseToken(cipherKey TokenCipherKey, encoded string, v any) error {
 
 	return msgpack.Unmarshal(serialized, v)
 }
+
+// - Codec -
+
+// TokenCodec encodes values into page tokens and decodes them back.
+//
+// Both [TokenCipherKey] and [TokenKeyring] are codecs, so components producing page tokens may accept either.
+type TokenCodec interface {
+	// EncodeToken creates a token from `v`.
+	EncodeToken(v any) (string, error)
+	// DecodeToken parses `encoded` into `v`.
+	DecodeToken(encoded string, v any) error
+}
+
+// compile-time assertions
+var (
+	_ TokenCodec = TokenCipherKey(nil)
+	_ TokenCodec = TokenKeyring{}
+)
+
+// EncodeToken creates a token from `v` using [NewToken].
+func (k TokenCipherKey) EncodeToken(v any) (string, error) {
+	return NewToken(k, v)
+}
+
+// DecodeToken parses `encoded` into `v` using [ParseToken].
+func (k TokenCipherKey) DecodeToken(encoded string, v any) error {
+	return ParseToken(k, encoded, v)
+}
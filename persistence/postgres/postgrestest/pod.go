@@ -49,6 +49,12 @@ func NewPod(ctx context.Context, opts ...PodOption) (Pod, error) {
 				WithOccurrence(2).
 				WithStartupTimeout(5*time.Second),
 		),
+		testcontainers.CustomizeRequestOption(func(req *testcontainers.GenericContainerRequest) error {
+			for _, setting := range podConfig.settings {
+				req.Cmd = append(req.Cmd, "-c", setting)
+			}
+			return nil
+		}),
 	)
 	if err != nil {
 		return Pod{}, err
@@ -120,6 +126,7 @@ type podOptions struct {
 	databaseName string
 	migrationsFs fs.FS
 	seedFs       fs.FS
+	settings     []string
 }
 
 type PodOption func(*podOptions)
@@ -158,3 +165,11 @@ func WithPodSeedFS(fs fs.FS) PodOption {
 		o.seedFs = fs
 	}
 }
+
+// WithPodSetting sets a server configuration parameter for the Postgres container,
+// e.g. `max_prepared_transactions`.
+func WithPodSetting(name, value string) PodOption {
+	return func(o *podOptions) {
+		o.settings = append(o.settings, name+"="+value)
+	}
+}
@@ -0,0 +1,153 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+
+	"github.com/jackc/pgx/v5/pgconn"
+
+	"github.com/bosonicalio/geck/persistence"
+	gecksql "github.com/bosonicalio/geck/persistence/sql"
+)
+
+const (
+	// CodeSerializationFailure is the SQLSTATE reported when a transaction cannot be serialized with concurrent
+	// transactions.
+	CodeSerializationFailure = "40001"
+	// CodeDeadlockDetected is the SQLSTATE reported when a transaction is aborted to resolve a deadlock.
+	CodeDeadlockDetected = "40P01"
+)
+
+// IsRetryableTxError indicates whether `err` is a transient transaction error reported by Postgres through pgx,
+// i.e. a serialization failure ([CodeSerializationFailure]) or a deadlock ([CodeDeadlockDetected]).
+//
+// It is meant to be used as [persistence.TxRetryPolicy] classifier.
+func IsRetryableTxError(err error) bool {
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) {
+		return false
+	}
+	return pgErr.Code == CodeSerializationFailure || pgErr.Code == CodeDeadlockDetected
+}
+
+// Transaction is a [gecksql.Transaction] supporting the two-phase commit protocol through Postgres prepared
+// transactions (see [persistence.PreparableTransaction]).
+type Transaction struct {
+	gecksql.Transaction
+	db gecksql.DB
+}
+
+// compile-time assertion(s)
+var (
+	_ gecksql.TxWrapper                 = (*Transaction)(nil)
+	_ persistence.PreparableTransaction = (*Transaction)(nil)
+)
+
+// Prepare prepares the transaction using `PREPARE TRANSACTION`, then, releases its underlying connection as the
+// session is no longer bound to the transaction.
+func (t Transaction) Prepare(ctx context.Context, id string) error {
+	if _, err := t.Parent.ExecContext(ctx, "PREPARE TRANSACTION "+quoteLiteral(id)); err != nil {
+		return err
+	}
+	return t.Parent.Rollback()
+}
+
+// CommitPrepared commits the transaction prepared as `id` using `COMMIT PREPARED`.
+func (t Transaction) CommitPrepared(ctx context.Context, id string) error {
+	return commitPrepared(ctx, t.db, id)
+}
+
+// RollbackPrepared rolls back the transaction prepared as `id` using `ROLLBACK PREPARED`.
+func (t Transaction) RollbackPrepared(ctx context.Context, id string) error {
+	return rollbackPrepared(ctx, t.db, id)
+}
+
+// -- Factory --
+
+// TxFactory is the [persistence.TxFactory] implementation for Postgres. It creates [Transaction] instances,
+// so it can be registered into a [persistence.TxManager] using two-phase commit
+// (see [persistence.WithTwoPhaseCommit]).
+//
+// Prepared transactions require the `max_prepared_transactions` server setting to be greater than zero.
+type TxFactory struct {
+	gecksql.TxFactory
+	db gecksql.DB
+}
+
+// compile-time assertion(s)
+var (
+	_ persistence.SavepointTxFactory  = (*TxFactory)(nil)
+	_ persistence.PreparedTxRecoverer = (*TxFactory)(nil)
+)
+
+// NewTxFactory creates a new instance of [TxFactory] with the provided [gecksql.DB] client and transaction
+// options.
+func NewTxFactory(db gecksql.DB, txOpts *sql.TxOptions) TxFactory {
+	return TxFactory{
+		TxFactory: gecksql.NewTxFactory(db, txOpts),
+		db:        db,
+	}
+}
+
+func (f TxFactory) NewTx(ctx context.Context) (persistence.Transaction, error) {
+	tx, err := f.TxFactory.NewTx(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return Transaction{
+		Transaction: tx.(gecksql.Transaction),
+		db:          f.db,
+	}, nil
+}
+
+// ListPrepared lists the transactions prepared within the current database.
+func (f TxFactory) ListPrepared(ctx context.Context) ([]persistence.PreparedTx, error) {
+	rows, err := f.db.QueryContext(detachTx(ctx),
+		"SELECT gid, prepared FROM pg_prepared_xacts WHERE database = current_database()")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	preparedTxs := make([]persistence.PreparedTx, 0)
+	for rows.Next() {
+		preparedTx := persistence.PreparedTx{}
+		if err = rows.Scan(&preparedTx.ID, &preparedTx.PrepareTime); err != nil {
+			return nil, err
+		}
+		preparedTxs = append(preparedTxs, preparedTx)
+	}
+	return preparedTxs, rows.Err()
+}
+
+// CommitPrepared commits the transaction prepared as `id` using `COMMIT PREPARED`.
+func (f TxFactory) CommitPrepared(ctx context.Context, id string) error {
+	return commitPrepared(ctx, f.db, id)
+}
+
+// RollbackPrepared rolls back the transaction prepared as `id` using `ROLLBACK PREPARED`.
+func (f TxFactory) RollbackPrepared(ctx context.Context, id string) error {
+	return rollbackPrepared(ctx, f.db, id)
+}
+
+func commitPrepared(ctx context.Context, db gecksql.DB, id string) error {
+	_, err := db.ExecContext(detachTx(ctx), "COMMIT PREPARED "+quoteLiteral(id))
+	return err
+}
+
+func rollbackPrepared(ctx context.Context, db gecksql.DB, id string) error {
+	_, err := db.ExecContext(detachTx(ctx), "ROLLBACK PREPARED "+quoteLiteral(id))
+	return err
+}
+
+// detachTx removes the transaction from `ctx` as prepared transactions cannot be resolved within a transaction
+// block (e.g. when `db` is a [gecksql.DBTxPropagator]).
+func detachTx(ctx context.Context) context.Context {
+	return persistence.WithTxContext(ctx, gecksql.TxDriver, nil)
+}
+
+func quoteLiteral(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
@@ -0,0 +1,139 @@
+package postgres_test
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bosonicalio/geck/persistence"
+	"github.com/bosonicalio/geck/persistence/postgres"
+	"github.com/bosonicalio/geck/persistence/postgres/postgrestest"
+)
+
+func insertUser(t *testing.T, tx persistence.Transaction, userID string) {
+	t.Helper()
+	_, err := tx.(postgres.Transaction).ParentTx().ExecContext(context.Background(),
+		"INSERT INTO platform_users (user_id, name) VALUES ($1, $2)", userID, userID)
+	require.NoError(t, err)
+}
+
+func listPrepared(t *testing.T, db *sql.DB) []string {
+	t.Helper()
+	rows, err := db.QueryContext(context.Background(), "SELECT gid FROM pg_prepared_xacts ORDER BY gid")
+	require.NoError(t, err)
+	defer rows.Close()
+	ids := make([]string, 0)
+	for rows.Next() {
+		var id string
+		require.NoError(t, rows.Scan(&id))
+		ids = append(ids, id)
+	}
+	require.NoError(t, rows.Err())
+	return ids
+}
+
+func countUsers(t *testing.T, db *sql.DB, userIDs ...string) int {
+	t.Helper()
+	total := 0
+	for _, userID := range userIDs {
+		var exists bool
+		err := db.QueryRowContext(context.Background(),
+			"SELECT EXISTS (SELECT 1 FROM platform_users WHERE user_id = $1)", userID).Scan(&exists)
+		require.NoError(t, err)
+		if exists {
+			total++
+		}
+	}
+	return total
+}
+
+func TestTxManager_TwoPhaseCommit(t *testing.T) {
+	pod, err := postgrestest.NewPod(context.Background(),
+		postgrestest.WithPodImageTag("16-alpine"),
+		postgrestest.WithPodMigrationsFS(os.DirFS("postgrestest/testdata/migration")),
+		postgrestest.WithPodSetting("max_prepared_transactions", "10"),
+	)
+	require.NoError(t, err)
+	defer func() {
+		assert.NoError(t, pod.Close())
+	}()
+	db := pod.Client()
+
+	// both participants share the database, each one runs its own transaction (i.e. session)
+	participants := []postgres.TxFactory{
+		postgres.NewTxFactory(db, nil),
+		postgres.NewTxFactory(db, nil),
+	}
+	manager := persistence.NewTxManager(persistence.WithTwoPhaseCommit(true))
+	for _, factory := range participants {
+		manager.Register(factory)
+	}
+
+	t.Run("execute", func(t *testing.T) {
+		err := manager.Execute(context.Background(), func(ctx context.Context) error {
+			tx, ok := persistence.FromTxContext(ctx, participants[0].Driver())
+			require.True(t, ok)
+			insertUser(t, tx, "user-execute")
+			return nil
+		})
+		require.NoError(t, err)
+		assert.Equal(t, 1, countUsers(t, db, "user-execute"))
+		assert.Empty(t, listPrepared(t, db))
+	})
+
+	// prepare returns the transactions of the participants prepared as the given global transaction
+	prepare := func(t *testing.T, globalID string, userIDs ...string) []postgres.Transaction {
+		t.Helper()
+		txs := make([]postgres.Transaction, 0, len(userIDs))
+		for i, userID := range userIDs {
+			tx, err := participants[i].NewTx(context.Background())
+			require.NoError(t, err)
+			insertUser(t, tx, userID)
+			require.NoError(t, tx.(postgres.Transaction).Prepare(context.Background(), preparedTxID(globalID, i)))
+			txs = append(txs, tx.(postgres.Transaction))
+		}
+		return txs
+	}
+
+	t.Run("recover commit phase", func(t *testing.T) {
+		txs := prepare(t, "a1", "user-a1", "user-a2")
+		// the process crashes once the first participant is committed
+		require.NoError(t, txs[0].CommitPrepared(context.Background(), preparedTxID("a1", 0)))
+		assert.Equal(t, []string{preparedTxID("a1", 1)}, listPrepared(t, db))
+
+		require.NoError(t, manager.Recover(context.Background(), persistence.WithTxRecoveryMinAge(0)))
+		assert.Empty(t, listPrepared(t, db))
+		assert.Equal(t, 2, countUsers(t, db, "user-a1", "user-a2"))
+	})
+
+	t.Run("recover prepare phase", func(t *testing.T) {
+		// the process crashes before preparing the second participant
+		prepare(t, "b1", "user-b1")
+		assert.Equal(t, []string{preparedTxID("b1", 0)}, listPrepared(t, db))
+
+		require.NoError(t, manager.Recover(context.Background(), persistence.WithTxRecoveryMinAge(0)))
+		assert.Empty(t, listPrepared(t, db))
+		assert.Zero(t, countUsers(t, db, "user-b1"))
+	})
+
+	t.Run("recent transactions are skipped", func(t *testing.T) {
+		prepare(t, "c1", "user-c1", "user-c2")
+		require.NoError(t, manager.Recover(context.Background()))
+		assert.Len(t, listPrepared(t, db), 2)
+
+		require.NoError(t, manager.Recover(context.Background(), persistence.WithTxRecoveryMinAge(0)))
+		assert.Empty(t, listPrepared(t, db))
+		assert.Zero(t, countUsers(t, db, "user-c1", "user-c2"))
+	})
+}
+
+// preparedTxID formats the identifier [persistence.TxManager] uses for the `index`-th participant of a global
+// transaction of two participants.
+func preparedTxID(globalID string, index int) string {
+	return "geck_2pc:" + globalID + ":" + strconv.Itoa(index) + ":2"
+}
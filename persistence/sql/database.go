@@ -249,11 +249,11 @@ func (d DBTxPropagator) BeginTx(ctx context.Context, opts *sql.TxOptions) (*sql.
 		return d.next.BeginTx(ctx, opts)
 	}
 
-	tx, ok := txIface.(Transaction)
+	tx, ok := txIface.(TxWrapper)
 	if !ok {
 		return nil, persistence.ErrInvalidTxContext
 	}
-	return tx.Parent, nil
+	return tx.ParentTx(), nil
 }
 
 func (d DBTxPropagator) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
@@ -262,11 +262,11 @@ func (d DBTxPropagator) QueryContext(ctx context.Context, query string, args ...
 		return d.next.QueryContext(ctx, query, args...)
 	}
 
-	tx, ok := txIface.(Transaction)
+	tx, ok := txIface.(TxWrapper)
 	if !ok {
 		return nil, persistence.ErrInvalidTxContext
 	}
-	return tx.Parent.QueryContext(ctx, query, args...)
+	return tx.ParentTx().QueryContext(ctx, query, args...)
 }
 
 func (d DBTxPropagator) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
@@ -274,11 +274,11 @@ func (d DBTxPropagator) QueryRowContext(ctx context.Context, query string, args
 	if !found {
 		return d.next.QueryRowContext(ctx, query, args...)
 	}
-	tx, ok := txIface.(Transaction)
+	tx, ok := txIface.(TxWrapper)
 	if !ok {
 		panic(persistence.ErrInvalidTxContext)
 	}
-	return tx.Parent.QueryRowContext(ctx, query, args...)
+	return tx.ParentTx().QueryRowContext(ctx, query, args...)
 }
 
 func (d DBTxPropagator) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
@@ -287,11 +287,11 @@ func (d DBTxPropagator) ExecContext(ctx context.Context, query string, args ...i
 		return d.next.ExecContext(ctx, query, args...)
 	}
 
-	tx, ok := txIface.(Transaction)
+	tx, ok := txIface.(TxWrapper)
 	if !ok {
 		return nil, persistence.ErrInvalidTxContext
 	}
-	return tx.Parent.ExecContext(ctx, query, args...)
+	return tx.ParentTx().ExecContext(ctx, query, args...)
 }
 
 func (d DBTxPropagator) PrepareContext(ctx context.Context, query string) (*sql.Stmt, error) {
@@ -300,9 +300,9 @@ func (d DBTxPropagator) PrepareContext(ctx context.Context, query string) (*sql.
 		return d.next.PrepareContext(ctx, query)
 	}
 
-	tx, ok := txIface.(Transaction)
+	tx, ok := txIface.(TxWrapper)
 	if !ok {
 		return nil, persistence.ErrInvalidTxContext
 	}
-	return tx.Parent.PrepareContext(ctx, query)
+	return tx.ParentTx().PrepareContext(ctx, query)
 }
@@ -3,6 +3,8 @@ package sql
 import (
 	"context"
 	"database/sql"
+	"strconv"
+	"sync/atomic"
 
 	"github.com/bosonicalio/geck/persistence"
 )
@@ -10,13 +12,28 @@ import (
 // TxDriver is a type alias for the [persistence.TxDriver] used in the context of a [DBTxPropagator].
 const TxDriver persistence.TxDriver = "sql"
 
+// TxWrapper is a [persistence.Transaction] wrapping a [sql.Tx].
+//
+// Components propagating transactions through contexts (e.g. [DBTxPropagator]) accept any [TxWrapper], so
+// database-specific adapters (e.g. supporting two-phase commit) may embed [Transaction] to extend it.
+type TxWrapper interface {
+	persistence.Transaction
+	// ParentTx returns the wrapped [sql.Tx].
+	ParentTx() *sql.Tx
+}
+
 // Transaction is the adapter structure of [persistence.Transaction] for [sql].
 type Transaction struct {
 	Parent *sql.Tx
 }
 
 // compile-time assertion
-var _ persistence.Transaction = (*Transaction)(nil)
+var _ TxWrapper = (*Transaction)(nil)
+
+// ParentTx returns the wrapped [sql.Tx].
+func (t Transaction) ParentTx() *sql.Tx {
+	return t.Parent
+}
 
 func (t Transaction) Commit(_ context.Context) error {
 	return t.Parent.Commit()
@@ -26,6 +43,28 @@ func (t Transaction) Rollback(_ context.Context) error {
 	return t.Parent.Rollback()
 }
 
+// Savepoint is the adapter structure of [persistence.Transaction] for savepoints within a [sql.Tx]
+// (nested transactions).
+type Savepoint struct {
+	Parent *sql.Tx
+	Name   string
+}
+
+// compile-time assertion
+var _ persistence.Transaction = (*Savepoint)(nil)
+
+// Commit releases the savepoint, keeping the work done since it was created.
+func (s Savepoint) Commit(ctx context.Context) error {
+	_, err := s.Parent.ExecContext(ctx, "RELEASE SAVEPOINT "+s.Name)
+	return err
+}
+
+// Rollback discards the work done since the savepoint was created.
+func (s Savepoint) Rollback(ctx context.Context) error {
+	_, err := s.Parent.ExecContext(ctx, "ROLLBACK TO SAVEPOINT "+s.Name)
+	return err
+}
+
 // -- Factory --
 
 // TxFactory is the concrete implementation of [persistence.TxFactory] for [sql].
@@ -43,7 +82,9 @@ func NewTxFactory(client DB, txOpts *sql.TxOptions) TxFactory {
 }
 
 // compile-time assertion
-var _ persistence.TxFactory = (*TxFactory)(nil)
+var _ persistence.SavepointTxFactory = (*TxFactory)(nil)
+
+var savepointSeq atomic.Uint64
 
 func (t TxFactory) Driver() persistence.TxDriver {
 	return TxDriver
@@ -56,3 +97,19 @@ func (t TxFactory) NewTx(ctx context.Context) (persistence.Transaction, error) {
 	}
 	return Transaction{Parent: tx}, nil
 }
+
+// NewSavepoint creates a savepoint within `parent`, which MUST be a [TxWrapper].
+func (t TxFactory) NewSavepoint(ctx context.Context, parent persistence.Transaction) (persistence.Transaction, error) {
+	tx, ok := parent.(TxWrapper)
+	if !ok {
+		return nil, persistence.ErrInvalidTxContext
+	}
+	savepoint := Savepoint{
+		Parent: tx.ParentTx(),
+		Name:   "geck_sp_" + strconv.FormatUint(savepointSeq.Add(1), 10),
+	}
+	if _, err := tx.ParentTx().ExecContext(ctx, "SAVEPOINT "+savepoint.Name); err != nil {
+		return nil, err
+	}
+	return savepoint, nil
+}
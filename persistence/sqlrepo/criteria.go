@@ -0,0 +1,96 @@
+package sqlrepo
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/bosonicalio/geck/persistence"
+)
+
+var (
+	// ErrUnsupportedFilter is returned when a [persistence.Filter] cannot be translated into SQL.
+	ErrUnsupportedFilter = errors.New("geck.sqlrepo: unsupported filter")
+	// ErrUnknownField is returned when a [persistence.Criteria] references a field not mapped to a column.
+	ErrUnknownField = errors.New("geck.sqlrepo: unknown field")
+)
+
+// _likeEscape is the escape character of LIKE patterns, supported by every [Dialect].
+const _likeEscape = "!"
+
+// Clauses are the SQL clauses translated from a [persistence.Criteria] (see [TranslateCriteria]).
+type Clauses struct {
+	// Where holds the filter conditions joined by AND, without the WHERE keyword. Empty if there are no filters.
+	Where string
+	// OrderBy holds the sort orders, without the ORDER BY keyword. Empty if there are no sort orders.
+	OrderBy string
+	// Args holds the arguments of the placeholders written into Where.
+	Args []any
+}
+
+// TranslateCriteria translates the filters and sort orders of `criteria` into SQL clauses using `dialect`.
+// Fields are used as column names. The criteria limit is not translated as paging is up to the caller.
+//
+// Placeholders are numbered starting from `argOffset` + 1, so clauses can be appended to statements already
+// holding arguments.
+func TranslateCriteria(dialect Dialect, criteria persistence.Criteria, argOffset int) (Clauses, error) {
+	clauses := Clauses{
+		Args: make([]any, 0, len(criteria.Filters)),
+	}
+	placeholder := func(v any) string {
+		clauses.Args = append(clauses.Args, v)
+		return dialect.Placeholder(argOffset + len(clauses.Args))
+	}
+
+	conditions := make([]string, 0, len(criteria.Filters))
+	for _, filter := range criteria.Filters {
+		col := dialect.QuoteIdentifier(filter.Field)
+		switch {
+		case filter.Operator == persistence.OperatorEq && len(filter.Values) == 1:
+			conditions = append(conditions, col+" = "+placeholder(filter.Values[0]))
+		case filter.Operator == persistence.OperatorIn && len(filter.Values) == 0:
+			// matches nothing
+			conditions = append(conditions, "1 = 0")
+		case filter.Operator == persistence.OperatorIn:
+			placeholders := make([]string, 0, len(filter.Values))
+			for _, v := range filter.Values {
+				placeholders = append(placeholders, placeholder(v))
+			}
+			conditions = append(conditions, col+" IN ("+strings.Join(placeholders, ", ")+")")
+		case filter.Operator == persistence.OperatorRange && len(filter.Values) == 2:
+			if from := filter.Values[0]; from != nil {
+				conditions = append(conditions, col+" >= "+placeholder(from))
+			}
+			if to := filter.Values[1]; to != nil {
+				conditions = append(conditions, col+" < "+placeholder(to))
+			}
+		case filter.Operator == persistence.OperatorPrefix && len(filter.Values) == 1:
+			prefix, ok := filter.Values[0].(string)
+			if !ok {
+				return Clauses{}, fmt.Errorf("%w: prefix of %s is not a string", ErrUnsupportedFilter, filter.Field)
+			}
+			conditions = append(conditions, col+" LIKE "+placeholder(escapeLike(prefix)+"%")+
+				" ESCAPE '"+_likeEscape+"'")
+		default:
+			return Clauses{}, fmt.Errorf("%w: operator %d with %d values on %s", ErrUnsupportedFilter,
+				filter.Operator, len(filter.Values), filter.Field)
+		}
+	}
+	clauses.Where = strings.Join(conditions, " AND ")
+
+	orders := make([]string, 0, len(criteria.Sorts))
+	for _, sort := range criteria.Sorts {
+		order := dialect.QuoteIdentifier(sort.Field)
+		if sort.IsDescending {
+			order += " DESC"
+		}
+		orders = append(orders, order)
+	}
+	clauses.OrderBy = strings.Join(orders, ", ")
+	return clauses, nil
+}
+
+func escapeLike(s string) string {
+	return strings.NewReplacer(_likeEscape, _likeEscape+_likeEscape, "%", _likeEscape+"%", "_", _likeEscape+"_").
+		Replace(s)
+}
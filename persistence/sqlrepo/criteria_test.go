@@ -0,0 +1,35 @@
+package sqlrepo_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bosonicalio/geck/persistence"
+	"github.com/bosonicalio/geck/persistence/sqlrepo"
+)
+
+func TestTranslateCriteria(t *testing.T) {
+	clauses, err := sqlrepo.TranslateCriteria(sqlrepo.DialectPostgres, persistence.Criteria{
+		Filters: []persistence.Filter{
+			persistence.Eq("status", "active"),
+			persistence.In("role", "admin", "owner"),
+			persistence.Range("age", 18, nil),
+			persistence.Prefix("name", "50%_"),
+			persistence.In("team"),
+		},
+		Sorts: []persistence.SortOrder{persistence.Desc("create_time"), persistence.Asc("name")},
+	}, 1)
+	require.NoError(t, err)
+	assert.Equal(t, `"status" = $2 AND "role" IN ($3, $4) AND "age" >= $5 AND "name" LIKE $6 ESCAPE '!' AND 1 = 0`,
+		clauses.Where)
+	assert.Equal(t, `"create_time" DESC, "name"`, clauses.OrderBy)
+	assert.Equal(t, []any{"active", "admin", "owner", 18, "50!%!_%"}, clauses.Args)
+
+	_, err = sqlrepo.TranslateCriteria(sqlrepo.DialectPostgres, persistence.Criteria{
+		Filters: []persistence.Filter{persistence.Prefix("name", "a"), {Field: "age", Operator: persistence.OperatorPrefix,
+			Values: []any{1}}},
+	}, 0)
+	assert.ErrorIs(t, err, sqlrepo.ErrUnsupportedFilter)
+}
@@ -0,0 +1,62 @@
+package sqlrepo
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Dialect writes the database-specific fragments of SQL statements.
+type Dialect interface {
+	// Placeholder returns the bind parameter placeholder for the n-th argument (starting from 1).
+	Placeholder(n int) string
+	// QuoteIdentifier quotes `name` so it can be used as a table or column name.
+	QuoteIdentifier(name string) string
+}
+
+var (
+	// DialectPostgres is the [Dialect] for Postgres (e.g. `$1` placeholders, `"name"` identifiers).
+	DialectPostgres Dialect = postgresDialect{}
+	// DialectMySQL is the [Dialect] for MySQL and MariaDB (e.g. `?` placeholders, backtick-quoted identifiers).
+	DialectMySQL Dialect = mysqlDialect{}
+	// DialectSQLite is the [Dialect] for SQLite (e.g. `?` placeholders, `"name"` identifiers).
+	DialectSQLite Dialect = sqliteDialect{}
+)
+
+type postgresDialect struct{}
+
+func (d postgresDialect) Placeholder(n int) string {
+	return "$" + strconv.Itoa(n)
+}
+
+func (d postgresDialect) QuoteIdentifier(name string) string {
+	return quoteIdentifier(name, `"`)
+}
+
+type mysqlDialect struct{}
+
+func (d mysqlDialect) Placeholder(_ int) string {
+	return "?"
+}
+
+func (d mysqlDialect) QuoteIdentifier(name string) string {
+	return quoteIdentifier(name, "`")
+}
+
+type sqliteDialect struct{}
+
+func (d sqliteDialect) Placeholder(_ int) string {
+	return "?"
+}
+
+func (d sqliteDialect) QuoteIdentifier(name string) string {
+	return quoteIdentifier(name, `"`)
+}
+
+// quoteIdentifier quotes every part of a qualified identifier (e.g. `schema.table`), escaping quotes.
+func quoteIdentifier(name, quote string) string {
+	parts := strings.Split(name, ".")
+	for i, part := range parts {
+		parts[i] = quote + strings.ReplaceAll(part, quote, quote+quote) + quote
+	}
+	return strings.Join(parts, ".")
+}
@@ -0,0 +1,143 @@
+package sqlrepo
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/bosonicalio/geck/persistence/audit"
+)
+
+const _tagName = "db"
+
+var _auditableType = reflect.TypeFor[audit.Auditable]()
+
+// column is a struct field mapped to a table column.
+type column struct {
+	name  string
+	index []int
+	isKey bool
+}
+
+// mapping is the table representation of a struct type, built from its `db` struct tags.
+type mapping struct {
+	columns []column
+	key     column
+	// auditIndex is the index of the embedded [audit.Auditable] field, nil if the struct is not auditable.
+	auditIndex []int
+}
+
+// newMapping builds the [mapping] of `typeof`.
+//
+// Fields are mapped using `db` struct tags (e.g. `db:"name"`); untagged fields are ignored while untagged
+// embedded structs are flattened. The key column is tagged using the `key` option (e.g. `db:"id,key"`),
+// defaulting to the `id` column.
+func newMapping(typeof reflect.Type) (mapping, error) {
+	if typeof.Kind() != reflect.Struct {
+		return mapping{}, fmt.Errorf("geck.sqlrepo: %s is not a struct", typeof)
+	}
+	m := mapping{}
+	if err := m.addFields(typeof, nil); err != nil {
+		return mapping{}, err
+	}
+
+	keyIdx, idIdx := -1, -1
+	for i, col := range m.columns {
+		switch {
+		case col.isKey && keyIdx >= 0:
+			return mapping{}, fmt.Errorf("geck.sqlrepo: %s has several key columns", typeof)
+		case col.isKey:
+			keyIdx = i
+		case col.name == "id":
+			idIdx = i
+		}
+	}
+	if keyIdx < 0 {
+		keyIdx = idIdx
+	}
+	if keyIdx < 0 {
+		return mapping{}, fmt.Errorf("geck.sqlrepo: %s has no key column", typeof)
+	}
+	m.columns[keyIdx].isKey = true
+	m.key = m.columns[keyIdx]
+	return m, nil
+}
+
+func (m *mapping) addFields(typeof reflect.Type, parentIndex []int) error {
+	for i := range typeof.NumField() {
+		field := typeof.Field(i)
+		tag, hasTag := field.Tag.Lookup(_tagName)
+		if tag == "-" || (!field.IsExported() && !field.Anonymous) {
+			continue
+		}
+		index := append(append(make([]int, 0, len(parentIndex)+1), parentIndex...), i)
+		if !hasTag {
+			if field.Anonymous && field.Type.Kind() == reflect.Struct {
+				if field.Type == _auditableType {
+					m.auditIndex = index
+				}
+				if err := m.addFields(field.Type, index); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+
+		name, opts, _ := strings.Cut(tag, ",")
+		if name == "" {
+			return errors.New("geck.sqlrepo: empty column name for field " + field.Name)
+		}
+		m.columns = append(m.columns, column{
+			name:  name,
+			index: index,
+			isKey: opts == "key",
+		})
+	}
+	return nil
+}
+
+// hasColumn indicates whether `name` is a mapped column.
+func (m mapping) hasColumn(name string) bool {
+	for _, col := range m.columns {
+		if col.name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// columnNames returns the names of the columns, quoted using `dialect`.
+func (m mapping) columnNames(dialect Dialect) []string {
+	names := make([]string, 0, len(m.columns))
+	for _, col := range m.columns {
+		names = append(names, dialect.QuoteIdentifier(col.name))
+	}
+	return names
+}
+
+// values returns the values of the columns of `v`.
+func (m mapping) values(v reflect.Value) []any {
+	values := make([]any, 0, len(m.columns))
+	for _, col := range m.columns {
+		values = append(values, v.FieldByIndex(col.index).Interface())
+	}
+	return values
+}
+
+// pointers returns pointers to the fields of `v` mapped to columns, to be used as scan destinations.
+func (m mapping) pointers(v reflect.Value) []any {
+	pointers := make([]any, 0, len(m.columns))
+	for _, col := range m.columns {
+		pointers = append(pointers, v.FieldByIndex(col.index).Addr().Interface())
+	}
+	return pointers
+}
+
+// auditable returns the [audit.Auditable] embedded into `v`, nil if the struct is not auditable.
+func (m mapping) auditable(v reflect.Value) *audit.Auditable {
+	if m.auditIndex == nil {
+		return nil
+	}
+	return v.FieldByIndex(m.auditIndex).Addr().Interface().(*audit.Auditable)
+}
@@ -0,0 +1,469 @@
+package sqlrepo
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/bosonicalio/geck/persistence"
+	"github.com/bosonicalio/geck/persistence/audit"
+	"github.com/bosonicalio/geck/persistence/paging"
+	gecksql "github.com/bosonicalio/geck/persistence/sql"
+	"github.com/bosonicalio/geck/syserr"
+)
+
+// -- Error(s) --
+
+var (
+	// ErrVersionConflict is returned when an auditable entity was modified concurrently, i.e. the stored version
+	// differs from the version of the entity being written.
+	ErrVersionConflict = errors.New("geck.sqlrepo: version conflict")
+	// ErrMissingTokenCodec is returned by [Repository.Find] if no page token codec was configured (see
+	// [WithTokenCodec]).
+	ErrMissingTokenCodec = errors.New("geck.sqlrepo: missing page token codec")
+)
+
+// Repository is a generic SQL implementation of [persistence.ReadRepository], [persistence.QueryRepository],
+// [persistence.WriteRepository] and [persistence.WriteBatchRepository].
+//
+// Entities (T) are mapped to table rows using `db` struct tags, e.g.
+//
+//	type User struct {
+//		audit.Auditable
+//		ID   string `db:"id,key"`
+//		Name string `db:"name"`
+//	}
+//
+// Untagged fields are ignored while untagged embedded structs are flattened. The key column is tagged using the
+// `key` option, defaulting to the `id` column.
+//
+// [persistence.Storable.IsNew] indicates whether entities are inserted or updated. If entities embed
+// [audit.Auditable], the repository manages their version, using it for optimistic locking (returning
+// [ErrVersionConflict] if the entity was modified concurrently), and deletes them softly (see [WithSoftDelete]).
+// Thus, entities MUST NOT be touched (see [audit.Touch]) before saving them.
+//
+// Statements are executed through a [gecksql.DBTxPropagator], so they join the transaction found in the context
+// (see [persistence.ExecInTx]). Batch operations are atomic only within such transactions.
+type Repository[K comparable, T any, PT interface {
+	*T
+	persistence.Storable
+}] struct {
+	db      gecksql.DB
+	table   string
+	dialect Dialect
+	mapping mapping
+	options repositoryOptions
+
+	selectQuery string
+	insertQuery string
+	updateQuery string
+	deleteQuery string
+}
+
+// compile-time assertion(s)
+var (
+	_ persistence.ReadRepository[string, persistence.NoopStorable] = (*Repository[string,
+		persistence.NoopStorable, *persistence.NoopStorable])(nil)
+	_ persistence.QueryRepository[persistence.NoopStorable] = (*Repository[string,
+		persistence.NoopStorable, *persistence.NoopStorable])(nil)
+	_ persistence.WriteRepository[string, *persistence.NoopStorable] = (*Repository[string,
+		persistence.NoopStorable, *persistence.NoopStorable])(nil)
+	_ persistence.WriteBatchRepository[string, *persistence.NoopStorable] = (*Repository[string,
+		persistence.NoopStorable, *persistence.NoopStorable])(nil)
+)
+
+const (
+	_versionColumn        = "version"
+	_isDeletedColumn      = "is_deleted"
+	_lastUpdateTimeColumn = "last_update_time"
+	_lastUpdateByColumn   = "last_update_by"
+)
+
+// NewRepository creates a new [Repository] instance for `table`.
+//
+// Returns an error if T cannot be mapped (e.g. T has no key column) or the key set with [WithTokenCipherKey] has an
+// invalid size.
+func NewRepository[K comparable, T any, PT interface {
+	*T
+	persistence.Storable
+}](db gecksql.DB, table string, opts ...RepositoryOption) (*Repository[K, T, PT], error) {
+	options := repositoryOptions{
+		dialect:    DialectPostgres,
+		softDelete: true,
+	}
+	for _, opt := range opts {
+		opt(&options)
+	}
+	m, err := newMapping(reflect.TypeFor[T]())
+	if err != nil {
+		return nil, err
+	}
+	if key, ok := options.tokenCodec.(paging.TokenCipherKey); ok && !slices.Contains([]int{16, 24, 32}, len(key)) {
+		return nil, fmt.Errorf("geck.sqlrepo: invalid token cipher key size %d", len(key))
+	}
+
+	r := &Repository[K, T, PT]{
+		db:      gecksql.NewDBTxPropagator(db),
+		table:   options.dialect.QuoteIdentifier(table),
+		dialect: options.dialect,
+		mapping: m,
+		options: options,
+	}
+	r.buildQueries()
+	return r, nil
+}
+
+// MustNewRepository creates a new [Repository] instance for `table`.
+//
+// This routine will panic if any error occurs (see [NewRepository]).
+func MustNewRepository[K comparable, T any, PT interface {
+	*T
+	persistence.Storable
+}](db gecksql.DB, table string, opts ...RepositoryOption) *Repository[K, T, PT] {
+	r, err := NewRepository[K, T, PT](db, table, opts...)
+	if err != nil {
+		panic(err)
+	}
+	return r
+}
+
+func (r *Repository[K, T, PT]) buildQueries() {
+	d, table := r.dialect, r.table
+	columns := r.mapping.columnNames(d)
+	keyColumn := d.QuoteIdentifier(r.mapping.key.name)
+
+	r.selectQuery = "SELECT " + strings.Join(columns, ", ") + " FROM " + table + " WHERE " + keyColumn + " = " +
+		d.Placeholder(1)
+	if r.isSoftDeleted() {
+		r.selectQuery += " AND " + d.QuoteIdentifier(_isDeletedColumn) + " = " + d.Placeholder(2)
+	}
+
+	placeholders := make([]string, 0, len(columns))
+	for i := range columns {
+		placeholders = append(placeholders, d.Placeholder(i+1))
+	}
+	r.insertQuery = "INSERT INTO " + table + " (" + strings.Join(columns, ", ") + ") VALUES (" +
+		strings.Join(placeholders, ", ") + ")"
+
+	// arguments: non-key columns, key, version (auditable only)
+	sets := make([]string, 0, len(columns))
+	for _, col := range r.mapping.columns {
+		if !col.isKey {
+			sets = append(sets, d.QuoteIdentifier(col.name)+" = "+d.Placeholder(len(sets)+1))
+		}
+	}
+	r.updateQuery = "UPDATE " + table + " SET " + strings.Join(sets, ", ") + " WHERE " + keyColumn + " = " +
+		d.Placeholder(len(sets)+1)
+	r.deleteQuery = "DELETE FROM " + table + " WHERE " + keyColumn + " = " + d.Placeholder(1)
+	if r.mapping.auditIndex != nil {
+		r.updateQuery += " AND " + d.QuoteIdentifier(_versionColumn) + " = " + d.Placeholder(len(sets)+2)
+		r.deleteQuery += " AND " + d.QuoteIdentifier(_versionColumn) + " = " + d.Placeholder(2)
+	}
+}
+
+func (r Repository[K, T, PT]) isSoftDeleted() bool {
+	return r.mapping.auditIndex != nil && r.options.softDelete
+}
+
+// FindByKey retrieves the entity identified by `key`. Softly deleted entities are not found.
+//
+// Returns a [syserr.Error] wrapping [syserr.ErrResourceNotFound] if the entity was not found.
+func (r Repository[K, T, PT]) FindByKey(ctx context.Context, key K) (*T, error) {
+	args := []any{key}
+	if r.isSoftDeleted() {
+		args = append(args, false)
+	}
+	entity := new(T)
+	err := r.db.QueryRowContext(ctx, r.selectQuery, args...).
+		Scan(r.mapping.pointers(reflect.ValueOf(entity).Elem())...)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, syserr.NewResourceNotFound[T]()
+	} else if err != nil {
+		return nil, err
+	}
+	return entity, nil
+}
+
+// Find retrieves a page of the entities matching `criteria`. Softly deleted entities are not found.
+//
+// Criteria fields are column names. Entities are sorted by the key column after the criteria sort orders, so pages
+// are stable. Page tokens are encoded using the codec set with [WithTokenCodec] (or [WithTokenCipherKey]) and are bound
+// to `criteria` (i.e. pass the same criteria along with them); the total number of items is not computed.
+//
+// Returns [ErrMissingTokenCodec] if no codec was configured, as pages could not be traversed.
+func (r Repository[K, T, PT]) Find(ctx context.Context, criteria persistence.Criteria,
+	opts ...paging.Option) (*paging.Page[T], error) {
+	if r.options.tokenCodec == nil {
+		return nil, ErrMissingTokenCodec
+	}
+	token, err := persistence.ResolveCriteria(r.options.tokenCodec, criteria, opts...)
+	if err != nil {
+		return nil, err
+	}
+	if err = r.validateCriteria(token.Criteria); err != nil {
+		return nil, err
+	}
+	clauses, err := TranslateCriteria(r.dialect, token.Criteria, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	d := r.dialect
+	query := strings.Builder{}
+	query.WriteString("SELECT " + strings.Join(r.mapping.columnNames(d), ", ") + " FROM " + r.table)
+	conditions := make([]string, 0, 2)
+	if clauses.Where != "" {
+		conditions = append(conditions, clauses.Where)
+	}
+	if r.isSoftDeleted() {
+		clauses.Args = append(clauses.Args, false)
+		conditions = append(conditions, d.QuoteIdentifier(_isDeletedColumn)+" = "+d.Placeholder(len(clauses.Args)))
+	}
+	if len(conditions) > 0 {
+		query.WriteString(" WHERE " + strings.Join(conditions, " AND "))
+	}
+	query.WriteString(" ORDER BY ")
+	if clauses.OrderBy != "" {
+		query.WriteString(clauses.OrderBy + ", ")
+	}
+	// fetch an extra item to know whether there are more pages
+	query.WriteString(d.QuoteIdentifier(r.mapping.key.name) + " LIMIT " + strconv.Itoa(token.Criteria.Limit+1) +
+		" OFFSET " + strconv.Itoa(token.Offset))
+
+	rows, err := r.db.QueryContext(ctx, query.String(), clauses.Args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := make([]T, 0, token.Criteria.Limit)
+	hasMore := false
+	for rows.Next() {
+		if len(items) == token.Criteria.Limit {
+			hasMore = true
+			break
+		}
+		var item T
+		if err = rows.Scan(r.mapping.pointers(reflect.ValueOf(&item).Elem())...); err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return persistence.NewCriteriaPage(r.options.tokenCodec, token, items, hasMore)
+}
+
+func (r Repository[K, T, PT]) validateCriteria(criteria persistence.Criteria) error {
+	fields := make([]string, 0, len(criteria.Filters)+len(criteria.Sorts))
+	for _, filter := range criteria.Filters {
+		fields = append(fields, filter.Field)
+	}
+	for _, sort := range criteria.Sorts {
+		fields = append(fields, sort.Field)
+	}
+	for _, field := range fields {
+		if !r.mapping.hasColumn(field) {
+			return fmt.Errorf("%w: %s", ErrUnknownField, field)
+		}
+	}
+	return nil
+}
+
+// Save inserts `entity` if it is new (see [persistence.Storable.IsNew]), otherwise, it updates it.
+func (r Repository[K, T, PT]) Save(ctx context.Context, entity PT) error {
+	v := reflect.ValueOf(entity).Elem()
+	auditable := r.mapping.auditable(v)
+	if !entity.IsNew() {
+		return r.update(ctx, v, func(a *audit.Auditable) {
+			audit.Touch(ctx, a)
+		})
+	}
+
+	var prev audit.Auditable
+	if auditable != nil {
+		prev = *auditable
+		auditable.Version++
+	}
+	if _, err := r.db.ExecContext(ctx, r.insertQuery, r.mapping.values(v)...); err != nil {
+		if auditable != nil {
+			*auditable = prev
+		}
+		return err
+	}
+	return nil
+}
+
+// update updates the row of `v`. If `v` is auditable, `touchFunc` is applied to it and the row is updated only if
+// its version did not change.
+func (r Repository[K, T, PT]) update(ctx context.Context, v reflect.Value, touchFunc func(*audit.Auditable)) error {
+	auditable := r.mapping.auditable(v)
+	var prev audit.Auditable
+	if auditable != nil {
+		prev = *auditable
+		touchFunc(auditable)
+	}
+
+	args := make([]any, 0, len(r.mapping.columns)+1)
+	var key any
+	for i, value := range r.mapping.values(v) {
+		if r.mapping.columns[i].isKey {
+			key = value
+			continue
+		}
+		args = append(args, value)
+	}
+	args = append(args, key)
+	if auditable == nil {
+		_, err := r.db.ExecContext(ctx, r.updateQuery, args...)
+		return err
+	}
+
+	args = append(args, prev.Version)
+	err := r.execLocked(ctx, r.updateQuery, args...)
+	if err != nil {
+		*auditable = prev
+	}
+	return err
+}
+
+// execLocked executes an optimistically locked statement, returning [ErrVersionConflict] if no rows were
+// affected.
+func (r Repository[K, T, PT]) execLocked(ctx context.Context, query string, args ...any) error {
+	res, err := r.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	} else if affected == 0 {
+		return ErrVersionConflict
+	}
+	return nil
+}
+
+// DeleteByKey deletes the entity identified by `key`. Auditable entities are deleted softly
+// (see [WithSoftDelete]), without optimistic locking.
+func (r Repository[K, T, PT]) DeleteByKey(ctx context.Context, key K) error {
+	return r.DeleteAllByKeys(ctx, []K{key})
+}
+
+// Delete deletes `entity`. Auditable entities are deleted softly (see [WithSoftDelete] and [audit.SoftDelete]).
+func (r Repository[K, T, PT]) Delete(ctx context.Context, entity PT) error {
+	v := reflect.ValueOf(entity).Elem()
+	if r.isSoftDeleted() {
+		return r.update(ctx, v, func(a *audit.Auditable) {
+			audit.SoftDelete(ctx, a)
+		})
+	}
+
+	key := v.FieldByIndex(r.mapping.key.index).Interface()
+	auditable := r.mapping.auditable(v)
+	if auditable == nil {
+		_, err := r.db.ExecContext(ctx, r.deleteQuery, key)
+		return err
+	}
+	return r.execLocked(ctx, r.deleteQuery, key, auditable.Version)
+}
+
+// SaveAll saves `entities` one by one (see [Repository.Save]).
+func (r Repository[K, T, PT]) SaveAll(ctx context.Context, entities []PT) error {
+	for _, entity := range entities {
+		if err := r.Save(ctx, entity); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// DeleteAll deletes `entities` one by one (see [Repository.Delete]).
+func (r Repository[K, T, PT]) DeleteAll(ctx context.Context, entities []PT) error {
+	for _, entity := range entities {
+		if err := r.Delete(ctx, entity); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// DeleteAllByKeys deletes the entities identified by `keys` using a single statement. Auditable entities are
+// deleted softly (see [WithSoftDelete]), without optimistic locking.
+func (r Repository[K, T, PT]) DeleteAllByKeys(ctx context.Context, keys []K) error {
+	if len(keys) == 0 {
+		return nil
+	}
+
+	d, table := r.dialect, r.table
+	query := strings.Builder{}
+	args := make([]any, 0, len(keys)+3)
+	if r.isSoftDeleted() {
+		touched := audit.New(ctx)
+		args = append(args, true, touched.LastUpdateTime, touched.LastUpdateBy)
+		query.WriteString("UPDATE " + table + " SET " +
+			d.QuoteIdentifier(_isDeletedColumn) + " = " + d.Placeholder(1) + ", " +
+			d.QuoteIdentifier(_versionColumn) + " = " + d.QuoteIdentifier(_versionColumn) + " + 1, " +
+			d.QuoteIdentifier(_lastUpdateTimeColumn) + " = " + d.Placeholder(2) + ", " +
+			d.QuoteIdentifier(_lastUpdateByColumn) + " = " + d.Placeholder(3))
+	} else {
+		query.WriteString("DELETE FROM " + table)
+	}
+	query.WriteString(" WHERE " + d.QuoteIdentifier(r.mapping.key.name) + " IN (")
+	for i, key := range keys {
+		if i > 0 {
+			query.WriteString(", ")
+		}
+		args = append(args, key)
+		query.WriteString(d.Placeholder(len(args)))
+	}
+	query.WriteString(")")
+	_, err := r.db.ExecContext(ctx, query.String(), args...)
+	return err
+}
+
+// -- Options --
+
+type repositoryOptions struct {
+	dialect    Dialect
+	softDelete bool
+	tokenCodec paging.TokenCodec
+}
+
+// RepositoryOption is a routine used to set up [Repository] optional configuration.
+type RepositoryOption func(*repositoryOptions)
+
+// WithDialect sets the [Dialect] used by a [Repository] to write statements. Defaults to [DialectPostgres].
+func WithDialect(dialect Dialect) RepositoryOption {
+	return func(o *repositoryOptions) {
+		o.dialect = dialect
+	}
+}
+
+// WithSoftDelete indicates whether a [Repository] deletes entities embedding [audit.Auditable] softly, i.e.
+// marking them as deleted (see [audit.SoftDelete]) instead of removing their rows. Enabled by default.
+func WithSoftDelete(enabled bool) RepositoryOption {
+	return func(o *repositoryOptions) {
+		o.softDelete = enabled
+	}
+}
+
+// WithTokenCipherKey sets the key used by a [Repository] to encrypt page tokens (see [Repository.Find]). The key
+// must be 16, 24, or 32 bytes long.
+func WithTokenCipherKey(key paging.TokenCipherKey) RepositoryOption {
+	return func(o *repositoryOptions) {
+		o.tokenCodec = key
+	}
+}
+
+// WithTokenCodec sets the [paging.TokenCodec] used by a [Repository] to encode page tokens (see [Repository.Find]),
+// e.g. a [paging.TokenKeyring] bound to the repository table.
+func WithTokenCodec(codec paging.TokenCodec) RepositoryOption {
+	return func(o *repositoryOptions) {
+		o.tokenCodec = codec
+	}
+}
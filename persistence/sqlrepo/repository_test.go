@@ -0,0 +1,274 @@
+package sqlrepo_test
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bosonicalio/geck/persistence"
+	"github.com/bosonicalio/geck/persistence/audit"
+	"github.com/bosonicalio/geck/persistence/paging"
+	"github.com/bosonicalio/geck/persistence/sqlrepo"
+	"github.com/bosonicalio/geck/syserr"
+)
+
+// -- Fake driver --
+
+type statement struct {
+	query string
+	args  []any
+}
+
+// fakeConnector is a [driver.Connector] recording executed statements. Executions affect `rowsAffected` rows
+// while queries return `rows`.
+type fakeConnector struct {
+	mu           sync.Mutex
+	statements   []statement
+	rowsAffected int64
+	columns      []string
+	rows         [][]driver.Value
+}
+
+func (c *fakeConnector) Connect(_ context.Context) (driver.Conn, error) {
+	return fakeConn{connector: c}, nil
+}
+
+func (c *fakeConnector) Driver() driver.Driver {
+	return nil
+}
+
+func (c *fakeConnector) record(query string, args []driver.NamedValue) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	values := make([]any, 0, len(args))
+	for _, arg := range args {
+		values = append(values, arg.Value)
+	}
+	c.statements = append(c.statements, statement{query: query, args: values})
+}
+
+type fakeConn struct {
+	connector *fakeConnector
+}
+
+func (c fakeConn) Prepare(_ string) (driver.Stmt, error) {
+	return nil, errors.New("not supported")
+}
+
+func (c fakeConn) Close() error {
+	return nil
+}
+
+func (c fakeConn) Begin() (driver.Tx, error) {
+	return nil, errors.New("not supported")
+}
+
+func (c fakeConn) ExecContext(_ context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	c.connector.record(query, args)
+	return driver.RowsAffected(c.connector.rowsAffected), nil
+}
+
+func (c fakeConn) QueryContext(_ context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	c.connector.record(query, args)
+	return &fakeRows{columns: c.connector.columns, rows: c.connector.rows}, nil
+}
+
+type fakeRows struct {
+	columns []string
+	rows    [][]driver.Value
+}
+
+func (r *fakeRows) Columns() []string {
+	return r.columns
+}
+
+func (r *fakeRows) Close() error {
+	return nil
+}
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if len(r.rows) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.rows[0])
+	r.rows = r.rows[1:]
+	return nil
+}
+
+// -- Tests --
+
+type user struct {
+	audit.Auditable
+	ID   string `db:"user_id,key"`
+	Name string `db:"name"`
+	Note string
+}
+
+type tag struct {
+	ID    string `db:"id"`
+	Label string `db:"label"`
+	New   bool   `db:"-"`
+}
+
+func (t tag) IsNew() bool {
+	return t.New
+}
+
+func TestRepository_Save(t *testing.T) {
+	ctx := context.Background()
+	connector := &fakeConnector{rowsAffected: 1}
+	repo := sqlrepo.MustNewRepository[string, user](sql.OpenDB(connector), "users")
+
+	u := &user{Auditable: audit.New(ctx), ID: "123", Name: "John"}
+	require.NoError(t, repo.Save(ctx, u))
+	assert.Equal(t, int64(1), u.Version)
+	require.NoError(t, repo.Save(ctx, u))
+	assert.Equal(t, int64(2), u.Version)
+
+	require.Len(t, connector.statements, 2)
+	assert.Equal(t, `INSERT INTO "users" ("create_time", "create_by", "last_update_time", "last_update_by", `+
+		`"version", "is_deleted", "user_id", "name") VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`,
+		connector.statements[0].query)
+	assert.Equal(t, `UPDATE "users" SET "create_time" = $1, "create_by" = $2, "last_update_time" = $3, `+
+		`"last_update_by" = $4, "version" = $5, "is_deleted" = $6, "name" = $7 WHERE "user_id" = $8 AND `+
+		`"version" = $9`, connector.statements[1].query)
+	assert.Equal(t, []any{int64(2), false, "John", "123", int64(1)}, connector.statements[1].args[4:])
+
+	connector.rowsAffected = 0
+	assert.ErrorIs(t, repo.Save(ctx, u), sqlrepo.ErrVersionConflict)
+	assert.Equal(t, int64(2), u.Version)
+}
+
+func TestRepository_FindByKey(t *testing.T) {
+	ctx := context.Background()
+	createTime := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	connector := &fakeConnector{
+		columns: []string{"create_time", "create_by", "last_update_time", "last_update_by", "version",
+			"is_deleted", "user_id", "name"},
+		rows: [][]driver.Value{{createTime, "admin", createTime, "admin", int64(3), false, "123", "John"}},
+	}
+	repo := sqlrepo.MustNewRepository[string, user](sql.OpenDB(connector), "users")
+
+	u, err := repo.FindByKey(ctx, "123")
+	require.NoError(t, err)
+	assert.Equal(t, "John", u.Name)
+	assert.Equal(t, int64(3), u.Version)
+	assert.Equal(t, `SELECT "create_time", "create_by", "last_update_time", "last_update_by", "version", `+
+		`"is_deleted", "user_id", "name" FROM "users" WHERE "user_id" = $1 AND "is_deleted" = $2`,
+		connector.statements[0].query)
+
+	connector.rows = nil
+	_, err = repo.FindByKey(ctx, "456")
+	assert.ErrorIs(t, err, syserr.ErrResourceNotFound)
+}
+
+func TestRepository_Delete(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("soft delete", func(t *testing.T) {
+		connector := &fakeConnector{rowsAffected: 1}
+		repo := sqlrepo.MustNewRepository[string, user](sql.OpenDB(connector), "users")
+		u := &user{Auditable: audit.New(ctx, audit.WithVersion(1)), ID: "123"}
+		require.NoError(t, repo.Delete(ctx, u))
+		assert.True(t, u.IsDeleted)
+		assert.Equal(t, int64(2), u.Version)
+
+		require.NoError(t, repo.DeleteAllByKeys(ctx, []string{"123", "456"}))
+		assert.Equal(t, `UPDATE "users" SET "is_deleted" = $1, "version" = "version" + 1, `+
+			`"last_update_time" = $2, "last_update_by" = $3 WHERE "user_id" IN ($4, $5)`,
+			connector.statements[1].query)
+	})
+
+	t.Run("hard delete", func(t *testing.T) {
+		connector := &fakeConnector{rowsAffected: 0}
+		repo := sqlrepo.MustNewRepository[string, user](sql.OpenDB(connector), "users",
+			sqlrepo.WithSoftDelete(false), sqlrepo.WithDialect(sqlrepo.DialectMySQL))
+		u := &user{Auditable: audit.New(ctx, audit.WithVersion(1)), ID: "123"}
+		assert.ErrorIs(t, repo.Delete(ctx, u), sqlrepo.ErrVersionConflict)
+		assert.Equal(t, "DELETE FROM `users` WHERE `user_id` = ? AND `version` = ?", connector.statements[0].query)
+	})
+}
+
+func TestRepository_NotAuditable(t *testing.T) {
+	ctx := context.Background()
+	connector := &fakeConnector{}
+	repo := sqlrepo.MustNewRepository[string, tag](sql.OpenDB(connector), "tags",
+		sqlrepo.WithDialect(sqlrepo.DialectSQLite))
+
+	require.NoError(t, repo.Save(ctx, &tag{ID: "1", Label: "go", New: true}))
+	require.NoError(t, repo.Save(ctx, &tag{ID: "1", Label: "golang"}))
+	require.NoError(t, repo.DeleteByKey(ctx, "1"))
+	require.Len(t, connector.statements, 3)
+	assert.Equal(t, `INSERT INTO "tags" ("id", "label") VALUES (?, ?)`, connector.statements[0].query)
+	assert.Equal(t, `UPDATE "tags" SET "label" = ? WHERE "id" = ?`, connector.statements[1].query)
+	assert.Equal(t, `DELETE FROM "tags" WHERE "id" IN (?)`, connector.statements[2].query)
+}
+
+func TestNewRepository(t *testing.T) {
+	_, err := sqlrepo.NewRepository[string, struct {
+		tag
+		Other string `db:"other,key"`
+		ID    string `db:"code,key"`
+	}](nil, "invalid")
+	assert.ErrorContains(t, err, "several key columns")
+
+	_, err = sqlrepo.NewRepository[string, tag](nil, "tags",
+		sqlrepo.WithTokenCipherKey(paging.TokenCipherKey("short")))
+	assert.ErrorContains(t, err, "invalid token cipher key size")
+
+	repo, err := sqlrepo.NewRepository[string, tag](nil, "tags",
+		sqlrepo.WithTokenCipherKey(paging.TokenCipherKey("0123456789abcdef")))
+	require.NoError(t, err)
+	assert.NotNil(t, repo)
+
+	assert.Panics(t, func() {
+		sqlrepo.MustNewRepository[string, tag](nil, "tags", sqlrepo.WithTokenCipherKey(paging.TokenCipherKey("short")))
+	})
+}
+
+func TestRepository_Find(t *testing.T) {
+	ctx := context.Background()
+	connector := &fakeConnector{
+		columns: []string{"id", "label"},
+		rows:    [][]driver.Value{{"1", "go"}, {"2", "golang"}, {"3", "gopher"}},
+	}
+	repo := sqlrepo.MustNewRepository[string, tag](sql.OpenDB(connector), "tags",
+		sqlrepo.WithTokenCipherKey(paging.TokenCipherKey("0123456789abcdef")))
+
+	criteria := persistence.Criteria{
+		Filters: []persistence.Filter{persistence.Prefix("label", "go")},
+		Sorts:   []persistence.SortOrder{persistence.Desc("label")},
+		Limit:   2,
+	}
+	page, err := repo.Find(ctx, criteria)
+	require.NoError(t, err)
+	assert.Equal(t, []tag{{ID: "1", Label: "go"}, {ID: "2", Label: "golang"}}, page.Items)
+	assert.NotEmpty(t, page.NextPageToken)
+	assert.Equal(t, `SELECT "id", "label" FROM "tags" WHERE "label" LIKE $1 ESCAPE '!' ORDER BY "label" DESC, "id" `+
+		`LIMIT 3 OFFSET 0`, connector.statements[0].query)
+
+	connector.rows = [][]driver.Value{{"3", "gopher"}}
+	page, err = repo.Find(ctx, criteria, paging.WithPageToken(page.NextPageToken))
+	require.NoError(t, err)
+	assert.Len(t, page.Items, 1)
+	assert.Empty(t, page.NextPageToken)
+	assert.NotEmpty(t, page.PreviousPageToken)
+	assert.Equal(t, `SELECT "id", "label" FROM "tags" WHERE "label" LIKE $1 ESCAPE '!' ORDER BY "label" DESC, "id" `+
+		`LIMIT 3 OFFSET 2`, connector.statements[1].query)
+
+	_, err = repo.Find(ctx, persistence.Criteria{Sorts: []persistence.SortOrder{persistence.Asc("unknown")}})
+	assert.ErrorIs(t, err, sqlrepo.ErrUnknownField)
+
+	statementCount := len(connector.statements)
+	_, err = sqlrepo.MustNewRepository[string, tag](sql.OpenDB(connector), "tags").Find(ctx, criteria)
+	assert.ErrorIs(t, err, sqlrepo.ErrMissingTokenCodec)
+	assert.Len(t, connector.statements, statementCount)
+}
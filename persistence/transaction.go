@@ -41,6 +41,130 @@ func FromTxContext(ctx context.Context, driver TxDriver) (Transaction, bool) {
 	return tx, ok
 }
 
+// -- Hooks --
+
+var (
+	// ErrTxHookFailed is returned when one or more hooks registered with [OnCommit] or [OnRollback] fail.
+	// The unit of work was already completed at that point, so the outcome of transactions is not affected.
+	ErrTxHookFailed = errors.New("geck.persistence: transaction hook failed")
+)
+
+type txHooksContextKey struct{}
+
+type txHookKind uint8
+
+const (
+	txHookCommit txHookKind = iota
+	txHookRollback
+	// txHookAlways hooks are rollback hooks of joined units of work rolled back while their parent was still
+	// running.
+	txHookAlways
+)
+
+type txHook struct {
+	kind txHookKind
+	fn   func(ctx context.Context) error
+}
+
+// txHooks holds the routines to be executed once a unit of work completes.
+//
+// Units of work joined to a parent unit of work (i.e. savepoints, see [PropagationNested]) hand their hooks over
+// to the parent, so hooks are executed once the parent completes. Units of work owning their transactions (e.g.
+// [ExecInTx] called with another [TxFactory] within [TxManager.Execute]) are completed independently, so they
+// execute their hooks right away.
+type txHooks struct {
+	parent *txHooks
+
+	mu    sync.Mutex
+	hooks []txHook
+}
+
+// withTxHooks creates the hooks scope of a unit of work. `isJoined` indicates whether the unit of work is joined
+// to the unit of work found in `parent` (if any).
+func withTxHooks(parent context.Context, isJoined bool) (context.Context, *txHooks) {
+	hooks := &txHooks{}
+	if isJoined {
+		hooks.parent, _ = parent.Value(txHooksContextKey{}).(*txHooks)
+	}
+	return context.WithValue(parent, txHooksContextKey{}, hooks), hooks
+}
+
+// OnCommit registers `fn` to be executed after the unit of work found in `ctx` (see [ExecInTx] and
+// [TxManager.Execute]) is committed successfully. Hence, `fn` is never executed if transactions are rolled back.
+//
+// Hooks are executed in registration order once the unit of work completes; hooks of nested transactions (see
+// [PropagationNested]) are executed once the transaction holding the savepoint completes. Errors returned by hooks
+// are reported by the unit of work wrapped with [ErrTxHookFailed].
+//
+// Returns [ErrInvalidTxContext] if `ctx` was not created by [ExecInTx] nor [TxManager.Execute].
+func OnCommit(ctx context.Context, fn func(ctx context.Context) error) error {
+	return registerTxHook(ctx, txHookCommit, fn)
+}
+
+// OnRollback registers `fn` to be executed after the unit of work found in `ctx` (see [ExecInTx] and
+// [TxManager.Execute]) is rolled back.
+//
+// Hooks are executed in registration order once the unit of work completes; hooks of nested transactions (see
+// [PropagationNested]) are executed once the transaction holding the savepoint completes. Errors returned by hooks
+// are reported by the unit of work wrapped with [ErrTxHookFailed].
+//
+// Returns [ErrInvalidTxContext] if `ctx` was not created by [ExecInTx] nor [TxManager.Execute].
+func OnRollback(ctx context.Context, fn func(ctx context.Context) error) error {
+	return registerTxHook(ctx, txHookRollback, fn)
+}
+
+func registerTxHook(ctx context.Context, kind txHookKind, fn func(ctx context.Context) error) error {
+	hooks, ok := ctx.Value(txHooksContextKey{}).(*txHooks)
+	if !ok {
+		return ErrInvalidTxContext
+	}
+	hooks.mu.Lock()
+	defer hooks.mu.Unlock()
+	hooks.hooks = append(hooks.hooks, txHook{kind: kind, fn: fn})
+	return nil
+}
+
+// complete finishes the unit of work. Joined units of work hand their hooks over to their parent while other
+// units of work execute the hooks matching their outcome (`isCommitted`), returning their errors.
+func (h *txHooks) complete(ctx context.Context, isCommitted bool) error {
+	h.mu.Lock()
+	hooks := h.hooks
+	h.hooks = nil
+	h.mu.Unlock()
+
+	if h.parent != nil {
+		h.parent.mu.Lock()
+		defer h.parent.mu.Unlock()
+		for _, hook := range hooks {
+			switch {
+			case isCommitted:
+				h.parent.hooks = append(h.parent.hooks, hook)
+			case hook.kind != txHookCommit:
+				// work done by this unit was discarded regardless of the parent outcome
+				h.parent.hooks = append(h.parent.hooks, txHook{kind: txHookAlways, fn: hook.fn})
+			}
+		}
+		return nil
+	}
+
+	errs := make([]error, 0)
+	for _, hook := range hooks {
+		isMatch := hook.kind == txHookAlways ||
+			(isCommitted && hook.kind == txHookCommit) ||
+			(!isCommitted && hook.kind == txHookRollback)
+		if !isMatch {
+			continue
+		}
+		if err := hook.fn(ctx); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if len(errs) == 0 {
+		return nil
+	}
+	return fmt.Errorf("%w: %w", ErrTxHookFailed, errors.Join(errs...))
+}
+
 // --- Factory ---
 
 // TxFactory is a component responsible for the creation of persistence transactions.
@@ -51,6 +175,15 @@ type TxFactory interface {
 	NewTx(ctx context.Context) (Transaction, error)
 }
 
+// SavepointTxFactory is a [TxFactory] able to create savepoints within existing transactions, enabling nested
+// transactions (see [PropagationNested]).
+type SavepointTxFactory interface {
+	TxFactory
+	// NewSavepoint creates a savepoint within `parent`. Committing the returned [Transaction] releases the
+	// savepoint while rolling it back discards the work done since the savepoint was created.
+	NewSavepoint(ctx context.Context, parent Transaction) (Transaction, error)
+}
+
 // TxManager is a manager for transaction factories that allows registering multiple
 // transaction factories and executing functions within the context of all registered transactions.
 // It provides a way to coordinate multiple transactions as a single logical transaction.
@@ -58,15 +191,25 @@ type TxFactory interface {
 // It is useful for scenarios where multiple databases or transaction sources need to be coordinated
 // together, ensuring that all operations across these transactions are either committed or rolled back
 // as a single unit of work.
+//
+// By default, transactions are committed one by one, so a failing commit cannot undo the transactions committed
+// before it. Use [WithTwoPhaseCommit] to prepare every transaction before committing any (see
+// [PreparableTransaction]).
 type TxManager struct {
 	regMu     sync.RWMutex
 	factories []TxFactory
+	options   txManagerOptions
 }
 
 // NewTxManager creates a new instance of [TxManager].
-func NewTxManager() *TxManager {
+func NewTxManager(opts ...TxManagerOption) *TxManager {
+	options := txManagerOptions{}
+	for _, opt := range opts {
+		opt(&options)
+	}
 	return &TxManager{
 		factories: make([]TxFactory, 0),
+		options:   options,
 	}
 }
 
@@ -93,7 +236,21 @@ func (m *TxManager) GetFactories() []TxFactory {
 // If any transaction fails during commit, all transactions are rolled back to maintain consistency.
 // The function `fn` receives a context that has all transactions set, allowing it to perform database operations
 // across multiple transaction contexts.
-func (m *TxManager) Execute(ctx context.Context, fn func(ctx context.Context) error) (err error) {
+//
+// Hooks registered using [OnCommit] and [OnRollback] are executed once all transactions are completed.
+// Transactions left in doubt by a two-phase commit (see [ErrTxInDoubt]) are considered committed.
+//
+// Use [WithTxManagerRetry] to re-run `fn` in new transactions when it fails due to transient transaction errors.
+func (m *TxManager) Execute(ctx context.Context, fn func(ctx context.Context) error) error {
+	if m.options.retryPolicy == nil {
+		return m.execute(ctx, fn)
+	}
+	return m.options.retryPolicy.execute(ctx, func() error {
+		return m.execute(ctx, fn)
+	})
+}
+
+func (m *TxManager) execute(ctx context.Context, fn func(ctx context.Context) error) (err error) {
 	if len(m.factories) == 0 {
 		return errors.New("no transaction factories registered")
 	}
@@ -118,7 +275,14 @@ func (m *TxManager) Execute(ctx context.Context, fn func(ctx context.Context) er
 		}
 		transactions = append(transactions, txInfo{tx: tx, executor: m.factories[i].Driver()})
 		txCtx = WithTxContext(txCtx, m.factories[i].Driver(), tx)
+		if _, ok := tx.(PreparableTransaction); m.options.twoPhaseCommit && !ok {
+			for _, txInfo := range transactions {
+				_ = txInfo.tx.Rollback(ctx)
+			}
+			return fmt.Errorf("%w: executor %s", ErrTxNotPreparable, m.factories[i].Driver())
+		}
 	}
+	txCtx, hooks := withTxHooks(txCtx, false)
 
 	defer func() {
 		if r := recover(); r != nil {
@@ -129,6 +293,7 @@ func (m *TxManager) Execute(ctx context.Context, fn func(ctx context.Context) er
 			err = errors.Join(err, panicErr)
 		}
 
+		// hooks are executed using the parent context as transactions are already finished
 		if err != nil {
 			// Rollback all transactions in reverse order
 			for i := len(transactions) - 1; i >= 0; i-- {
@@ -136,6 +301,17 @@ func (m *TxManager) Execute(ctx context.Context, fn func(ctx context.Context) er
 					err = errors.Join(err, errRollback)
 				}
 			}
+			err = errors.Join(err, hooks.complete(ctx, false))
+			return
+		}
+
+		if m.options.twoPhaseCommit {
+			txs := make([]PreparableTransaction, 0, len(transactions))
+			for _, txInfo := range transactions {
+				txs = append(txs, txInfo.tx.(PreparableTransaction))
+			}
+			isCommitted, errCommit := commitTwoPhase(txCtx, txs)
+			err = errors.Join(errCommit, hooks.complete(ctx, isCommitted))
 			return
 		}
 
@@ -149,9 +325,11 @@ func (m *TxManager) Execute(ctx context.Context, fn func(ctx context.Context) er
 						err = errors.Join(err, errRollback)
 					}
 				}
+				err = errors.Join(err, hooks.complete(ctx, false))
 				return
 			}
 		}
+		err = hooks.complete(ctx, true)
 	}()
 
 	err = fn(txCtx)
@@ -160,17 +338,86 @@ func (m *TxManager) Execute(ctx context.Context, fn func(ctx context.Context) er
 
 // --- Utilities ---
 
+var (
+	// ErrTxRequired is returned by [ExecInTx] when using [PropagationMandatory] and `ctx` holds no transaction.
+	ErrTxRequired = errors.New("geck.persistence: transaction required")
+	// ErrNestedTxNotSupported is returned by [ExecInTx] when using [PropagationNested] with a [TxFactory] not
+	// implementing [SavepointTxFactory].
+	ErrNestedTxNotSupported = errors.New("geck.persistence: nested transactions not supported")
+)
+
 // ExecInTx executes the provided function `fn` within the context of a transaction.
 // It automatically handles transaction commit and rollback based on the function's execution result.
 // If `fn` panics, it will recover and rollback the transaction, returning the panic as an error.
 // The function `fn` receives a context that has the transaction set, allowing it to perform database operations
 // within the transaction scope.
-func ExecInTx(ctx context.Context, factory TxFactory, fn func(ctx context.Context) error) (err error) {
-	tx, err := factory.NewTx(ctx)
-	if err != nil {
-		return fmt.Errorf("failed to create new transaction: %w", err)
+//
+// If `ctx` already holds a transaction from `factory`, the behavior is selected using [WithTxPropagation]
+// (defaults to [PropagationRequiresNew], so `fn` always runs in a fresh transaction unless told otherwise). Use [WithTxRetry] to re-run `fn` in a fresh transaction when it fails
+// due to transient transaction errors.
+//
+// Hooks registered using [OnCommit] and [OnRollback] are executed once the transaction is completed.
+func ExecInTx(ctx context.Context, factory TxFactory, fn func(ctx context.Context) error, opts ...TxOption) error {
+	options := txOptions{
+		propagation: PropagationRequiresNew,
+	}
+	for _, opt := range opts {
+		opt(&options)
 	}
-	txCtx := WithTxContext(ctx, factory.Driver(), tx)
+
+	parent, hasParent := FromTxContext(ctx, factory.Driver())
+	switch options.propagation {
+	case PropagationMandatory:
+		if !hasParent {
+			return ErrTxRequired
+		}
+		return fn(ctx)
+	case PropagationRequiresNew:
+		if hasParent {
+			// detach the transaction from the running unit of work
+			return execInNewTx(WithTxContext(ctx, factory.Driver(), nil), factory, fn, options.retryPolicy)
+		}
+	case PropagationNested:
+		if hasParent {
+			spFactory, ok := factory.(SavepointTxFactory)
+			if !ok {
+				return ErrNestedTxNotSupported
+			}
+			savepoint, err := spFactory.NewSavepoint(ctx, parent)
+			if err != nil {
+				return fmt.Errorf("failed to create savepoint: %w", err)
+			}
+			// operations keep using the parent transaction, the savepoint only delimits the work to discard
+			return execTx(ctx, ctx, savepoint, true, fn)
+		}
+	default:
+		if hasParent {
+			return fn(ctx)
+		}
+	}
+	return execInNewTx(ctx, factory, fn, options.retryPolicy)
+}
+
+func execInNewTx(ctx context.Context, factory TxFactory, fn func(ctx context.Context) error,
+	retryPolicy *TxRetryPolicy) error {
+	exec := func() error {
+		tx, err := factory.NewTx(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to create new transaction: %w", err)
+		}
+		return execTx(ctx, WithTxContext(ctx, factory.Driver(), tx), tx, false, fn)
+	}
+	if retryPolicy == nil {
+		return exec()
+	}
+	return retryPolicy.execute(ctx, exec)
+}
+
+// execTx executes `fn` using `txCtx`, then, completes `tx` based on the function's execution result.
+// `isJoined` indicates whether `tx` is joined to the transaction found in `ctx` (i.e. a savepoint).
+func execTx(ctx, txCtx context.Context, tx Transaction, isJoined bool,
+	fn func(ctx context.Context) error) (err error) {
+	txCtx, hooks := withTxHooks(txCtx, isJoined)
 	defer func() {
 		if r := recover(); r != nil {
 			// Ensure we handle panics gracefully starting from persistence layer
@@ -180,16 +427,99 @@ func ExecInTx(ctx context.Context, factory TxFactory, fn func(ctx context.Contex
 			}
 			err = errors.Join(err, panicErr)
 		}
+		// hooks are executed using the parent context as the transaction is already finished
 		if err != nil {
 			if errRollback := tx.Rollback(txCtx); errRollback != nil {
 				err = errors.Join(err, errRollback)
 			}
+			err = errors.Join(err, hooks.complete(ctx, false))
 			return
 		}
 		if errCommit := tx.Commit(txCtx); errCommit != nil {
-			err = errors.Join(err, errCommit)
+			err = errors.Join(err, errCommit, hooks.complete(ctx, false))
+			return
 		}
+		err = hooks.complete(ctx, true)
 	}()
 	err = fn(txCtx)
 	return
 }
+
+// -- Options --
+
+type txManagerOptions struct {
+	twoPhaseCommit bool
+	retryPolicy    *TxRetryPolicy
+}
+
+// TxManagerOption is a routine used to set up [TxManager] optional configuration.
+type TxManagerOption func(*txManagerOptions)
+
+// WithTwoPhaseCommit indicates whether a [TxManager] commits transactions using a two-phase commit protocol.
+// Disabled by default.
+//
+// When enabled, every registered factory MUST create [PreparableTransaction] instances and SHOULD implement
+// [PreparedTxRecoverer] so transactions left in doubt can be resolved using [TxManager.Recover].
+func WithTwoPhaseCommit(enabled bool) TxManagerOption {
+	return func(o *txManagerOptions) {
+		o.twoPhaseCommit = enabled
+	}
+}
+
+// WithTxManagerRetry sets the [TxRetryPolicy] used by [TxManager.Execute] to re-run the function in new
+// transactions if it fails with a retryable error. Transactions left in doubt (see [ErrTxInDoubt]) are never
+// retried.
+func WithTxManagerRetry(policy TxRetryPolicy) TxManagerOption {
+	return func(o *txManagerOptions) {
+		policy = policy.withDefaults()
+		o.retryPolicy = &policy
+	}
+}
+
+// TxPropagation indicates how [ExecInTx] behaves when the context already holds a transaction.
+type TxPropagation uint8
+
+const (
+	// PropagationRequired joins the transaction found in the context, creating a new one if none is found.
+	// The joined transaction is completed by its creator, so errors returned by the function are propagated
+	// as-is.
+	PropagationRequired TxPropagation = iota
+	// PropagationRequiresNew always creates a new, independent transaction. The transaction found in the
+	// context (if any) is not affected by the outcome of the new one.
+	PropagationRequiresNew
+	// PropagationNested creates a savepoint within the transaction found in the context (see
+	// [SavepointTxFactory]), so only the work done by the function is discarded on failure. A new transaction is
+	// created if none is found.
+	PropagationNested
+	// PropagationMandatory joins the transaction found in the context, returning [ErrTxRequired] if none is
+	// found.
+	PropagationMandatory
+)
+
+type txOptions struct {
+	propagation TxPropagation
+	retryPolicy *TxRetryPolicy
+}
+
+// TxOption is a routine used to set up [ExecInTx] optional configuration.
+type TxOption func(*txOptions)
+
+// WithTxPropagation sets the [TxPropagation] used by [ExecInTx].
+func WithTxPropagation(propagation TxPropagation) TxOption {
+	return func(o *txOptions) {
+		o.propagation = propagation
+	}
+}
+
+// WithTxRetry sets the [TxRetryPolicy] used by [ExecInTx] to re-run the function in a new transaction if it
+// fails with a retryable error.
+//
+// Retries only apply to transactions created by [ExecInTx]. Joined transactions and savepoints are never retried
+// as transient errors (e.g. serialization failures) usually abort the whole transaction, so the outermost unit
+// of work is the one which should be retried.
+func WithTxRetry(policy TxRetryPolicy) TxOption {
+	return func(o *txOptions) {
+		policy = policy.withDefaults()
+		o.retryPolicy = &policy
+	}
+}
@@ -0,0 +1,78 @@
+package persistence
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"time"
+
+	"github.com/samber/lo"
+)
+
+// TxRetryPolicy is the configuration used to re-run units of work failing due to transient transaction errors
+// (e.g. serialization failures or deadlocks). Zero values fall back to defaults.
+//
+// See [WithTxRetry] and [WithTxManagerRetry].
+type TxRetryPolicy struct {
+	// MaxAttempts is the maximum number of times the unit of work is executed, including the first execution.
+	// Defaults to 3.
+	MaxAttempts int
+	// InitialBackoff is the delay before the first retry. Defaults to 50 milliseconds.
+	InitialBackoff time.Duration
+	// MaxBackoff is the upper bound of the delay between retries. Defaults to 1 second.
+	MaxBackoff time.Duration
+	// Classifier indicates whether an error is transient, hence, retryable. Classifiers are driver-specific;
+	// errors are never retried if nil.
+	Classifier func(err error) bool
+}
+
+func (p TxRetryPolicy) withDefaults() TxRetryPolicy {
+	p.MaxAttempts = lo.CoalesceOrEmpty(p.MaxAttempts, 3)
+	p.InitialBackoff = lo.CoalesceOrEmpty(p.InitialBackoff, 50*time.Millisecond)
+	p.MaxBackoff = lo.CoalesceOrEmpty(p.MaxBackoff, time.Second)
+	if p.Classifier == nil {
+		p.Classifier = func(_ error) bool {
+			return false
+		}
+	}
+	return p
+}
+
+// execute calls `fn` until it succeeds, returns a permanent error or attempts are exhausted.
+//
+// Units of work are never retried if their hooks failed (see [ErrTxHookFailed]) or their transactions are in
+// doubt (see [ErrTxInDoubt]), as transactions were already committed at that point.
+func (p TxRetryPolicy) execute(ctx context.Context, fn func() error) error {
+	var err error
+	for attempt := 1; ; attempt++ {
+		err = fn()
+		isCommitted := errors.Is(err, ErrTxHookFailed) || errors.Is(err, ErrTxInDoubt)
+		if err == nil || isCommitted || attempt >= p.MaxAttempts || !p.Classifier(err) || ctx.Err() != nil {
+			return err
+		}
+
+		delay := p.backoff(attempt)
+		if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) <= delay {
+			return err
+		}
+		timer := time.NewTimer(delay)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		}
+	}
+}
+
+// backoff computes the delay before the retry following `attempt` using exponential backoff with
+// equal jitter.
+func (p TxRetryPolicy) backoff(attempt int) time.Duration {
+	delay := p.InitialBackoff
+	for i := 1; i < attempt && delay < p.MaxBackoff; i++ {
+		delay *= 2
+	}
+	delay = min(delay, p.MaxBackoff)
+	half := delay / 2
+	return half + rand.N(delay-half+1)
+}
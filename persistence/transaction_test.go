@@ -0,0 +1,367 @@
+package persistence_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"github.com/bosonicalio/geck/persistence"
+	"github.com/bosonicalio/geck/persistencemock"
+)
+
+func newTxFactory(ctrl *gomock.Controller, driver persistence.TxDriver, commitErr error) *persistencemock.MockTxFactory {
+	tx := persistencemock.NewMockTransaction(ctrl)
+	tx.EXPECT().Commit(gomock.Any()).Return(commitErr).AnyTimes()
+	tx.EXPECT().Rollback(gomock.Any()).Return(nil).AnyTimes()
+	factory := persistencemock.NewMockTxFactory(ctrl)
+	factory.EXPECT().Driver().Return(driver).AnyTimes()
+	factory.EXPECT().NewTx(gomock.Any()).Return(tx, nil).AnyTimes()
+	return factory
+}
+
+func TestOnCommit(t *testing.T) {
+	ctx := context.Background()
+	assert.ErrorIs(t, persistence.OnCommit(ctx, nil), persistence.ErrInvalidTxContext)
+	assert.ErrorIs(t, persistence.OnRollback(ctx, nil), persistence.ErrInvalidTxContext)
+
+	t.Run("committed", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		calls := make([]string, 0)
+		err := persistence.ExecInTx(ctx, newTxFactory(ctrl, "mock", nil), func(ctx context.Context) error {
+			for _, name := range []string{"first", "second"} {
+				require.NoError(t, persistence.OnCommit(ctx, func(_ context.Context) error {
+					calls = append(calls, name)
+					return nil
+				}))
+			}
+			require.NoError(t, persistence.OnRollback(ctx, func(_ context.Context) error {
+				calls = append(calls, "rollback")
+				return nil
+			}))
+			assert.Empty(t, calls)
+			return nil
+		})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"first", "second"}, calls)
+	})
+
+	t.Run("rolled back", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		calls := make([]string, 0)
+		errFn := errors.New("some error")
+		err := persistence.ExecInTx(ctx, newTxFactory(ctrl, "mock", nil), func(ctx context.Context) error {
+			require.NoError(t, persistence.OnCommit(ctx, func(_ context.Context) error {
+				calls = append(calls, "commit")
+				return nil
+			}))
+			require.NoError(t, persistence.OnRollback(ctx, func(_ context.Context) error {
+				calls = append(calls, "rollback")
+				return nil
+			}))
+			return errFn
+		})
+		assert.ErrorIs(t, err, errFn)
+		assert.Equal(t, []string{"rollback"}, calls)
+	})
+
+	t.Run("commit failed", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		calls := make([]string, 0)
+		errCommit := errors.New("commit failed")
+		err := persistence.ExecInTx(ctx, newTxFactory(ctrl, "mock", errCommit), func(ctx context.Context) error {
+			require.NoError(t, persistence.OnCommit(ctx, func(_ context.Context) error {
+				calls = append(calls, "commit")
+				return nil
+			}))
+			require.NoError(t, persistence.OnRollback(ctx, func(_ context.Context) error {
+				calls = append(calls, "rollback")
+				return nil
+			}))
+			return nil
+		})
+		assert.ErrorIs(t, err, errCommit)
+		assert.Equal(t, []string{"rollback"}, calls)
+	})
+
+	t.Run("hook errors", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		errHook := errors.New("hook failed")
+		calls := 0
+		err := persistence.ExecInTx(ctx, newTxFactory(ctrl, "mock", nil), func(ctx context.Context) error {
+			require.NoError(t, persistence.OnCommit(ctx, func(_ context.Context) error {
+				calls++
+				return errHook
+			}))
+			require.NoError(t, persistence.OnCommit(ctx, func(_ context.Context) error {
+				calls++
+				return nil
+			}))
+			return nil
+		})
+		assert.ErrorIs(t, err, persistence.ErrTxHookFailed)
+		assert.ErrorIs(t, err, errHook)
+		assert.Equal(t, 2, calls)
+	})
+
+	t.Run("independent inner unit of work", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		manager := persistence.NewTxManager()
+		manager.Register(newTxFactory(ctrl, "outer", nil))
+		innerFactory := newTxFactory(ctrl, "inner", nil)
+		calls := make([]string, 0)
+		errInner, errOuter := errors.New("inner error"), errors.New("outer error")
+		err := manager.Execute(ctx, func(ctx context.Context) error {
+			require.NoError(t, persistence.OnCommit(ctx, func(_ context.Context) error {
+				calls = append(calls, "outer commit")
+				return nil
+			}))
+			errExec := persistence.ExecInTx(ctx, innerFactory, func(ctx context.Context) error {
+				require.NoError(t, persistence.OnCommit(ctx, func(_ context.Context) error {
+					calls = append(calls, "inner commit")
+					return nil
+				}))
+				return nil
+			})
+			require.NoError(t, errExec)
+			errExec = persistence.ExecInTx(ctx, innerFactory, func(ctx context.Context) error {
+				require.NoError(t, persistence.OnCommit(ctx, func(_ context.Context) error {
+					calls = append(calls, "discarded commit")
+					return nil
+				}))
+				require.NoError(t, persistence.OnRollback(ctx, func(_ context.Context) error {
+					calls = append(calls, "inner rollback")
+					return nil
+				}))
+				return errInner
+			})
+			require.ErrorIs(t, errExec, errInner)
+			// inner transactions were completed on their own, regardless of the outer outcome
+			assert.Equal(t, []string{"inner commit", "inner rollback"}, calls)
+			return errOuter
+		})
+		require.ErrorIs(t, err, errOuter)
+		assert.Equal(t, []string{"inner commit", "inner rollback"}, calls)
+	})
+
+	t.Run("savepoint", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		parent := persistencemock.NewMockTransaction(ctrl)
+		parent.EXPECT().Rollback(gomock.Any()).Return(nil)
+		savepoint := persistencemock.NewMockTransaction(ctrl)
+		savepoint.EXPECT().Commit(gomock.Any()).Return(nil)
+		factory := persistencemock.NewMockSavepointTxFactory(ctrl)
+		factory.EXPECT().Driver().Return(persistence.TxDriver("mock")).AnyTimes()
+		factory.EXPECT().NewTx(gomock.Any()).Return(parent, nil)
+		factory.EXPECT().NewSavepoint(gomock.Any(), parent).Return(savepoint, nil)
+		calls := make([]string, 0)
+		errOuter := errors.New("outer error")
+		err := persistence.ExecInTx(ctx, factory, func(ctx context.Context) error {
+			errExec := persistence.ExecInTx(ctx, factory, func(ctx context.Context) error {
+				require.NoError(t, persistence.OnCommit(ctx, func(_ context.Context) error {
+					calls = append(calls, "savepoint commit")
+					return nil
+				}))
+				return persistence.OnRollback(ctx, func(_ context.Context) error {
+					calls = append(calls, "savepoint rollback")
+					return nil
+				})
+			}, persistence.WithTxPropagation(persistence.PropagationNested))
+			require.NoError(t, errExec)
+			// savepoints are joined to the parent transaction, so hooks wait for it
+			assert.Empty(t, calls)
+			return errOuter
+		})
+		require.ErrorIs(t, err, errOuter)
+		assert.Equal(t, []string{"savepoint rollback"}, calls)
+	})
+}
+
+func TestExecInTx_Propagation(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("required joins parent", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		parent := persistencemock.NewMockTransaction(ctrl)
+		factory := persistencemock.NewMockTxFactory(ctrl)
+		factory.EXPECT().Driver().Return(persistence.TxDriver("mock")).AnyTimes()
+		parentCtx := persistence.WithTxContext(ctx, "mock", parent)
+		errFn := errors.New("some error")
+		err := persistence.ExecInTx(parentCtx, factory, func(ctx context.Context) error {
+			tx, ok := persistence.FromTxContext(ctx, "mock")
+			require.True(t, ok)
+			assert.Equal(t, parent, tx)
+			return errFn
+		}, persistence.WithTxPropagation(persistence.PropagationRequired))
+		assert.ErrorIs(t, err, errFn)
+	})
+
+	t.Run("requires new by default", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		parent := persistencemock.NewMockTransaction(ctrl)
+		tx := persistencemock.NewMockTransaction(ctrl)
+		tx.EXPECT().Commit(gomock.Any()).Return(nil)
+		factory := persistencemock.NewMockTxFactory(ctrl)
+		factory.EXPECT().Driver().Return(persistence.TxDriver("mock")).AnyTimes()
+		factory.EXPECT().NewTx(gomock.Any()).DoAndReturn(func(ctx context.Context) (persistence.Transaction, error) {
+			_, ok := persistence.FromTxContext(ctx, "mock")
+			assert.False(t, ok)
+			return tx, nil
+		})
+		calls := make([]string, 0)
+		err := persistence.ExecInTx(ctx, newTxFactory(ctrl, "outer", nil), func(ctx context.Context) error {
+			ctx = persistence.WithTxContext(ctx, "mock", parent)
+			errExec := persistence.ExecInTx(ctx, factory, func(ctx context.Context) error {
+				return persistence.OnCommit(ctx, func(_ context.Context) error {
+					calls = append(calls, "inner commit")
+					return nil
+				})
+			})
+			require.NoError(t, errExec)
+			// independent transactions execute their hooks right away
+			assert.Equal(t, []string{"inner commit"}, calls)
+			return nil
+		})
+		require.NoError(t, err)
+	})
+
+	t.Run("nested", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		parent := persistencemock.NewMockTransaction(ctrl)
+		savepoint := persistencemock.NewMockTransaction(ctrl)
+		savepoint.EXPECT().Rollback(gomock.Any()).Return(nil)
+		factory := persistencemock.NewMockSavepointTxFactory(ctrl)
+		factory.EXPECT().Driver().Return(persistence.TxDriver("mock")).AnyTimes()
+		factory.EXPECT().NewSavepoint(gomock.Any(), parent).Return(savepoint, nil)
+		parentCtx := persistence.WithTxContext(ctx, "mock", parent)
+		errFn := errors.New("some error")
+		err := persistence.ExecInTx(parentCtx, factory, func(ctx context.Context) error {
+			tx, ok := persistence.FromTxContext(ctx, "mock")
+			require.True(t, ok)
+			assert.Equal(t, parent, tx)
+			return errFn
+		}, persistence.WithTxPropagation(persistence.PropagationNested))
+		assert.ErrorIs(t, err, errFn)
+	})
+
+	t.Run("nested not supported", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		parentCtx := persistence.WithTxContext(ctx, "mock", persistencemock.NewMockTransaction(ctrl))
+		err := persistence.ExecInTx(parentCtx, newTxFactory(ctrl, "mock", nil), func(_ context.Context) error {
+			return nil
+		}, persistence.WithTxPropagation(persistence.PropagationNested))
+		assert.ErrorIs(t, err, persistence.ErrNestedTxNotSupported)
+	})
+
+	t.Run("nested without parent", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		tx := persistencemock.NewMockTransaction(ctrl)
+		tx.EXPECT().Commit(gomock.Any()).Return(nil)
+		factory := persistencemock.NewMockSavepointTxFactory(ctrl)
+		factory.EXPECT().Driver().Return(persistence.TxDriver("mock")).AnyTimes()
+		factory.EXPECT().NewTx(gomock.Any()).Return(tx, nil)
+		err := persistence.ExecInTx(ctx, factory, func(_ context.Context) error {
+			return nil
+		}, persistence.WithTxPropagation(persistence.PropagationNested))
+		require.NoError(t, err)
+	})
+
+	t.Run("mandatory", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		factory := newTxFactory(ctrl, "mock", nil)
+		fn := func(_ context.Context) error { return nil }
+		err := persistence.ExecInTx(ctx, factory, fn, persistence.WithTxPropagation(persistence.PropagationMandatory))
+		assert.ErrorIs(t, err, persistence.ErrTxRequired)
+
+		parentCtx := persistence.WithTxContext(ctx, "mock", persistencemock.NewMockTransaction(ctrl))
+		err = persistence.ExecInTx(parentCtx, factory, fn, persistence.WithTxPropagation(persistence.PropagationMandatory))
+		require.NoError(t, err)
+	})
+}
+
+func TestExecInTx_Retry(t *testing.T) {
+	ctx := context.Background()
+	errConflict := errors.New("serialization failure")
+	policy := persistence.TxRetryPolicy{
+		MaxAttempts:    3,
+		InitialBackoff: time.Millisecond,
+		Classifier: func(err error) bool {
+			return errors.Is(err, errConflict)
+		},
+	}
+
+	t.Run("retried", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		tx := persistencemock.NewMockTransaction(ctrl)
+		tx.EXPECT().Rollback(gomock.Any()).Return(nil).Times(1)
+		tx.EXPECT().Commit(gomock.Any()).Return(nil).Times(1)
+		factory := persistencemock.NewMockTxFactory(ctrl)
+		factory.EXPECT().Driver().Return(persistence.TxDriver("mock")).AnyTimes()
+		factory.EXPECT().NewTx(gomock.Any()).Return(tx, nil).Times(2)
+		attempts := 0
+		err := persistence.ExecInTx(ctx, factory, func(_ context.Context) error {
+			attempts++
+			if attempts == 1 {
+				return errConflict
+			}
+			return nil
+		}, persistence.WithTxRetry(policy))
+		require.NoError(t, err)
+		assert.Equal(t, 2, attempts)
+	})
+
+	t.Run("exhausted", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		attempts := 0
+		err := persistence.ExecInTx(ctx, newTxFactory(ctrl, "mock", nil), func(_ context.Context) error {
+			attempts++
+			return errConflict
+		}, persistence.WithTxRetry(policy))
+		assert.ErrorIs(t, err, errConflict)
+		assert.Equal(t, 3, attempts)
+	})
+
+	t.Run("permanent error", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		errFn := errors.New("some error")
+		attempts := 0
+		err := persistence.ExecInTx(ctx, newTxFactory(ctrl, "mock", nil), func(_ context.Context) error {
+			attempts++
+			return errFn
+		}, persistence.WithTxRetry(policy))
+		assert.ErrorIs(t, err, errFn)
+		assert.Equal(t, 1, attempts)
+	})
+
+	t.Run("joined transaction", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		attempts := 0
+		parentCtx := persistence.WithTxContext(ctx, "mock", persistencemock.NewMockTransaction(ctrl))
+		err := persistence.ExecInTx(parentCtx, newTxFactory(ctrl, "mock", nil), func(_ context.Context) error {
+			attempts++
+			return errConflict
+		}, persistence.WithTxPropagation(persistence.PropagationRequired), persistence.WithTxRetry(policy))
+		assert.ErrorIs(t, err, errConflict)
+		assert.Equal(t, 1, attempts)
+	})
+
+	t.Run("tx manager", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		manager := persistence.NewTxManager(persistence.WithTxManagerRetry(policy))
+		manager.Register(newTxFactory(ctrl, "mock", nil))
+		attempts := 0
+		err := manager.Execute(ctx, func(_ context.Context) error {
+			attempts++
+			if attempts < 3 {
+				return errConflict
+			}
+			return nil
+		})
+		require.NoError(t, err)
+		assert.Equal(t, 3, attempts)
+	})
+}
@@ -0,0 +1,249 @@
+package persistence
+
+import (
+	"cmp"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// PreparableTransaction is a [Transaction] supporting the two-phase commit protocol (2PC).
+//
+// Once prepared, a transaction is no longer bound to the session which created it and survives crashes of
+// the persistence system until it is either committed or rolled back using its identifier.
+type PreparableTransaction interface {
+	Transaction
+	// Prepare prepares the transaction to be committed later on, using `id` as its global identifier.
+	Prepare(ctx context.Context, id string) error
+	// CommitPrepared commits the transaction previously prepared as `id`.
+	CommitPrepared(ctx context.Context, id string) error
+	// RollbackPrepared rolls back the transaction previously prepared as `id`.
+	RollbackPrepared(ctx context.Context, id string) error
+}
+
+// PreparedTx is a transaction prepared (see [PreparableTransaction]) and not yet resolved.
+type PreparedTx struct {
+	// ID is the global identifier of the transaction.
+	ID string
+	// PrepareTime is the time the transaction was prepared.
+	PrepareTime time.Time
+}
+
+// PreparedTxRecoverer is a component able to list and resolve the prepared transactions of a persistence system.
+//
+// [TxFactory] instances creating [PreparableTransaction] instances implement this interface so transactions left
+// in doubt by a [TxManager] can be resolved (see [TxManager.Recover]).
+type PreparedTxRecoverer interface {
+	// ListPrepared lists the transactions prepared and not yet resolved.
+	ListPrepared(ctx context.Context) ([]PreparedTx, error)
+	// CommitPrepared commits the transaction prepared as `id`.
+	CommitPrepared(ctx context.Context, id string) error
+	// RollbackPrepared rolls back the transaction prepared as `id`.
+	RollbackPrepared(ctx context.Context, id string) error
+}
+
+var (
+	// ErrTxNotPreparable is returned by [TxManager.Execute] when two-phase commit is enabled
+	// (see [WithTwoPhaseCommit]) and a factory creates a transaction not implementing [PreparableTransaction].
+	ErrTxNotPreparable = errors.New("geck.persistence: transaction not preparable")
+	// ErrTxInDoubt is returned by [TxManager.Execute] when a two-phase commit failed after some transactions were
+	// committed. Remaining transactions are left prepared, to be committed by [TxManager.Recover].
+	ErrTxInDoubt = errors.New("geck.persistence: transaction in doubt")
+)
+
+const preparedTxIDPrefix = "geck_2pc"
+
+// commitTwoPhase commits `txs` using the two-phase commit protocol. Returns whether transactions were committed,
+// including transactions left in doubt.
+//
+// Transactions are prepared and committed in order while rolled back in reverse order, so [TxManager.Recover]
+// can infer the outcome of a global transaction from its participants left prepared: if the first participant is
+// gone while the last one is still prepared, the commit phase had started.
+func commitTwoPhase(ctx context.Context, txs []PreparableTransaction) (bool, error) {
+	globalID, err := newGlobalTxID()
+	if err != nil {
+		errs := []error{fmt.Errorf("failed to generate global transaction id: %w", err)}
+		for i := len(txs) - 1; i >= 0; i-- {
+			if errRollback := txs[i].Rollback(ctx); errRollback != nil {
+				errs = append(errs, errRollback)
+			}
+		}
+		return false, errors.Join(errs...)
+	}
+
+	ids := make([]string, 0, len(txs))
+	for i, tx := range txs {
+		id := formatPreparedTxID(globalID, i, len(txs))
+		if errPrepare := tx.Prepare(ctx, id); errPrepare != nil {
+			errs := []error{fmt.Errorf("failed to prepare transaction %s: %w", id, errPrepare)}
+			for j := len(txs) - 1; j >= i; j-- {
+				if errRollback := txs[j].Rollback(ctx); errRollback != nil {
+					errs = append(errs, errRollback)
+				}
+			}
+			for j := i - 1; j >= 0; j-- {
+				if errRollback := txs[j].RollbackPrepared(ctx, ids[j]); errRollback != nil {
+					errs = append(errs, errRollback)
+				}
+			}
+			return false, errors.Join(errs...)
+		}
+		ids = append(ids, id)
+	}
+
+	for i, tx := range txs {
+		errCommit := tx.CommitPrepared(ctx, ids[i])
+		if errCommit == nil {
+			continue
+		} else if i > 0 {
+			return true, fmt.Errorf("%w: failed to commit transaction %s: %w", ErrTxInDoubt, ids[i], errCommit)
+		}
+
+		// nothing was committed yet, so the global transaction can still be rolled back
+		errs := []error{fmt.Errorf("failed to commit transaction %s: %w", ids[i], errCommit)}
+		for j := len(txs) - 1; j >= 0; j-- {
+			if errRollback := txs[j].RollbackPrepared(ctx, ids[j]); errRollback != nil {
+				errs = append(errs, errRollback)
+			}
+		}
+		return false, errors.Join(errs...)
+	}
+	return true, nil
+}
+
+func newGlobalTxID() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+func formatPreparedTxID(globalID string, index, total int) string {
+	return preparedTxIDPrefix + ":" + globalID + ":" + strconv.Itoa(index) + ":" + strconv.Itoa(total)
+}
+
+func parsePreparedTxID(id string) (globalID string, index, total int, ok bool) {
+	parts := strings.Split(id, ":")
+	if len(parts) != 4 || parts[0] != preparedTxIDPrefix {
+		return "", 0, 0, false
+	}
+	index, errIndex := strconv.Atoi(parts[2])
+	total, errTotal := strconv.Atoi(parts[3])
+	if errIndex != nil || errTotal != nil || index < 0 || index >= total {
+		return "", 0, 0, false
+	}
+	return parts[1], index, total, true
+}
+
+// Recover resolves the transactions left in doubt by two-phase commits of this manager (see
+// [WithTwoPhaseCommit]), e.g. after a crash of the process. Registered factories MUST implement
+// [PreparedTxRecoverer] and be registered in the same order used by the failed [TxManager.Execute] calls.
+//
+// A global transaction is committed if its commit phase had started. Otherwise, it is rolled back.
+// Global transactions with participants prepared recently (see [WithTxRecoveryMinAge]) are skipped as they
+// might still be in progress.
+func (m *TxManager) Recover(ctx context.Context, opts ...TxRecoveryOption) error {
+	options := txRecoveryOptions{
+		minAge: time.Minute * 5,
+	}
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	factories := m.GetFactories()
+	recoverers := make([]PreparedTxRecoverer, 0, len(factories))
+	for _, factory := range factories {
+		recoverer, ok := factory.(PreparedTxRecoverer)
+		if !ok {
+			return fmt.Errorf("%w: executor %s cannot recover prepared transactions", ErrTxNotPreparable,
+				factory.Driver())
+		}
+		recoverers = append(recoverers, recoverer)
+	}
+
+	type globalTx struct {
+		id           string
+		total        int
+		participants map[int]string
+		isRecent     bool
+	}
+	globalTxs := make(map[string]*globalTx)
+	for _, recoverer := range recoverers {
+		// factories might share the same persistence system, hence, the same prepared transactions
+		preparedTxs, err := recoverer.ListPrepared(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to list prepared transactions: %w", err)
+		}
+		for _, preparedTx := range preparedTxs {
+			globalID, index, total, ok := parsePreparedTxID(preparedTx.ID)
+			if !ok {
+				continue
+			}
+			gtx, ok := globalTxs[globalID]
+			if !ok {
+				gtx = &globalTx{id: globalID, total: total, participants: make(map[int]string)}
+				globalTxs[globalID] = gtx
+			}
+			gtx.participants[index] = preparedTx.ID
+			gtx.isRecent = gtx.isRecent || time.Since(preparedTx.PrepareTime) < options.minAge
+		}
+	}
+
+	errs := make([]error, 0)
+	for _, gtx := range globalTxs {
+		if gtx.isRecent {
+			continue
+		} else if gtx.total != len(recoverers) {
+			errs = append(errs, fmt.Errorf("cannot resolve global transaction %s: expected %d participants, got %d",
+				gtx.id, gtx.total, len(recoverers)))
+			continue
+		}
+
+		indexes := make([]int, 0, len(gtx.participants))
+		for index := range gtx.participants {
+			indexes = append(indexes, index)
+		}
+		_, isFirstPrepared := gtx.participants[0]
+		_, isLastPrepared := gtx.participants[gtx.total-1]
+		if isCommitPhase := !isFirstPrepared && isLastPrepared; isCommitPhase {
+			slices.Sort(indexes)
+			for _, index := range indexes {
+				if err := recoverers[index].CommitPrepared(ctx, gtx.participants[index]); err != nil {
+					errs = append(errs, err)
+				}
+			}
+			continue
+		}
+		slices.SortFunc(indexes, func(a, b int) int { return cmp.Compare(b, a) })
+		for _, index := range indexes {
+			if err := recoverers[index].RollbackPrepared(ctx, gtx.participants[index]); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// -- Options --
+
+type txRecoveryOptions struct {
+	minAge time.Duration
+}
+
+// TxRecoveryOption is a routine used to set up [TxManager.Recover] optional configuration.
+type TxRecoveryOption func(*txRecoveryOptions)
+
+// WithTxRecoveryMinAge sets the minimum time since a transaction was prepared for it to be resolved by
+// [TxManager.Recover]. Defaults to 5 minutes.
+func WithTxRecoveryMinAge(d time.Duration) TxRecoveryOption {
+	return func(o *txRecoveryOptions) {
+		o.minAge = d
+	}
+}
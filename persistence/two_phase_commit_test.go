@@ -0,0 +1,144 @@
+package persistence_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"github.com/bosonicalio/geck/persistence"
+	"github.com/bosonicalio/geck/persistencemock"
+)
+
+type recoverableTxFactory struct {
+	*persistencemock.MockTxFactory
+	*persistencemock.MockPreparedTxRecoverer
+}
+
+func newPreparableTx(ctrl *gomock.Controller, driver persistence.TxDriver) (*persistencemock.MockTxFactory,
+	*persistencemock.MockPreparableTransaction) {
+	tx := persistencemock.NewMockPreparableTransaction(ctrl)
+	factory := persistencemock.NewMockTxFactory(ctrl)
+	factory.EXPECT().Driver().Return(driver).AnyTimes()
+	factory.EXPECT().NewTx(gomock.Any()).Return(tx, nil)
+	return factory, tx
+}
+
+func TestTxManager_Execute_TwoPhaseCommit(t *testing.T) {
+	ctx := context.Background()
+	fn := func(_ context.Context) error { return nil }
+
+	t.Run("committed", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		factoryA, txA := newPreparableTx(ctrl, "a")
+		factoryB, txB := newPreparableTx(ctrl, "b")
+		ids := make([]string, 0, 2)
+		prepare := func(_ context.Context, id string) error {
+			ids = append(ids, id)
+			return nil
+		}
+		gomock.InOrder(
+			txA.EXPECT().Prepare(gomock.Any(), gomock.Any()).DoAndReturn(prepare),
+			txB.EXPECT().Prepare(gomock.Any(), gomock.Any()).DoAndReturn(prepare),
+			txA.EXPECT().CommitPrepared(gomock.Any(), gomock.Any()).Return(nil),
+			txB.EXPECT().CommitPrepared(gomock.Any(), gomock.Any()).Return(nil),
+		)
+
+		manager := persistence.NewTxManager(persistence.WithTwoPhaseCommit(true))
+		manager.Register(factoryA)
+		manager.Register(factoryB)
+		calls := 0
+		err := manager.Execute(ctx, func(ctx context.Context) error {
+			return persistence.OnCommit(ctx, func(_ context.Context) error {
+				calls++
+				return nil
+			})
+		})
+		require.NoError(t, err)
+		assert.Equal(t, 1, calls)
+		require.Len(t, ids, 2)
+		assert.True(t, strings.HasSuffix(ids[0], ":0:2"))
+		assert.True(t, strings.HasSuffix(ids[1], ":1:2"))
+		assert.Equal(t, strings.TrimSuffix(ids[0], ":0:2"), strings.TrimSuffix(ids[1], ":1:2"))
+	})
+
+	t.Run("prepare failed", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		factoryA, txA := newPreparableTx(ctrl, "a")
+		factoryB, txB := newPreparableTx(ctrl, "b")
+		errPrepare := errors.New("prepare failed")
+		gomock.InOrder(
+			txA.EXPECT().Prepare(gomock.Any(), gomock.Any()).Return(nil),
+			txB.EXPECT().Prepare(gomock.Any(), gomock.Any()).Return(errPrepare),
+			txB.EXPECT().Rollback(gomock.Any()).Return(nil),
+			txA.EXPECT().RollbackPrepared(gomock.Any(), gomock.Any()).Return(nil),
+		)
+
+		manager := persistence.NewTxManager(persistence.WithTwoPhaseCommit(true))
+		manager.Register(factoryA)
+		manager.Register(factoryB)
+		assert.ErrorIs(t, manager.Execute(ctx, fn), errPrepare)
+	})
+
+	t.Run("in doubt", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		factoryA, txA := newPreparableTx(ctrl, "a")
+		factoryB, txB := newPreparableTx(ctrl, "b")
+		errCommit := errors.New("commit failed")
+		gomock.InOrder(
+			txA.EXPECT().Prepare(gomock.Any(), gomock.Any()).Return(nil),
+			txB.EXPECT().Prepare(gomock.Any(), gomock.Any()).Return(nil),
+			txA.EXPECT().CommitPrepared(gomock.Any(), gomock.Any()).Return(nil),
+			txB.EXPECT().CommitPrepared(gomock.Any(), gomock.Any()).Return(errCommit),
+		)
+
+		manager := persistence.NewTxManager(persistence.WithTwoPhaseCommit(true))
+		manager.Register(factoryA)
+		manager.Register(factoryB)
+		err := manager.Execute(ctx, fn)
+		assert.ErrorIs(t, err, persistence.ErrTxInDoubt)
+		assert.ErrorIs(t, err, errCommit)
+	})
+
+	t.Run("not preparable", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		manager := persistence.NewTxManager(persistence.WithTwoPhaseCommit(true))
+		manager.Register(newTxFactory(ctrl, "a", nil))
+		assert.ErrorIs(t, manager.Execute(ctx, fn), persistence.ErrTxNotPreparable)
+	})
+}
+
+func TestTxManager_Recover(t *testing.T) {
+	ctx := context.Background()
+	ctrl := gomock.NewController(t)
+	factoryA := recoverableTxFactory{
+		MockTxFactory:           persistencemock.NewMockTxFactory(ctrl),
+		MockPreparedTxRecoverer: persistencemock.NewMockPreparedTxRecoverer(ctrl),
+	}
+	factoryB := recoverableTxFactory{
+		MockTxFactory:           persistencemock.NewMockTxFactory(ctrl),
+		MockPreparedTxRecoverer: persistencemock.NewMockPreparedTxRecoverer(ctrl),
+	}
+	prepareTime := time.Now().Add(-time.Hour)
+	factoryA.MockPreparedTxRecoverer.EXPECT().ListPrepared(ctx).Return([]persistence.PreparedTx{
+		{ID: "geck_2pc:aborted:0:2", PrepareTime: prepareTime},
+		{ID: "geck_2pc:recent:0:2", PrepareTime: time.Now()},
+		{ID: "some_other_tx", PrepareTime: prepareTime},
+	}, nil)
+	factoryB.MockPreparedTxRecoverer.EXPECT().ListPrepared(ctx).Return([]persistence.PreparedTx{
+		{ID: "geck_2pc:committing:1:2", PrepareTime: prepareTime},
+		{ID: "geck_2pc:recent:1:2", PrepareTime: prepareTime},
+	}, nil)
+	factoryA.MockPreparedTxRecoverer.EXPECT().RollbackPrepared(ctx, "geck_2pc:aborted:0:2").Return(nil)
+	factoryB.MockPreparedTxRecoverer.EXPECT().CommitPrepared(ctx, "geck_2pc:committing:1:2").Return(nil)
+
+	manager := persistence.NewTxManager(persistence.WithTwoPhaseCommit(true))
+	manager.Register(factoryA)
+	manager.Register(factoryB)
+	require.NoError(t, manager.Recover(ctx, persistence.WithTxRecoveryMinAge(time.Minute)))
+}
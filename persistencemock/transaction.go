@@ -121,3 +121,71 @@ func (mr *MockTxFactoryMockRecorder) NewTx(ctx any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "NewTx", reflect.TypeOf((*MockTxFactory)(nil).NewTx), ctx)
 }
+
+// MockSavepointTxFactory is a mock of SavepointTxFactory interface.
+type MockSavepointTxFactory struct {
+	ctrl     *gomock.Controller
+	recorder *MockSavepointTxFactoryMockRecorder
+	isgomock struct{}
+}
+
+// MockSavepointTxFactoryMockRecorder is the mock recorder for MockSavepointTxFactory.
+type MockSavepointTxFactoryMockRecorder struct {
+	mock *MockSavepointTxFactory
+}
+
+// NewMockSavepointTxFactory creates a new mock instance.
+func NewMockSavepointTxFactory(ctrl *gomock.Controller) *MockSavepointTxFactory {
+	mock := &MockSavepointTxFactory{ctrl: ctrl}
+	mock.recorder = &MockSavepointTxFactoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockSavepointTxFactory) EXPECT() *MockSavepointTxFactoryMockRecorder {
+	return m.recorder
+}
+
+// Driver mocks base method.
+func (m *MockSavepointTxFactory) Driver() persistence.TxDriver {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Driver")
+	ret0, _ := ret[0].(persistence.TxDriver)
+	return ret0
+}
+
+// Driver indicates an expected call of Driver.
+func (mr *MockSavepointTxFactoryMockRecorder) Driver() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Driver", reflect.TypeOf((*MockSavepointTxFactory)(nil).Driver))
+}
+
+// NewSavepoint mocks base method.
+func (m *MockSavepointTxFactory) NewSavepoint(ctx context.Context, parent persistence.Transaction) (persistence.Transaction, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "NewSavepoint", ctx, parent)
+	ret0, _ := ret[0].(persistence.Transaction)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// NewSavepoint indicates an expected call of NewSavepoint.
+func (mr *MockSavepointTxFactoryMockRecorder) NewSavepoint(ctx, parent any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "NewSavepoint", reflect.TypeOf((*MockSavepointTxFactory)(nil).NewSavepoint), ctx, parent)
+}
+
+// NewTx mocks base method.
+func (m *MockSavepointTxFactory) NewTx(ctx context.Context) (persistence.Transaction, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "NewTx", ctx)
+	ret0, _ := ret[0].(persistence.Transaction)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// NewTx indicates an expected call of NewTx.
+func (mr *MockSavepointTxFactoryMockRecorder) NewTx(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "NewTx", reflect.TypeOf((*MockSavepointTxFactory)(nil).NewTx), ctx)
+}
@@ -0,0 +1,76 @@
+package cryptox
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// KEKProvider is a component wrapping (encrypting) and unwrapping data keys using key-encryption keys (KEK), e.g.
+// a key management service.
+//
+// KEKs are identified by provider-specific IDs (e.g. a key ARN) and never leave the provider.
+type KEKProvider interface {
+	// WrapKey encrypts `dataKey` using the KEK identified by `kekID`.
+	WrapKey(ctx context.Context, kekID string, dataKey []byte) ([]byte, error)
+	// UnwrapKey decrypts `wrappedKey`, a data key encrypted using the KEK identified by `kekID`.
+	UnwrapKey(ctx context.Context, kekID string, wrappedKey []byte) ([]byte, error)
+}
+
+// -- Local --
+
+// LocalKEKProvider is a [KEKProvider] holding KEKs in memory, wrapping data keys using AES-GCM.
+//
+// Use it for local development, tests or deployments without a key management service.
+type LocalKEKProvider struct {
+	keks map[string][]byte
+}
+
+// compile-time assertion
+var _ KEKProvider = LocalKEKProvider{}
+
+// NewLocalKEKProvider creates a [LocalKEKProvider] from `keks`, indexed by ID. KEKs must be 16, 24, or 32 bytes
+// long.
+func NewLocalKEKProvider(keks map[string][]byte) LocalKEKProvider {
+	return LocalKEKProvider{keks: keks}
+}
+
+// LoadLocalKEKProvider creates a [LocalKEKProvider] from the JSON file at `path`. The file holds an object
+// mapping KEK IDs to base64 encoded KEKs, e.g. `{"app-kek": "MDEyMzQ1Njc4OWFiY2RlZg=="}`.
+func LoadLocalKEKProvider(path string) (LocalKEKProvider, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return LocalKEKProvider{}, err
+	}
+	encoded := map[string]string{}
+	if err = json.Unmarshal(data, &encoded); err != nil {
+		return LocalKEKProvider{}, err
+	}
+	keks := make(map[string][]byte, len(encoded))
+	for id, v := range encoded {
+		if keks[id], err = base64.StdEncoding.DecodeString(v); err != nil {
+			return LocalKEKProvider{}, fmt.Errorf("geck.cryptox: invalid KEK %q: %w", id, err)
+		}
+	}
+	return NewLocalKEKProvider(keks), nil
+}
+
+// WrapKey encrypts `dataKey` using the KEK identified by `kekID`, authenticating the ID as well.
+func (p LocalKEKProvider) WrapKey(_ context.Context, kekID string, dataKey []byte) ([]byte, error) {
+	kek, ok := p.keks[kekID]
+	if !ok {
+		return nil, fmt.Errorf("%w: KEK %q", ErrKeyNotFound, kekID)
+	}
+	return seal(kek, dataKey, []byte(kekID))
+}
+
+// UnwrapKey decrypts `wrappedKey` using the KEK identified by `kekID`.
+func (p LocalKEKProvider) UnwrapKey(_ context.Context, kekID string, wrappedKey []byte) ([]byte, error) {
+	kek, ok := p.keks[kekID]
+	if !ok {
+		return nil, fmt.Errorf("%w: KEK %q", ErrKeyNotFound, kekID)
+	}
+	return open(kek, wrappedKey, []byte(kekID))
+}
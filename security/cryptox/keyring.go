@@ -0,0 +1,137 @@
+package cryptox
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+)
+
+var (
+	// ErrKeyNotFound is returned when a key is not registered into a [Keyring] or a [KEKProvider].
+	ErrKeyNotFound = errors.New("geck.cryptox: key not found")
+	// ErrMalformedCiphertext is returned when a ciphertext header produced by a [Keyring] cannot be parsed.
+	ErrMalformedCiphertext = errors.New("geck.cryptox: malformed ciphertext")
+	// ErrUnsupportedFormat is returned when a ciphertext was produced using an unsupported format version.
+	ErrUnsupportedFormat = errors.New("geck.cryptox: unsupported ciphertext format")
+)
+
+const (
+	// _formatVersion is the current version of the ciphertexts produced by [Keyring].
+	_formatVersion byte = 1
+	// _dataKeySize is the size of the data keys generated by [Keyring] (AES-256).
+	_dataKeySize = 32
+)
+
+// Key is a named and versioned key registered into a [Keyring].
+type Key struct {
+	Name    string
+	Version uint32
+	// KEKID is the ID of the key-encryption key (KEK) wrapping data keys within the [KEKProvider] (e.g. a key ARN).
+	KEKID string
+}
+
+// Keyring is a component encrypting data using envelope encryption: each plaintext is encrypted using a fresh
+// data key (AES-256-GCM) which is then wrapped by the key-encryption key (KEK) of a [Key], using a [KEKProvider].
+//
+// Ciphertexts are self-describing; they start with a header holding the format version, the key name and version,
+// and the wrapped data key. The header is authenticated along with the associated data (AAD) passed by callers.
+//
+// Keys are rotated by registering a new version; plaintexts are encrypted using the latest version of a key while
+// ciphertexts are decrypted using the version they were encrypted with.
+type Keyring struct {
+	provider KEKProvider
+	keys     map[string]map[uint32]Key
+	latest   map[string]uint32
+}
+
+// NewKeyring creates a [Keyring] using `provider` to wrap data keys with the KEKs of `keys`.
+func NewKeyring(provider KEKProvider, keys ...Key) (*Keyring, error) {
+	k := &Keyring{
+		provider: provider,
+		keys:     make(map[string]map[uint32]Key, len(keys)),
+		latest:   make(map[string]uint32, len(keys)),
+	}
+	for _, key := range keys {
+		if key.Name == "" || len(key.Name) > math.MaxUint8 {
+			return nil, fmt.Errorf("geck.cryptox: invalid key name %q", key.Name)
+		}
+		if _, ok := k.keys[key.Name]; !ok {
+			k.keys[key.Name] = make(map[uint32]Key)
+		}
+		if _, ok := k.keys[key.Name][key.Version]; ok {
+			return nil, fmt.Errorf("geck.cryptox: duplicated key %s@%d", key.Name, key.Version)
+		}
+		k.keys[key.Name][key.Version] = key
+		k.latest[key.Name] = max(k.latest[key.Name], key.Version)
+	}
+	return k, nil
+}
+
+// Encrypt encrypts `plaintext` using the latest version of the key named `keyName`. `aad` is authenticated but not
+// encrypted, so the same `aad` MUST be passed to [Keyring.Decrypt] (e.g. a record ID binding the ciphertext to it).
+func (k *Keyring) Encrypt(ctx context.Context, keyName string, plaintext, aad []byte) ([]byte, error) {
+	version, ok := k.latest[keyName]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrKeyNotFound, keyName)
+	}
+	key := k.keys[keyName][version]
+
+	dataKey := make([]byte, _dataKeySize)
+	if _, err := rand.Read(dataKey); err != nil {
+		return nil, err
+	}
+	wrappedKey, err := k.provider.WrapKey(ctx, key.KEKID, dataKey)
+	if err != nil {
+		return nil, err
+	} else if len(wrappedKey) > math.MaxUint16 {
+		return nil, errors.New("geck.cryptox: wrapped data key too long")
+	}
+
+	header := make([]byte, 0, 8+len(key.Name)+len(wrappedKey))
+	header = append(header, _formatVersion, byte(len(key.Name)))
+	header = append(header, key.Name...)
+	header = binary.BigEndian.AppendUint32(header, key.Version)
+	header = binary.BigEndian.AppendUint16(header, uint16(len(wrappedKey)))
+	header = append(header, wrappedKey...)
+
+	sealed, err := seal(dataKey, plaintext, append(header[:len(header):len(header)], aad...))
+	if err != nil {
+		return nil, err
+	}
+	return append(header, sealed...), nil
+}
+
+// Decrypt decrypts `ciphertext` produced by [Keyring.Encrypt] using the key version it was encrypted with.
+func (k *Keyring) Decrypt(ctx context.Context, ciphertext, aad []byte) ([]byte, error) {
+	if len(ciphertext) < 2 {
+		return nil, ErrMalformedCiphertext
+	} else if ciphertext[0] != _formatVersion {
+		return nil, fmt.Errorf("%w: %d", ErrUnsupportedFormat, ciphertext[0])
+	}
+	nameLen := int(ciphertext[1])
+	offset := 2 + nameLen + 6 // name, version and wrapped key length
+	if len(ciphertext) < offset {
+		return nil, ErrMalformedCiphertext
+	}
+	name := string(ciphertext[2 : 2+nameLen])
+	version := binary.BigEndian.Uint32(ciphertext[2+nameLen:])
+	wrappedKeyLen := int(binary.BigEndian.Uint16(ciphertext[offset-2:]))
+	if len(ciphertext) < offset+wrappedKeyLen {
+		return nil, ErrMalformedCiphertext
+	}
+	wrappedKey := ciphertext[offset : offset+wrappedKeyLen]
+	header := ciphertext[:offset+wrappedKeyLen]
+
+	key, ok := k.keys[name][version]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s@%d", ErrKeyNotFound, name, version)
+	}
+	dataKey, err := k.provider.UnwrapKey(ctx, key.KEKID, wrappedKey)
+	if err != nil {
+		return nil, err
+	}
+	return open(dataKey, ciphertext[len(header):], append(header[:len(header):len(header)], aad...))
+}
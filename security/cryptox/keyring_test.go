@@ -0,0 +1,84 @@
+package cryptox_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bosonicalio/geck/security/cryptox"
+)
+
+func TestKeyring(t *testing.T) {
+	ctx := context.Background()
+	provider := cryptox.NewLocalKEKProvider(map[string][]byte{
+		"kek-1": []byte("0123456789abcdef0123456789abcdef"),
+		"kek-2": []byte("fedcba9876543210fedcba9876543210"),
+	})
+	keyring, err := cryptox.NewKeyring(provider, cryptox.Key{Name: "users", Version: 1, KEKID: "kek-1"})
+	require.NoError(t, err)
+
+	ciphertext, err := keyring.Encrypt(ctx, "users", []byte("secret"), []byte("user-123"))
+	require.NoError(t, err)
+	plaintext, err := keyring.Decrypt(ctx, ciphertext, []byte("user-123"))
+	require.NoError(t, err)
+	assert.Equal(t, []byte("secret"), plaintext)
+
+	t.Run("aad mismatch", func(t *testing.T) {
+		_, err := keyring.Decrypt(ctx, ciphertext, []byte("user-456"))
+		assert.Error(t, err)
+	})
+
+	t.Run("key rotation", func(t *testing.T) {
+		rotated, err := cryptox.NewKeyring(provider,
+			cryptox.Key{Name: "users", Version: 1, KEKID: "kek-1"},
+			cryptox.Key{Name: "users", Version: 2, KEKID: "kek-2"},
+		)
+		require.NoError(t, err)
+		plaintext, err := rotated.Decrypt(ctx, ciphertext, []byte("user-123"))
+		require.NoError(t, err)
+		assert.Equal(t, []byte("secret"), plaintext)
+
+		rotatedCiphertext, err := rotated.Encrypt(ctx, "users", []byte("secret"), nil)
+		require.NoError(t, err)
+		_, err = keyring.Decrypt(ctx, rotatedCiphertext, nil)
+		assert.ErrorIs(t, err, cryptox.ErrKeyNotFound)
+	})
+
+	t.Run("malformed", func(t *testing.T) {
+		_, err := keyring.Decrypt(ctx, ciphertext[:10], []byte("user-123"))
+		assert.ErrorIs(t, err, cryptox.ErrMalformedCiphertext)
+		_, err = keyring.Decrypt(ctx, []byte{2, 0}, nil)
+		assert.ErrorIs(t, err, cryptox.ErrUnsupportedFormat)
+		_, err = keyring.Encrypt(ctx, "unknown", []byte("secret"), nil)
+		assert.ErrorIs(t, err, cryptox.ErrKeyNotFound)
+	})
+}
+
+func TestLoadLocalKEKProvider(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "keks.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{"kek-1": "MDEyMzQ1Njc4OWFiY2RlZg=="}`), 0o600))
+	provider, err := cryptox.LoadLocalKEKProvider(path)
+	require.NoError(t, err)
+
+	wrapped, err := provider.WrapKey(context.Background(), "kek-1", []byte("data-key"))
+	require.NoError(t, err)
+	dataKey, err := provider.UnwrapKey(context.Background(), "kek-1", wrapped)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("data-key"), dataKey)
+}
+
+func TestDecrypt(t *testing.T) {
+	key := []byte("0123456789abcdef")
+	_, err := cryptox.Decrypt([]byte("short"), key)
+	assert.ErrorIs(t, err, cryptox.ErrCiphertextTooShort)
+
+	ciphertext, err := cryptox.Encrypt([]byte("secret"), key)
+	require.NoError(t, err)
+	plaintext, err := cryptox.Decrypt(ciphertext, key)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("secret"), plaintext)
+}
@@ -4,15 +4,37 @@ import (
 	"crypto/aes"
 	"crypto/cipher"
 	"crypto/rand"
+	"errors"
 )
 
+// ErrCiphertextTooShort is returned when a ciphertext is shorter than its nonce and authentication tag.
+var ErrCiphertextTooShort = errors.New("geck.cryptox: ciphertext too short")
+
 // Encrypt encrypts the plaintext using the key and returns the ciphertext.
 func Encrypt(plaintext []byte, key []byte) ([]byte, error) {
-	block, err := aes.NewCipher(key)
-	if err != nil {
-		return nil, err
-	}
-	gcm, err := cipher.NewGCM(block)
+	return seal(key, plaintext, nil)
+}
+
+// Decrypt decrypts the ciphertext using the key and returns the plaintext.
+func Decrypt(ciphertext []byte, key []byte) ([]byte, error) {
+	return open(key, ciphertext, nil)
+}
+
+// EncryptWithAAD encrypts the plaintext using the key and returns the ciphertext. The additional authenticated
+// data (AAD) is not encrypted but the ciphertext can only be decrypted using the same AAD (see [DecryptWithAAD]).
+func EncryptWithAAD(plaintext, key, aad []byte) ([]byte, error) {
+	return seal(key, plaintext, aad)
+}
+
+// DecryptWithAAD decrypts the ciphertext using the key and the additional authenticated data (AAD) it was
+// encrypted with, and returns the plaintext.
+func DecryptWithAAD(ciphertext, key, aad []byte) ([]byte, error) {
+	return open(key, ciphertext, aad)
+}
+
+// seal encrypts `plaintext` using AES-GCM, authenticating `aad` as well. The random nonce prefixes the ciphertext.
+func seal(key, plaintext, aad []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
 	if err != nil {
 		return nil, err
 	}
@@ -20,21 +42,27 @@ func Encrypt(plaintext []byte, key []byte) ([]byte, error) {
 	if _, err = rand.Read(nonce); err != nil {
 		return nil, err
 	}
-	ciphertext := gcm.Seal(nonce, nonce, plaintext, nil)
-	return ciphertext, nil
+	return gcm.Seal(nonce, nonce, plaintext, aad), nil
 }
 
-// Decrypt decrypts the ciphertext using the key and returns the plaintext.
-func Decrypt(ciphertext []byte, key []byte) ([]byte, error) {
-	block, err := aes.NewCipher(key)
+// open decrypts `ciphertext` produced by seal using the same `key` and `aad`.
+func open(key, ciphertext, aad []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
 	if err != nil {
 		return nil, err
 	}
-	gcm, err := cipher.NewGCM(block)
+	nonceSize := gcm.NonceSize()
+	if len(ciphertext) < nonceSize+gcm.Overhead() {
+		return nil, ErrCiphertextTooShort
+	}
+	nonce, ciphertext := ciphertext[:nonceSize], ciphertext[nonceSize:]
+	return gcm.Open(nil, nonce, ciphertext, aad)
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
 	if err != nil {
 		return nil, err
 	}
-	nonceSize := gcm.NonceSize()
-	nonce, ciphertext := ciphertext[:nonceSize], ciphertext[nonceSize:]
-	return gcm.Open(nil, nonce, ciphertext, nil)
+	return cipher.NewGCM(block)
 }
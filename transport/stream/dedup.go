@@ -0,0 +1,130 @@
+package stream
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+
+	"github.com/samber/lo"
+)
+
+// DedupStore is a component keeping track of processed messages, used by idempotent consumers to skip
+// messages delivered more than once.
+type DedupStore interface {
+	// IsProcessed indicates whether the message identified by `key` was already processed.
+	IsProcessed(ctx context.Context, key string) (bool, error)
+	// MarkProcessed records the message identified by `key` as processed.
+	MarkProcessed(ctx context.Context, key string) error
+}
+
+// - In-memory -
+
+// MemoryDedupStore is an in-memory [DedupStore] implementation with a bounded capacity, evicting the least
+// recently used keys first. Keys also expire after a time-to-live.
+//
+// As its state is not shared nor persisted, this store only deduplicates messages redelivered to the same
+// process (e.g. batches fetched again after a rebalance).
+type MemoryDedupStore struct {
+	capacity int
+	ttl      time.Duration
+
+	mu      sync.Mutex
+	entries map[string]*list.Element
+	lru     *list.List
+}
+
+type memoryDedupEntry struct {
+	key        string
+	expireTime time.Time
+}
+
+// compile-time assertion
+var _ DedupStore = (*MemoryDedupStore)(nil)
+
+// NewMemoryDedupStore creates a new [MemoryDedupStore] instance.
+func NewMemoryDedupStore(opts ...MemoryDedupStoreOption) *MemoryDedupStore {
+	options := memoryDedupStoreOptions{}
+	for _, opt := range opts {
+		opt(&options)
+	}
+	return &MemoryDedupStore{
+		capacity: lo.CoalesceOrEmpty(options.capacity, 10000),
+		ttl:      lo.CoalesceOrEmpty(options.ttl, time.Hour),
+		entries:  make(map[string]*list.Element),
+		lru:      list.New(),
+	}
+}
+
+// IsProcessed indicates whether the message identified by `key` was processed and its record did not expire.
+func (s *MemoryDedupStore) IsProcessed(_ context.Context, key string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	elem, ok := s.entries[key]
+	if !ok {
+		return false, nil
+	}
+	if time.Now().After(elem.Value.(*memoryDedupEntry).expireTime) {
+		s.remove(elem)
+		return false, nil
+	}
+	s.lru.MoveToFront(elem)
+	return true, nil
+}
+
+// MarkProcessed records the message identified by `key` as processed, evicting the least recently used key if
+// the store is full.
+func (s *MemoryDedupStore) MarkProcessed(_ context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	expireTime := time.Now().Add(s.ttl)
+	if elem, ok := s.entries[key]; ok {
+		elem.Value.(*memoryDedupEntry).expireTime = expireTime
+		s.lru.MoveToFront(elem)
+		return nil
+	}
+	s.entries[key] = s.lru.PushFront(&memoryDedupEntry{
+		key:        key,
+		expireTime: expireTime,
+	})
+	for s.lru.Len() > s.capacity {
+		s.remove(s.lru.Back())
+	}
+	return nil
+}
+
+// Len returns the number of keys held by the store, including expired keys not evicted yet.
+func (s *MemoryDedupStore) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.lru.Len()
+}
+
+func (s *MemoryDedupStore) remove(elem *list.Element) {
+	s.lru.Remove(elem)
+	delete(s.entries, elem.Value.(*memoryDedupEntry).key)
+}
+
+// -- Options --
+
+type memoryDedupStoreOptions struct {
+	capacity int
+	ttl      time.Duration
+}
+
+// MemoryDedupStoreOption is a routine used to set up [MemoryDedupStore] optional configuration.
+type MemoryDedupStoreOption func(*memoryDedupStoreOptions)
+
+// WithMemoryDedupCapacity sets the maximum number of keys held by a [MemoryDedupStore]. Defaults to 10,000.
+func WithMemoryDedupCapacity(capacity int) MemoryDedupStoreOption {
+	return func(o *memoryDedupStoreOptions) {
+		o.capacity = capacity
+	}
+}
+
+// WithMemoryDedupTTL sets the time-to-live of keys held by a [MemoryDedupStore]. Defaults to 1 hour.
+func WithMemoryDedupTTL(ttl time.Duration) MemoryDedupStoreOption {
+	return func(o *memoryDedupStoreOptions) {
+		o.ttl = ttl
+	}
+}
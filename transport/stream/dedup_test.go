@@ -0,0 +1,54 @@
+package stream_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bosonicalio/geck/transport/stream"
+)
+
+func TestMemoryDedupStore(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("mark processed", func(t *testing.T) {
+		store := stream.NewMemoryDedupStore()
+		isProcessed, err := store.IsProcessed(ctx, "a")
+		require.NoError(t, err)
+		assert.False(t, isProcessed)
+
+		require.NoError(t, store.MarkProcessed(ctx, "a"))
+		isProcessed, err = store.IsProcessed(ctx, "a")
+		require.NoError(t, err)
+		assert.True(t, isProcessed)
+	})
+
+	t.Run("evict least recently used", func(t *testing.T) {
+		store := stream.NewMemoryDedupStore(stream.WithMemoryDedupCapacity(2))
+		require.NoError(t, store.MarkProcessed(ctx, "a"))
+		require.NoError(t, store.MarkProcessed(ctx, "b"))
+		_, err := store.IsProcessed(ctx, "a") // moves `a` to the front
+		require.NoError(t, err)
+		require.NoError(t, store.MarkProcessed(ctx, "c"))
+
+		assert.Equal(t, 2, store.Len())
+		for key, exp := range map[string]bool{"a": true, "b": false, "c": true} {
+			isProcessed, errProc := store.IsProcessed(ctx, key)
+			require.NoError(t, errProc)
+			assert.Equal(t, exp, isProcessed, key)
+		}
+	})
+
+	t.Run("expire keys", func(t *testing.T) {
+		store := stream.NewMemoryDedupStore(stream.WithMemoryDedupTTL(time.Millisecond))
+		require.NoError(t, store.MarkProcessed(ctx, "a"))
+		time.Sleep(5 * time.Millisecond)
+		isProcessed, err := store.IsProcessed(ctx, "a")
+		require.NoError(t, err)
+		assert.False(t, isProcessed)
+		assert.Equal(t, 0, store.Len())
+	})
+}
@@ -6,12 +6,17 @@ import (
 	"github.com/bosonicalio/geck/transport/stream"
 )
 
-func marshalHeaders(headers stream.Header) []kgo.RecordHeader {
+// MarshalHeaders converts `headers` into record headers the way writers do, keeping keys as written (e.g. lowercase
+// keys defined by the Cloud Events Kafka protocol binding) along with the first value of each key.
+func MarshalHeaders(headers stream.Header) []kgo.RecordHeader {
 	kgoHeaders := make([]kgo.RecordHeader, 0, len(headers))
-	for k := range headers {
+	for k, values := range headers {
+		if len(values) == 0 {
+			continue
+		}
 		kgoHeaders = append(kgoHeaders, kgo.RecordHeader{
 			Key:   k,
-			Value: []byte(headers.Get(k)),
+			Value: []byte(values[0]),
 		})
 	}
 	return kgoHeaders
@@ -0,0 +1,38 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+
+	"github.com/twmb/franz-go/pkg/kgo"
+
+	"github.com/bosonicalio/geck/observability/health"
+)
+
+// compile-time assertion
+var _ health.Checker = (*ChannelReaderManager)(nil)
+
+// NewHealthChecker creates a [health.Checker] pinging the Apache Kafka cluster using `client`.
+func NewHealthChecker(client *kgo.Client) health.Checker {
+	return health.CheckerFunc(client.Ping)
+}
+
+// Check verifies all Apache Kafka clients used by the reader manager are able to reach the cluster.
+//
+// Implements [health.Checker].
+func (c *ChannelReaderManager) Check(ctx context.Context) error {
+	if c.isClosed.Load() {
+		return ErrReaderManagerClosed
+	}
+	groupClients := c.groupClients()
+	errs := make([]error, 0, len(groupClients)+1)
+	if err := c.client.Ping(ctx); err != nil {
+		errs = append(errs, err)
+	}
+	for _, groupClient := range groupClients {
+		if err := groupClient.Ping(ctx); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
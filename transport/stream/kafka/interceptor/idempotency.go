@@ -0,0 +1,101 @@
+package interceptor
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/twmb/franz-go/pkg/kgo"
+
+	"github.com/bosonicalio/geck/event"
+	"github.com/bosonicalio/geck/transport/stream"
+	"github.com/bosonicalio/geck/transport/stream/kafka"
+)
+
+// -- Idempotency --
+
+// IdempotencyKeyFunc is a routine extracting the key identifying a record for deduplication purposes.
+// Records with an empty key are not deduplicated.
+type IdempotencyKeyFunc func(msg *kgo.Record) (string, error)
+
+// EventIDKey is an [IdempotencyKeyFunc] using the event identifier as key, supporting every
+// [event.ContentMode].
+//
+// Identifiers of events written using [event.ContentModeHeader] or [event.ContentModeBinary] are read from the
+// record headers while only the `id` attribute is decoded from events written using
+// [event.ContentModeStructured]. Returns an error wrapping [event.ErrMissingAttribute] if the record holds no
+// event identifier.
+func EventIDKey(msg *kgo.Record) (string, error) {
+	var contentType string
+	for _, h := range msg.Headers {
+		switch {
+		case strings.EqualFold(h.Key, event.HeaderCloudEventsID), strings.EqualFold(h.Key, event.HeaderEventID):
+			if len(h.Value) > 0 {
+				return string(h.Value), nil
+			}
+		case strings.EqualFold(h.Key, event.HeaderContentType):
+			contentType = string(h.Value)
+		}
+	}
+	if !strings.HasPrefix(contentType, event.ContentModeStructuredMimeType) {
+		return "", fmt.Errorf("%w: %s", event.ErrMissingAttribute, event.HeaderCloudEventsID)
+	}
+
+	doc := struct {
+		ID string `json:"id"`
+	}{}
+	if err := json.Unmarshal(msg.Value, &doc); err != nil {
+		return "", err
+	} else if doc.ID == "" {
+		return "", fmt.Errorf("%w: id", event.ErrMissingAttribute)
+	}
+	return doc.ID, nil
+}
+
+// UseIdempotency is a [kafka.ReaderInterceptor] skipping records already processed according to `store`.
+// Records are identified using `keyFunc`; if nil, [EventIDKey] is used.
+//
+// Records which key cannot be extracted are not processed; the extraction error is returned instead.
+// Keys are recorded into `store` once the handler succeeds. If the store supports transactions
+// (e.g. [stream.SQLDedupStore]), this interceptor must be placed inside
+// [UseTransaction] (or [UseTxManager]) so the key is recorded within the same transaction as the handler.
+func UseIdempotency(store stream.DedupStore, keyFunc IdempotencyKeyFunc, opts ...kafka.InterceptorOption) kafka.ReaderInterceptor {
+	ops := &kafka.InterceptorOptions{}
+	for _, opt := range opts {
+		opt(ops)
+	}
+	if ops.Skip == nil {
+		ops.Skip = func(msg *kgo.Record) bool {
+			return false
+		}
+	}
+	if keyFunc == nil {
+		keyFunc = EventIDKey
+	}
+	return func(next kafka.ReaderHandlerFunc) kafka.ReaderHandlerFunc {
+		return func(ctx context.Context, msg *kgo.Record) error {
+			if ops.Skip(msg) {
+				return next(ctx, msg)
+			}
+
+			key, err := keyFunc(msg)
+			if err != nil {
+				return err
+			} else if key == "" {
+				return next(ctx, msg)
+			}
+			isProcessed, err := store.IsProcessed(ctx, key)
+			if err != nil {
+				return err
+			} else if isProcessed {
+				return nil
+			}
+
+			if err = next(ctx, msg); err != nil {
+				return err
+			}
+			return store.MarkProcessed(ctx, key)
+		}
+	}
+}
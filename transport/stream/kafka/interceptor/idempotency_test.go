@@ -0,0 +1,106 @@
+package interceptor
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/twmb/franz-go/pkg/kgo"
+
+	"github.com/bosonicalio/geck/event"
+	"github.com/bosonicalio/geck/transport/stream"
+)
+
+func TestEventIDKey(t *testing.T) {
+	structured := kgo.RecordHeader{Key: event.HeaderContentType, Value: []byte(event.ContentModeStructuredMimeType)}
+	tests := []struct {
+		name   string
+		record *kgo.Record
+		exp    string
+		expErr error
+	}{
+		{
+			name: "header mode",
+			record: &kgo.Record{Headers: []kgo.RecordHeader{
+				{Key: event.HeaderEventType, Value: []byte("acme-corp.user.created")},
+				{Key: event.HeaderEventID, Value: []byte("123")},
+			}},
+			exp: "123",
+		},
+		{
+			name: "binary mode",
+			record: &kgo.Record{Headers: []kgo.RecordHeader{
+				{Key: event.HeaderContentType, Value: []byte("application/json")},
+				{Key: event.HeaderCloudEventsID, Value: []byte("123")},
+			}, Value: []byte(`not a structured event`)},
+			exp: "123",
+		},
+		{
+			name: "structured mode",
+			record: &kgo.Record{
+				Headers: []kgo.RecordHeader{structured},
+				Value:   []byte(`{"specversion":"1.0","id":"123","type":"acme-corp.user.created","data":{"name":"John"}}`),
+			},
+			exp: "123",
+		},
+		{
+			name:   "missing identifier",
+			record: &kgo.Record{Value: []byte(`{"id":"123"}`)},
+			expErr: event.ErrMissingAttribute,
+		},
+		{
+			name: "structured missing identifier",
+			record: &kgo.Record{
+				Headers: []kgo.RecordHeader{structured},
+				Value:   []byte(`{"specversion":"1.0","type":"acme-corp.user.created"}`),
+			},
+			expErr: event.ErrMissingAttribute,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			key, err := EventIDKey(tt.record)
+			assert.ErrorIs(t, err, tt.expErr)
+			assert.Equal(t, tt.exp, key)
+		})
+	}
+
+	t.Run("malformed structured event", func(t *testing.T) {
+		key, err := EventIDKey(&kgo.Record{Headers: []kgo.RecordHeader{structured}, Value: []byte(`{`)})
+		assert.Error(t, err)
+		assert.Empty(t, key)
+	})
+}
+
+func TestUseIdempotency(t *testing.T) {
+	store := stream.NewMemoryDedupStore()
+	calls := 0
+	handler := UseIdempotency(store, nil)(func(_ context.Context, _ *kgo.Record) error {
+		calls++
+		return nil
+	})
+	record := &kgo.Record{Headers: []kgo.RecordHeader{{Key: event.HeaderCloudEventsID, Value: []byte("123")}}}
+
+	require.NoError(t, handler(context.Background(), record))
+	require.NoError(t, handler(context.Background(), record))
+	assert.Equal(t, 1, calls)
+
+	// records which key cannot be extracted are not processed
+	err := handler(context.Background(), &kgo.Record{})
+	assert.ErrorIs(t, err, event.ErrMissingAttribute)
+	assert.Equal(t, 1, calls)
+
+	// failed records are not marked as processed
+	errHandler := errors.New("handler failed")
+	failing := UseIdempotency(store, func(_ *kgo.Record) (string, error) {
+		return "456", nil
+	})(func(_ context.Context, _ *kgo.Record) error {
+		return errHandler
+	})
+	assert.ErrorIs(t, failing(context.Background(), record), errHandler)
+	isProcessed, err := store.IsProcessed(context.Background(), "456")
+	require.NoError(t, err)
+	assert.False(t, isProcessed)
+}
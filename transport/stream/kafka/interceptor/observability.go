@@ -0,0 +1,158 @@
+package interceptor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"runtime/debug"
+	"time"
+
+	"github.com/twmb/franz-go/pkg/kgo"
+
+	"github.com/bosonicalio/geck/transport/stream/kafka"
+)
+
+// -- Recover --
+
+// PanicError is the error returned by [UseRecover] when a handler panics.
+type PanicError struct {
+	// Value is the value passed to panic.
+	Value any
+	// Stack is the stack trace of the goroutine at the time of the panic.
+	Stack []byte
+}
+
+// compile-time assertion
+var _ error = (*PanicError)(nil)
+
+// Error returns the value passed to panic. The stack trace is not included, use [PanicError.Stack] instead.
+func (e PanicError) Error() string {
+	return fmt.Sprintf("handler panic: %v", e.Value)
+}
+
+// Unwrap returns the value passed to panic if such value is an error.
+func (e PanicError) Unwrap() error {
+	err, _ := e.Value.(error)
+	return err
+}
+
+// UseRecover is a [kafka.ReaderInterceptor] that recovers the handler from panics, returning a [PanicError]
+// holding the stack trace instead of crashing the worker.
+//
+// This interceptor should be the outermost one (i.e. registered last) to catch panics from other interceptors.
+func UseRecover(opts ...kafka.InterceptorOption) kafka.ReaderInterceptor {
+	ops := &kafka.InterceptorOptions{}
+	for _, opt := range opts {
+		opt(ops)
+	}
+	if ops.Skip == nil {
+		ops.Skip = func(msg *kgo.Record) bool {
+			return false
+		}
+	}
+	return func(next kafka.ReaderHandlerFunc) kafka.ReaderHandlerFunc {
+		return func(ctx context.Context, msg *kgo.Record) (err error) {
+			if ops.Skip(msg) {
+				return next(ctx, msg)
+			}
+
+			defer func() {
+				if r := recover(); r != nil {
+					err = PanicError{
+						Value: r,
+						Stack: debug.Stack(),
+					}
+				}
+			}()
+			return next(ctx, msg)
+		}
+	}
+}
+
+// -- Logger --
+
+// UseLogger is a [kafka.ReaderInterceptor] that logs the outcome of every handled record, including its topic,
+// partition, offset, key and the time it took to be processed.
+//
+// Records processed successfully are logged using the [slog.LevelInfo] level while failures are logged
+// using the [slog.LevelError] level by default (see [WithLoggerLevel] and [WithLoggerErrorLevel]). The stack trace
+// of [PanicError] failures is logged using the `stack` attribute.
+func UseLogger(logger *slog.Logger, opts ...LoggerOption) kafka.ReaderInterceptor {
+	ops := &loggerOptions{
+		level:      slog.LevelInfo,
+		errorLevel: slog.LevelError,
+	}
+	for _, opt := range opts {
+		opt(ops)
+	}
+	if ops.Skip == nil {
+		ops.Skip = func(msg *kgo.Record) bool {
+			return false
+		}
+	}
+	return func(next kafka.ReaderHandlerFunc) kafka.ReaderHandlerFunc {
+		return func(ctx context.Context, msg *kgo.Record) error {
+			if ops.Skip(msg) {
+				return next(ctx, msg)
+			}
+
+			start := time.Now()
+			err := next(ctx, msg)
+			attrs := []slog.Attr{
+				slog.String("topic", msg.Topic),
+				slog.Int("partition", int(msg.Partition)),
+				slog.Int64("offset", msg.Offset),
+				slog.String("key", string(msg.Key)),
+				slog.String("took", time.Since(start).String()),
+			}
+			if err == nil {
+				logger.LogAttrs(ctx, ops.level, "processed record", attrs...)
+				return nil
+			}
+
+			attrs = append(attrs, slog.String("err", err.Error()))
+			var errPanic PanicError
+			if errors.As(err, &errPanic) {
+				attrs = append(attrs, slog.String("stack", string(errPanic.Stack)))
+			}
+			logger.LogAttrs(ctx, ops.errorLevel, "failed to process record", attrs...)
+			return err
+		}
+	}
+}
+
+// --- Options ---
+
+type loggerOptions struct {
+	kafka.InterceptorOptions
+	level      slog.Level
+	errorLevel slog.Level
+}
+
+// LoggerOption is a routine used to set up [UseLogger] optional configuration.
+type LoggerOption func(*loggerOptions)
+
+// WithLoggerLevel sets the log level used by [UseLogger] for records processed successfully.
+func WithLoggerLevel(lvl slog.Level) LoggerOption {
+	return func(o *loggerOptions) {
+		o.level = lvl
+	}
+}
+
+// WithLoggerErrorLevel sets the log level used by [UseLogger] for records failing to be processed.
+func WithLoggerErrorLevel(lvl slog.Level) LoggerOption {
+	return func(o *loggerOptions) {
+		o.errorLevel = lvl
+	}
+}
+
+// WithLoggerInterceptorOptions sets the [kafka.InterceptorOption] values of [UseLogger] (e.g.
+// [kafka.WithSkipInterceptor]).
+func WithLoggerInterceptorOptions(opts ...kafka.InterceptorOption) LoggerOption {
+	return func(o *loggerOptions) {
+		for _, opt := range opts {
+			opt(&o.InterceptorOptions)
+		}
+	}
+}
@@ -0,0 +1,164 @@
+package interceptor
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/twmb/franz-go/pkg/kgo"
+
+	"github.com/bosonicalio/geck/transport/stream/kafka"
+)
+
+func TestUseRecover(t *testing.T) {
+	errPanic := errors.New("something went wrong")
+	tests := []struct {
+		name      string
+		value     any
+		expMsg    string
+		expUnwrap error
+	}{
+		{
+			name:      "error",
+			value:     errPanic,
+			expMsg:    "handler panic: something went wrong",
+			expUnwrap: errPanic,
+		},
+		{
+			name:   "any value",
+			value:  42,
+			expMsg: "handler panic: 42",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := UseRecover()(func(_ context.Context, _ *kgo.Record) error {
+				panic(tt.value)
+			})
+			err := handler(context.Background(), &kgo.Record{})
+			var errRecovered PanicError
+			require.ErrorAs(t, err, &errRecovered)
+			assert.Equal(t, tt.value, errRecovered.Value)
+			assert.Equal(t, tt.expMsg, err.Error())
+			assert.Contains(t, string(errRecovered.Stack), "TestUseRecover")
+			if tt.expUnwrap != nil {
+				assert.ErrorIs(t, err, tt.expUnwrap)
+			}
+		})
+	}
+
+	t.Run("no panic", func(t *testing.T) {
+		errHandler := errors.New("handler failed")
+		handler := UseRecover()(func(_ context.Context, _ *kgo.Record) error {
+			return errHandler
+		})
+		assert.Equal(t, errHandler, handler(context.Background(), &kgo.Record{}))
+	})
+}
+
+// recordingHandler is a [slog.Handler] keeping every handled record.
+type recordingHandler struct {
+	mu      sync.Mutex
+	records []slog.Record
+}
+
+func (h *recordingHandler) Enabled(_ context.Context, _ slog.Level) bool {
+	return true
+}
+
+func (h *recordingHandler) Handle(_ context.Context, record slog.Record) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.records = append(h.records, record)
+	return nil
+}
+
+func (h *recordingHandler) WithAttrs(_ []slog.Attr) slog.Handler {
+	return h
+}
+
+func (h *recordingHandler) WithGroup(_ string) slog.Handler {
+	return h
+}
+
+func recordAttrs(record slog.Record) map[string]string {
+	attrs := make(map[string]string)
+	record.Attrs(func(attr slog.Attr) bool {
+		attrs[attr.Key] = attr.Value.String()
+		return true
+	})
+	return attrs
+}
+
+func TestUseLogger(t *testing.T) {
+	msg := &kgo.Record{Topic: "orders", Partition: 2, Offset: 10, Key: []byte("order-1")}
+	errHandler := errors.New("handler failed")
+	succeed := func(_ context.Context, _ *kgo.Record) error {
+		return nil
+	}
+	fail := func(_ context.Context, _ *kgo.Record) error {
+		return errHandler
+	}
+
+	t.Run("default levels", func(t *testing.T) {
+		recorder := &recordingHandler{}
+		interceptor := UseLogger(slog.New(recorder))
+		require.NoError(t, interceptor(succeed)(context.Background(), msg))
+		assert.ErrorIs(t, interceptor(fail)(context.Background(), msg), errHandler)
+
+		require.Len(t, recorder.records, 2)
+		assert.Equal(t, slog.LevelInfo, recorder.records[0].Level)
+		assert.Equal(t, "processed record", recorder.records[0].Message)
+		attrs := recordAttrs(recorder.records[0])
+		assert.Equal(t, "orders", attrs["topic"])
+		assert.Equal(t, "2", attrs["partition"])
+		assert.Equal(t, "10", attrs["offset"])
+		assert.Equal(t, "order-1", attrs["key"])
+		assert.NotEmpty(t, attrs["took"])
+
+		assert.Equal(t, slog.LevelError, recorder.records[1].Level)
+		assert.Equal(t, "failed to process record", recorder.records[1].Message)
+		assert.Equal(t, "handler failed", recordAttrs(recorder.records[1])["err"])
+	})
+
+	t.Run("custom levels", func(t *testing.T) {
+		recorder := &recordingHandler{}
+		interceptor := UseLogger(slog.New(recorder),
+			WithLoggerLevel(slog.LevelDebug),
+			WithLoggerErrorLevel(slog.LevelWarn),
+		)
+		require.NoError(t, interceptor(succeed)(context.Background(), msg))
+		assert.Error(t, interceptor(fail)(context.Background(), msg))
+		require.Len(t, recorder.records, 2)
+		assert.Equal(t, slog.LevelDebug, recorder.records[0].Level)
+		assert.Equal(t, slog.LevelWarn, recorder.records[1].Level)
+	})
+
+	t.Run("panic stack", func(t *testing.T) {
+		recorder := &recordingHandler{}
+		// the logger wraps the recovery interceptor to log recovered panics
+		handler := UseLogger(slog.New(recorder))(UseRecover()(func(_ context.Context, _ *kgo.Record) error {
+			panic("boom")
+		}))
+		assert.Error(t, handler(context.Background(), msg))
+		require.Len(t, recorder.records, 1)
+		attrs := recordAttrs(recorder.records[0])
+		assert.Equal(t, "handler panic: boom", attrs["err"])
+		assert.Contains(t, attrs["stack"], "TestUseLogger")
+	})
+
+	t.Run("skipped", func(t *testing.T) {
+		recorder := &recordingHandler{}
+		interceptor := UseLogger(slog.New(recorder), WithLoggerInterceptorOptions(
+			kafka.WithSkipInterceptor(func(_ *kgo.Record) bool {
+				return true
+			}),
+		))
+		require.NoError(t, interceptor(succeed)(context.Background(), msg))
+		assert.Empty(t, recorder.records)
+	})
+}
@@ -10,6 +10,9 @@ import (
 	"github.com/bosonicalio/geck/transport/stream/kafka"
 )
 
+// HeaderOriginalTopic is the key of the header holding the name of the topic a record was originally written to.
+const HeaderOriginalTopic = "Original-Topic"
+
 // -- Dead Letter Queue --
 
 // UseDeadLetter is a [kafka.ReaderInterceptor] that sends messages to a dead letter queue (DLQ) if the handler returns
@@ -43,7 +46,7 @@ func UseDeadLetter(client *kgo.Client, topic string, opts ...kafka.InterceptorOp
 				msg.Headers = make([]kgo.RecordHeader, 0, 1)
 			}
 			msg.Headers = append(msg.Headers, kgo.RecordHeader{
-				Key:   "Original-Topic",
+				Key:   HeaderOriginalTopic,
 				Value: []byte(msg.Topic),
 			})
 			msg.Topic = lo.CoalesceOrEmpty(topic, msg.Topic+"-dlq")
@@ -0,0 +1,118 @@
+package interceptor
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"time"
+
+	"github.com/samber/lo"
+	"github.com/twmb/franz-go/pkg/kgo"
+
+	"github.com/bosonicalio/geck/syserr"
+	"github.com/bosonicalio/geck/transport/stream/kafka"
+)
+
+// -- Retry --
+
+// RetryPolicy is the configuration of [UseRetry]. Zero values fall back to defaults.
+type RetryPolicy struct {
+	// MaxAttempts is the maximum number of times the handler is called, including the first call.
+	// Defaults to 3.
+	MaxAttempts int
+	// InitialBackoff is the delay before the first retry. Defaults to 100 milliseconds.
+	InitialBackoff time.Duration
+	// MaxBackoff is the upper bound of the delay between retries. Defaults to 5 seconds.
+	MaxBackoff time.Duration
+	// Classifier indicates whether an error is transient, hence, retryable. Defaults to [IsRetryable].
+	Classifier func(err error) bool
+}
+
+// IsRetryable indicates whether `err` is a transient error. Only [syserr.Error] instances (either values or
+// pointers) of type [syserr.Unavailable], [syserr.DeadlineExceeded], [syserr.ResourceExhausted] and
+// [syserr.Aborted] are considered retryable; every other error is permanent.
+func IsRetryable(err error) bool {
+	var errType syserr.Type
+	var errSys syserr.Error
+	var errSysPtr *syserr.Error
+	switch {
+	case errors.As(err, &errSys):
+		errType = errSys.Type
+	case errors.As(err, &errSysPtr):
+		errType = errSysPtr.Type
+	default:
+		return false
+	}
+	switch errType {
+	case syserr.Unavailable, syserr.DeadlineExceeded, syserr.ResourceExhausted, syserr.Aborted:
+		return true
+	default:
+		return false
+	}
+}
+
+// UseRetry is a [kafka.ReaderInterceptor] that calls the handler again, within the same process, if it returns
+// a retryable error (see [RetryPolicy.Classifier]).
+//
+// Retries are delayed using exponential backoff with jitter. The handler context deadline
+// (see [kafka.WithReaderManagerHandlerTimeout]) is honored; no retry is attempted if the deadline would be
+// reached before the next call.
+//
+// The last error is returned if every attempt failed, so this interceptor may be combined with outer ones such
+// as [UseDeadLetter] or [UseRetryTopics] to deal with permanent failures.
+func UseRetry(policy RetryPolicy, opts ...kafka.InterceptorOption) kafka.ReaderInterceptor {
+	ops := &kafka.InterceptorOptions{}
+	for _, opt := range opts {
+		opt(ops)
+	}
+	if ops.Skip == nil {
+		ops.Skip = func(msg *kgo.Record) bool {
+			return false
+		}
+	}
+	policy.MaxAttempts = lo.CoalesceOrEmpty(policy.MaxAttempts, 3)
+	policy.InitialBackoff = lo.CoalesceOrEmpty(policy.InitialBackoff, 100*time.Millisecond)
+	policy.MaxBackoff = lo.CoalesceOrEmpty(policy.MaxBackoff, 5*time.Second)
+	if policy.Classifier == nil {
+		policy.Classifier = IsRetryable
+	}
+	return func(next kafka.ReaderHandlerFunc) kafka.ReaderHandlerFunc {
+		return func(ctx context.Context, msg *kgo.Record) error {
+			if ops.Skip(msg) {
+				return next(ctx, msg)
+			}
+
+			var err error
+			for attempt := 1; ; attempt++ {
+				err = next(ctx, msg)
+				if err == nil || attempt >= policy.MaxAttempts || !policy.Classifier(err) || ctx.Err() != nil {
+					return err
+				}
+
+				delay := policy.backoff(attempt)
+				if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) <= delay {
+					return err
+				}
+				timer := time.NewTimer(delay)
+				select {
+				case <-timer.C:
+				case <-ctx.Done():
+					timer.Stop()
+					return err
+				}
+			}
+		}
+	}
+}
+
+// backoff computes the delay before the retry following `attempt` using exponential backoff with
+// equal jitter.
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	delay := p.InitialBackoff
+	for i := 1; i < attempt && delay < p.MaxBackoff; i++ {
+		delay *= 2
+	}
+	delay = min(delay, p.MaxBackoff)
+	half := delay / 2
+	return half + rand.N(delay-half+1)
+}
@@ -0,0 +1,140 @@
+package interceptor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/twmb/franz-go/pkg/kgo"
+
+	"github.com/bosonicalio/geck/syserr"
+	"github.com/bosonicalio/geck/transport/stream/kafka"
+)
+
+func TestIsRetryable(t *testing.T) {
+	errUnavailable := syserr.New(syserr.Unavailable, "service unavailable")
+	errNotFound := syserr.New(syserr.ResourceNotFound, "not found")
+	tests := []struct {
+		name string
+		err  error
+		exp  bool
+	}{
+		{name: "nil", err: nil},
+		{name: "generic error", err: errors.New("some error")},
+		{name: "permanent value", err: errNotFound},
+		{name: "permanent pointer", err: &errNotFound},
+		{name: "retryable value", err: errUnavailable, exp: true},
+		{name: "retryable pointer", err: &errUnavailable, exp: true},
+		{name: "wrapped value", err: fmt.Errorf("wrapped: %w", errUnavailable), exp: true},
+		{name: "wrapped pointer", err: fmt.Errorf("wrapped: %w", &errUnavailable), exp: true},
+		{name: "deadline exceeded", err: syserr.New(syserr.DeadlineExceeded, "timeout"), exp: true},
+		{name: "resource exhausted", err: syserr.New(syserr.ResourceExhausted, "throttled"), exp: true},
+		{name: "aborted", err: syserr.New(syserr.Aborted, "conflict"), exp: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.exp, IsRetryable(tt.err))
+		})
+	}
+}
+
+func TestRetryPolicy_Backoff(t *testing.T) {
+	policy := RetryPolicy{
+		InitialBackoff: 100 * time.Millisecond,
+		MaxBackoff:     time.Second,
+	}
+	tests := []struct {
+		attempt int
+		exp     time.Duration
+	}{
+		{attempt: 1, exp: 100 * time.Millisecond},
+		{attempt: 2, exp: 200 * time.Millisecond},
+		{attempt: 3, exp: 400 * time.Millisecond},
+		{attempt: 5, exp: time.Second},
+		{attempt: 100, exp: time.Second},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("attempt %d", tt.attempt), func(t *testing.T) {
+			for range 20 {
+				delay := policy.backoff(tt.attempt)
+				assert.GreaterOrEqual(t, delay, tt.exp/2)
+				assert.LessOrEqual(t, delay, tt.exp)
+			}
+		})
+	}
+}
+
+func TestUseRetry(t *testing.T) {
+	errRetryable := syserr.New(syserr.Unavailable, "service unavailable")
+	errPermanent := errors.New("permanent error")
+	policy := RetryPolicy{
+		MaxAttempts:    3,
+		InitialBackoff: time.Millisecond,
+		MaxBackoff:     2 * time.Millisecond,
+	}
+	newHandler := func(calls *int, errs ...error) kafka.ReaderHandlerFunc {
+		return func(_ context.Context, _ *kgo.Record) error {
+			*calls++
+			if *calls > len(errs) {
+				return nil
+			}
+			return errs[*calls-1]
+		}
+	}
+
+	t.Run("succeeds after retries", func(t *testing.T) {
+		calls := 0
+		handler := UseRetry(policy)(newHandler(&calls, errRetryable, errRetryable))
+		assert.NoError(t, handler(context.Background(), &kgo.Record{}))
+		assert.Equal(t, 3, calls)
+	})
+
+	t.Run("attempts exhausted", func(t *testing.T) {
+		calls := 0
+		handler := UseRetry(policy)(newHandler(&calls, errRetryable, errRetryable, errRetryable, errRetryable))
+		assert.Equal(t, errRetryable, handler(context.Background(), &kgo.Record{}))
+		assert.Equal(t, 3, calls)
+	})
+
+	t.Run("permanent error", func(t *testing.T) {
+		calls := 0
+		handler := UseRetry(policy)(newHandler(&calls, errPermanent))
+		assert.ErrorIs(t, handler(context.Background(), &kgo.Record{}), errPermanent)
+		assert.Equal(t, 1, calls)
+	})
+
+	t.Run("custom classifier", func(t *testing.T) {
+		calls := 0
+		custom := policy
+		custom.Classifier = func(err error) bool {
+			return errors.Is(err, errPermanent)
+		}
+		handler := UseRetry(custom)(newHandler(&calls, errPermanent))
+		assert.NoError(t, handler(context.Background(), &kgo.Record{}))
+		assert.Equal(t, 2, calls)
+	})
+
+	t.Run("deadline reached", func(t *testing.T) {
+		calls := 0
+		slow := policy
+		slow.InitialBackoff = time.Minute
+		slow.MaxBackoff = time.Minute
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		handler := UseRetry(slow)(newHandler(&calls, errRetryable))
+		assert.Equal(t, errRetryable, handler(ctx, &kgo.Record{}))
+		assert.Equal(t, 1, calls)
+	})
+
+	t.Run("skipped", func(t *testing.T) {
+		calls := 0
+		handler := UseRetry(policy, kafka.WithSkipInterceptor(func(_ *kgo.Record) bool {
+			return true
+		}))(newHandler(&calls, errRetryable))
+		assert.Equal(t, errRetryable, handler(context.Background(), &kgo.Record{}))
+		assert.Equal(t, 1, calls)
+	})
+}
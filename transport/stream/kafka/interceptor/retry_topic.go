@@ -0,0 +1,242 @@
+package interceptor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/twmb/franz-go/pkg/kgo"
+
+	"github.com/bosonicalio/geck/transport/stream/kafka"
+)
+
+const (
+	// HeaderRetryAttempt is the key of the header holding the number of times a record was retried.
+	HeaderRetryAttempt = "Retry-Attempt"
+	// HeaderRetryFirstFailureTime is the key of the header holding the time (RFC 3339) a record failed to be
+	// processed for the first time.
+	HeaderRetryFirstFailureTime = "Retry-First-Failure-Time"
+	// HeaderRetryLastError is the key of the header holding the last error returned by the record handler.
+	HeaderRetryLastError = "Retry-Last-Error"
+	// HeaderRetryNotBefore is the key of the header holding the time (RFC 3339) a retried record is allowed to
+	// be processed again.
+	HeaderRetryNotBefore = "Retry-Not-Before"
+)
+
+// -- Error(s) --
+
+// ErrRetryNotDue is returned by [UseRetryDelay] when the backoff deadline of a record is beyond the handler
+// context deadline.
+var ErrRetryNotDue = errors.New("retry is not due")
+
+// -- Retry Topics --
+
+// RetryTopicName returns the name of the retry topic of `topic` for the given `attempt` (starting from 1),
+// e.g. `orders-retry-1`.
+func RetryTopicName(topic string, attempt int) string {
+	return topic + "-retry-" + strconv.Itoa(attempt)
+}
+
+// DeadLetterTopicName returns the name of the dead letter queue of `topic`, e.g. `orders-dlq`.
+func DeadLetterTopicName(topic string) string {
+	return topic + "-dlq"
+}
+
+// RetryTopicPolicy is the configuration of [UseRetryTopics].
+type RetryTopicPolicy struct {
+	// Backoffs are the retry tiers; a record failing for the `n`-th time is sent to the `n`-th retry topic and
+	// delayed by the `n`-th backoff.
+	Backoffs []time.Duration
+	// DeadLetterTopic returns the name of the dead letter queue for records originally written to `topic`.
+	// Defaults to [DeadLetterTopicName].
+	DeadLetterTopic func(topic string) string
+}
+
+// UseRetryTopics is a [kafka.ReaderInterceptor] that republishes records to tiered retry topics if the handler
+// returns an error, following the non-blocking retry pattern.
+//
+// Each entry of [RetryTopicPolicy.Backoffs] is a retry tier; a record failing for the `n`-th time is sent to the
+// topic `<topic>-retry-<n>` (see [RetryTopicName]) along with the [HeaderRetryAttempt],
+// [HeaderRetryFirstFailureTime], [HeaderRetryLastError], [HeaderOriginalTopic] and [HeaderRetryNotBefore]
+// headers. Once every tier is exhausted, the record is sent to the dead letter queue
+// (see [RetryTopicPolicy.DeadLetterTopic]).
+//
+// Records successfully republished are considered handled, hence, this routine returns a nil error in that
+// scenario so the reader is able to commit their offsets.
+//
+// Records not processed yet as their backoff deadline was not reached (i.e. [UseRetryDelay] returned
+// [ErrRetryNotDue]) are republished to the same topic with unchanged headers; thus, they stay in their tier.
+//
+// Readers of retry topics must use this interceptor too (so records move to the next tier) along
+// with [UseRetryDelay], e.g.
+//
+//	for i := range policy.Backoffs {
+//		manager.MustRegister(interceptor.RetryTopicName("orders", i+1), handler,
+//			kafka.WithReaderInterceptors(interceptor.UseRetryDelay(), interceptor.UseRetryTopics(client, policy)),
+//		)
+//	}
+func UseRetryTopics(client *kgo.Client, policy RetryTopicPolicy, opts ...kafka.InterceptorOption) kafka.ReaderInterceptor {
+	return useRetryTopics(client, policy, opts...)
+}
+
+// recordProducer is the producing subset of [kgo.Client] used by [UseRetryTopics].
+type recordProducer interface {
+	ProduceSync(ctx context.Context, rs ...*kgo.Record) kgo.ProduceResults
+}
+
+func useRetryTopics(producer recordProducer, policy RetryTopicPolicy, opts ...kafka.InterceptorOption) kafka.ReaderInterceptor {
+	ops := &kafka.InterceptorOptions{}
+	for _, opt := range opts {
+		opt(ops)
+	}
+	if ops.Skip == nil {
+		ops.Skip = func(msg *kgo.Record) bool {
+			return false
+		}
+	}
+	if policy.DeadLetterTopic == nil {
+		policy.DeadLetterTopic = DeadLetterTopicName
+	}
+	return func(next kafka.ReaderHandlerFunc) kafka.ReaderHandlerFunc {
+		return func(ctx context.Context, msg *kgo.Record) error {
+			if ops.Skip(msg) {
+				return next(ctx, msg)
+			}
+
+			err := next(ctx, msg)
+			if err == nil {
+				return nil
+			}
+
+			var record *kgo.Record
+			if errors.Is(err, ErrRetryNotDue) {
+				record = newRequeuedRecord(msg)
+			} else {
+				record = newRetryRecord(msg, policy, err, time.Now().UTC())
+			}
+			if errProduce := producer.ProduceSync(ctx, record).FirstErr(); errProduce != nil {
+				return errors.Join(err, errProduce)
+			}
+			return nil
+		}
+	}
+}
+
+// newRetryRecord creates a copy of `msg` routed to the next retry tier (or the dead letter queue if tiers were
+// exhausted).
+func newRetryRecord(msg *kgo.Record, policy RetryTopicPolicy, err error, now time.Time) *kgo.Record {
+	originalTopic := msg.Topic
+	firstFailureTime := now.Format(time.RFC3339Nano)
+	attempt := 1
+	headers := make([]kgo.RecordHeader, 0, len(msg.Headers)+5)
+	for _, h := range msg.Headers {
+		switch h.Key {
+		case HeaderOriginalTopic:
+			originalTopic = string(h.Value)
+		case HeaderRetryFirstFailureTime:
+			firstFailureTime = string(h.Value)
+		case HeaderRetryAttempt:
+			if prev, errParse := strconv.Atoi(string(h.Value)); errParse == nil {
+				attempt = prev + 1
+			}
+		case HeaderRetryLastError, HeaderRetryNotBefore:
+		default:
+			headers = append(headers, h)
+		}
+	}
+
+	headers = append(headers,
+		kgo.RecordHeader{Key: HeaderOriginalTopic, Value: []byte(originalTopic)},
+		kgo.RecordHeader{Key: HeaderRetryAttempt, Value: []byte(strconv.Itoa(attempt))},
+		kgo.RecordHeader{Key: HeaderRetryFirstFailureTime, Value: []byte(firstFailureTime)},
+		kgo.RecordHeader{Key: HeaderRetryLastError, Value: []byte(err.Error())},
+	)
+	topic := policy.DeadLetterTopic(originalTopic)
+	if attempt <= len(policy.Backoffs) {
+		topic = RetryTopicName(originalTopic, attempt)
+		headers = append(headers, kgo.RecordHeader{
+			Key:   HeaderRetryNotBefore,
+			Value: []byte(now.Add(policy.Backoffs[attempt-1]).Format(time.RFC3339Nano)),
+		})
+	}
+	return &kgo.Record{
+		Key:     msg.Key,
+		Value:   msg.Value,
+		Headers: headers,
+		Topic:   topic,
+	}
+}
+
+// newRequeuedRecord creates a copy of `msg` routed to its current topic, keeping its retry tier and backoff deadline.
+func newRequeuedRecord(msg *kgo.Record) *kgo.Record {
+	return &kgo.Record{
+		Key:     msg.Key,
+		Value:   msg.Value,
+		Headers: slices.Clone(msg.Headers),
+		Topic:   msg.Topic,
+	}
+}
+
+// UseRetryDelay is a [kafka.ReaderInterceptor] delaying the processing of records published by [UseRetryTopics]
+// until their backoff deadline ([HeaderRetryNotBefore]) is reached. Records without the header are processed
+// right away.
+//
+// The delay is part of the handler timeout (see [kafka.WithReaderManagerHandlerTimeout]). If the backoff deadline
+// is beyond the handler context deadline, this routine returns [ErrRetryNotDue] right away instead of waiting,
+// leaving the record unprocessed ([UseRetryTopics] republishes such records to their current tier); thus, the
+// handler timeout of readers of retry topics should be greater than the backoff of their tier to avoid records
+// being republished over and over. Moreover, delayed records block their worker, therefore, it is recommended to read
+// retry topics using a dedicated [kafka.ChannelReaderManager].
+func UseRetryDelay(opts ...kafka.InterceptorOption) kafka.ReaderInterceptor {
+	ops := &kafka.InterceptorOptions{}
+	for _, opt := range opts {
+		opt(ops)
+	}
+	if ops.Skip == nil {
+		ops.Skip = func(msg *kgo.Record) bool {
+			return false
+		}
+	}
+	return func(next kafka.ReaderHandlerFunc) kafka.ReaderHandlerFunc {
+		return func(ctx context.Context, msg *kgo.Record) error {
+			if ops.Skip(msg) {
+				return next(ctx, msg)
+			}
+
+			notBefore := retryNotBefore(msg)
+			delay := time.Until(notBefore)
+			if delay <= 0 {
+				return next(ctx, msg)
+			}
+			if deadline, ok := ctx.Deadline(); ok && deadline.Before(notBefore) {
+				return fmt.Errorf("%w: record is not due until %s", ErrRetryNotDue, notBefore.Format(time.RFC3339Nano))
+			}
+
+			timer := time.NewTimer(delay)
+			select {
+			case <-timer.C:
+			case <-ctx.Done():
+				timer.Stop()
+				return ctx.Err()
+			}
+			return next(ctx, msg)
+		}
+	}
+}
+
+func retryNotBefore(msg *kgo.Record) time.Time {
+	for _, h := range msg.Headers {
+		if h.Key != HeaderRetryNotBefore {
+			continue
+		}
+		notBefore, err := time.Parse(time.RFC3339Nano, string(h.Value))
+		if err != nil {
+			return time.Time{}
+		}
+		return notBefore
+	}
+	return time.Time{}
+}
@@ -0,0 +1,232 @@
+package interceptor
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/twmb/franz-go/pkg/kgo"
+)
+
+func headerValue(record *kgo.Record, key string) string {
+	for _, h := range record.Headers {
+		if h.Key == key {
+			return string(h.Value)
+		}
+	}
+	return ""
+}
+
+func TestNewRetryRecord(t *testing.T) {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	policy := RetryTopicPolicy{
+		Backoffs:        []time.Duration{time.Second, time.Minute},
+		DeadLetterTopic: DeadLetterTopicName,
+	}
+	errHandler := errors.New("handler failed")
+	msg := &kgo.Record{
+		Topic:   "orders",
+		Key:     []byte("order-1"),
+		Value:   []byte("data"),
+		Headers: []kgo.RecordHeader{{Key: "ce_id", Value: []byte("1")}},
+	}
+
+	first := newRetryRecord(msg, policy, errHandler, now)
+	assert.Equal(t, "orders-retry-1", first.Topic)
+	assert.Equal(t, msg.Key, first.Key)
+	assert.Equal(t, msg.Value, first.Value)
+	assert.Equal(t, "1", headerValue(first, "ce_id"))
+	assert.Equal(t, "orders", headerValue(first, HeaderOriginalTopic))
+	assert.Equal(t, "1", headerValue(first, HeaderRetryAttempt))
+	assert.Equal(t, now.Format(time.RFC3339Nano), headerValue(first, HeaderRetryFirstFailureTime))
+	assert.Equal(t, "handler failed", headerValue(first, HeaderRetryLastError))
+	assert.Equal(t, now.Add(time.Second).Format(time.RFC3339Nano), headerValue(first, HeaderRetryNotBefore))
+
+	first.Topic = "orders-retry-1"
+	later := now.Add(time.Hour)
+	second := newRetryRecord(first, policy, errHandler, later)
+	assert.Equal(t, "orders-retry-2", second.Topic)
+	assert.Equal(t, "2", headerValue(second, HeaderRetryAttempt))
+	assert.Equal(t, now.Format(time.RFC3339Nano), headerValue(second, HeaderRetryFirstFailureTime))
+	assert.Equal(t, later.Add(time.Minute).Format(time.RFC3339Nano), headerValue(second, HeaderRetryNotBefore))
+	assert.Len(t, second.Headers, len(first.Headers)) // previous retry headers are replaced
+
+	second.Topic = "orders-retry-2"
+	exhausted := newRetryRecord(second, policy, errHandler, later)
+	assert.Equal(t, "orders-dlq", exhausted.Topic)
+	assert.Equal(t, "3", headerValue(exhausted, HeaderRetryAttempt))
+	assert.Empty(t, headerValue(exhausted, HeaderRetryNotBefore))
+
+	policy.DeadLetterTopic = func(topic string) string {
+		return "dead-letters." + topic
+	}
+	assert.Equal(t, "dead-letters.orders", newRetryRecord(second, policy, errHandler, later).Topic)
+}
+
+func TestRetryNotBefore(t *testing.T) {
+	notBefore := time.Date(2025, 1, 1, 0, 0, 1, 500, time.UTC)
+	tests := []struct {
+		name   string
+		header []kgo.RecordHeader
+		exp    time.Time
+	}{
+		{
+			name: "missing header",
+		},
+		{
+			name:   "malformed header",
+			header: []kgo.RecordHeader{{Key: HeaderRetryNotBefore, Value: []byte("tomorrow")}},
+		},
+		{
+			name: "valid header",
+			header: []kgo.RecordHeader{
+				{Key: HeaderRetryAttempt, Value: []byte("1")},
+				{Key: HeaderRetryNotBefore, Value: []byte(notBefore.Format(time.RFC3339Nano))},
+			},
+			exp: notBefore,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.True(t, tt.exp.Equal(retryNotBefore(&kgo.Record{Headers: tt.header})))
+		})
+	}
+}
+
+func TestUseRetryDelay(t *testing.T) {
+	calls := 0
+	handler := UseRetryDelay()(func(_ context.Context, _ *kgo.Record) error {
+		calls++
+		return nil
+	})
+	newRecord := func(delay time.Duration) *kgo.Record {
+		return &kgo.Record{Headers: []kgo.RecordHeader{{
+			Key:   HeaderRetryNotBefore,
+			Value: []byte(time.Now().Add(delay).Format(time.RFC3339Nano)),
+		}}}
+	}
+
+	t.Run("due", func(t *testing.T) {
+		calls = 0
+		require.NoError(t, handler(context.Background(), &kgo.Record{}))
+		require.NoError(t, handler(context.Background(), newRecord(-time.Second)))
+		assert.Equal(t, 2, calls)
+	})
+
+	t.Run("delayed", func(t *testing.T) {
+		calls = 0
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		start := time.Now()
+		require.NoError(t, handler(ctx, newRecord(20*time.Millisecond)))
+		assert.GreaterOrEqual(t, time.Since(start), 20*time.Millisecond)
+		assert.Equal(t, 1, calls)
+	})
+
+	t.Run("beyond deadline", func(t *testing.T) {
+		calls = 0
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		start := time.Now()
+		assert.ErrorIs(t, handler(ctx, newRecord(time.Minute)), ErrRetryNotDue)
+		assert.Less(t, time.Since(start), 10*time.Millisecond)
+		assert.Zero(t, calls)
+	})
+
+	t.Run("canceled", func(t *testing.T) {
+		calls = 0
+		ctx, cancel := context.WithCancel(context.Background())
+		time.AfterFunc(10*time.Millisecond, cancel)
+		assert.ErrorIs(t, handler(ctx, newRecord(time.Minute)), context.Canceled)
+		assert.Zero(t, calls)
+	})
+}
+
+type fakeProducer struct {
+	records []*kgo.Record
+}
+
+func (p *fakeProducer) ProduceSync(_ context.Context, rs ...*kgo.Record) kgo.ProduceResults {
+	p.records = append(p.records, rs...)
+	return nil
+}
+
+func TestUseRetryTopics_RetryDelay(t *testing.T) {
+	policy := RetryTopicPolicy{Backoffs: []time.Duration{time.Minute, time.Hour}}
+	errHandler := errors.New("handler failed")
+	newRecord := func(notBefore time.Time) *kgo.Record {
+		return &kgo.Record{
+			Topic: "orders-retry-1",
+			Key:   []byte("order-1"),
+			Value: []byte("data"),
+			Headers: []kgo.RecordHeader{
+				{Key: HeaderOriginalTopic, Value: []byte("orders")},
+				{Key: HeaderRetryAttempt, Value: []byte("1")},
+				{Key: HeaderRetryNotBefore, Value: []byte(notBefore.Format(time.RFC3339Nano))},
+			},
+		}
+	}
+
+	tests := []struct {
+		name       string
+		notBefore  time.Time
+		handlerErr error
+		wantCalls  int
+		wantTopic  string
+		wantHeader string // expected value of HeaderRetryAttempt
+	}{
+		{
+			name:       "not due",
+			notBefore:  time.Now().Add(time.Minute),
+			wantTopic:  "orders-retry-1",
+			wantHeader: "1",
+		},
+		{
+			name:       "due and failed",
+			notBefore:  time.Now().Add(-time.Second),
+			handlerErr: errHandler,
+			wantCalls:  1,
+			wantTopic:  "orders-retry-2",
+			wantHeader: "2",
+		},
+		{
+			name:      "due and processed",
+			notBefore: time.Now().Add(-time.Second),
+			wantCalls: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			producer := &fakeProducer{}
+			calls := 0
+			handler := useRetryTopics(producer, policy)(UseRetryDelay()(func(_ context.Context, _ *kgo.Record) error {
+				calls++
+				return tt.handlerErr
+			}))
+
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+			defer cancel()
+			msg := newRecord(tt.notBefore)
+			require.NoError(t, handler(ctx, msg))
+			assert.Equal(t, tt.wantCalls, calls)
+			if tt.wantTopic == "" {
+				assert.Empty(t, producer.records)
+				return
+			}
+
+			require.Len(t, producer.records, 1)
+			record := producer.records[0]
+			assert.Equal(t, tt.wantTopic, record.Topic)
+			assert.Equal(t, msg.Key, record.Key)
+			assert.Equal(t, msg.Value, record.Value)
+			assert.Equal(t, tt.wantHeader, headerValue(record, HeaderRetryAttempt))
+			if calls == 0 {
+				// records not due are republished as they are
+				assert.Equal(t, msg.Headers, record.Headers)
+			}
+		})
+	}
+}
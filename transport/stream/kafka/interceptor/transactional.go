@@ -0,0 +1,51 @@
+package interceptor
+
+import (
+	"context"
+
+	"github.com/twmb/franz-go/pkg/kgo"
+
+	"github.com/bosonicalio/geck/persistence"
+	"github.com/bosonicalio/geck/transport/stream/kafka"
+)
+
+// -- Transaction --
+
+// UseTransaction is a [kafka.ReaderInterceptor] that executes the handler within a transaction created by
+// `factory` (see [persistence.ExecInTx]). If the handler returns an error (or panics), the transaction will be
+// rolled back. If the handler completes successfully, the transaction will be committed.
+func UseTransaction(factory persistence.TxFactory, opts ...kafka.InterceptorOption) kafka.ReaderInterceptor {
+	return useTx(func(ctx context.Context, fn func(ctx context.Context) error) error {
+		return persistence.ExecInTx(ctx, factory, fn)
+	}, opts...)
+}
+
+// UseTxManager is a [kafka.ReaderInterceptor] that executes the handler within the set of transactions managed
+// by `manager` (see [persistence.TxManager.Execute]). If the handler returns an error (or panics), every
+// transaction will be rolled back. If the handler completes successfully, every transaction will be committed.
+func UseTxManager(manager *persistence.TxManager, opts ...kafka.InterceptorOption) kafka.ReaderInterceptor {
+	return useTx(manager.Execute, opts...)
+}
+
+func useTx(exec func(ctx context.Context, fn func(ctx context.Context) error) error,
+	opts ...kafka.InterceptorOption) kafka.ReaderInterceptor {
+	ops := &kafka.InterceptorOptions{}
+	for _, opt := range opts {
+		opt(ops)
+	}
+	if ops.Skip == nil {
+		ops.Skip = func(msg *kgo.Record) bool {
+			return false
+		}
+	}
+	return func(next kafka.ReaderHandlerFunc) kafka.ReaderHandlerFunc {
+		return func(ctx context.Context, msg *kgo.Record) error {
+			if ops.Skip(msg) {
+				return next(ctx, msg)
+			}
+			return exec(ctx, func(ctx context.Context) error {
+				return next(ctx, msg)
+			})
+		}
+	}
+}
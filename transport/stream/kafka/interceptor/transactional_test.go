@@ -0,0 +1,116 @@
+package interceptor
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/twmb/franz-go/pkg/kgo"
+
+	"github.com/bosonicalio/geck/persistence"
+	"github.com/bosonicalio/geck/transport/stream/kafka"
+)
+
+type fakeTx struct {
+	committed  bool
+	rolledBack bool
+}
+
+func (t *fakeTx) Commit(_ context.Context) error {
+	t.committed = true
+	return nil
+}
+
+func (t *fakeTx) Rollback(_ context.Context) error {
+	t.rolledBack = true
+	return nil
+}
+
+type fakeTxFactory struct {
+	driver persistence.TxDriver
+	txs    []*fakeTx
+}
+
+func (f *fakeTxFactory) Driver() persistence.TxDriver {
+	return f.driver
+}
+
+func (f *fakeTxFactory) NewTx(_ context.Context) (persistence.Transaction, error) {
+	tx := &fakeTx{}
+	f.txs = append(f.txs, tx)
+	return tx, nil
+}
+
+func TestUseTransaction(t *testing.T) {
+	errHandler := errors.New("handler failed")
+	tests := []struct {
+		name        string
+		handler     kafka.ReaderHandlerFunc
+		expErr      error
+		expCommit   bool
+		expRollback bool
+	}{
+		{
+			name: "commit",
+			handler: func(ctx context.Context, _ *kgo.Record) error {
+				_, ok := persistence.FromTxContext(ctx, "fake")
+				assert.True(t, ok)
+				return nil
+			},
+			expCommit: true,
+		},
+		{
+			name: "rollback on error",
+			handler: func(_ context.Context, _ *kgo.Record) error {
+				return errHandler
+			},
+			expErr:      errHandler,
+			expRollback: true,
+		},
+		{
+			name: "rollback on panic",
+			handler: func(_ context.Context, _ *kgo.Record) error {
+				panic(errHandler)
+			},
+			expErr:      errHandler,
+			expRollback: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			interceptors := map[string]func(factory *fakeTxFactory) kafka.ReaderInterceptor{
+				"factory": func(factory *fakeTxFactory) kafka.ReaderInterceptor {
+					return UseTransaction(factory)
+				},
+				"manager": func(factory *fakeTxFactory) kafka.ReaderInterceptor {
+					manager := persistence.NewTxManager()
+					manager.Register(factory)
+					return UseTxManager(manager)
+				},
+			}
+			for name, newInterceptor := range interceptors {
+				factory := &fakeTxFactory{driver: "fake"}
+				err := newInterceptor(factory)(tt.handler)(context.Background(), &kgo.Record{})
+				assert.ErrorIs(t, err, tt.expErr, name)
+				require.Len(t, factory.txs, 1, name)
+				assert.Equal(t, tt.expCommit, factory.txs[0].committed, name)
+				assert.Equal(t, tt.expRollback, factory.txs[0].rolledBack, name)
+			}
+		})
+	}
+
+	t.Run("skipped", func(t *testing.T) {
+		factory := &fakeTxFactory{driver: "fake"}
+		handler := UseTransaction(factory, kafka.WithSkipInterceptor(func(_ *kgo.Record) bool {
+			return true
+		}))(func(ctx context.Context, _ *kgo.Record) error {
+			_, ok := persistence.FromTxContext(ctx, "fake")
+			assert.False(t, ok)
+			return nil
+		})
+		require.NoError(t, handler(context.Background(), &kgo.Record{}))
+		assert.Empty(t, factory.txs)
+	})
+}
@@ -0,0 +1,36 @@
+package kafkatest
+
+import (
+	"context"
+
+	"github.com/twmb/franz-go/pkg/kgo"
+
+	"github.com/bosonicalio/geck/transport/stream/kafka"
+	"github.com/bosonicalio/geck/transport/stream/memstream"
+)
+
+// NewMemHandler adapts a [kafka.ReaderHandlerFunc] into a [memstream.HandlerFunc], allowing handlers (and their
+// interceptors) to be tested using a [memstream.Reader] instead of an Apache Kafka cluster.
+func NewMemHandler(handler kafka.ReaderHandlerFunc) memstream.HandlerFunc {
+	return func(ctx context.Context, record memstream.Record) error {
+		return handler(ctx, NewRecord(record))
+	}
+}
+
+// NewRecord converts a [memstream.Record] into a [kgo.Record]. Headers are converted the same way Kafka writers
+// (e.g. [kafka.SyncWriter]) do, see [kafka.MarshalHeaders].
+func NewRecord(record memstream.Record) *kgo.Record {
+	var key []byte
+	if record.Key != "" {
+		key = []byte(record.Key)
+	}
+	return &kgo.Record{
+		Key:       key,
+		Value:     record.Data,
+		Headers:   kafka.MarshalHeaders(record.Header),
+		Timestamp: record.Timestamp,
+		Topic:     record.Topic,
+		Partition: record.Partition,
+		Offset:    record.Offset,
+	}
+}
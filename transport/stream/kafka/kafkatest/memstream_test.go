@@ -0,0 +1,42 @@
+package kafkatest_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/twmb/franz-go/pkg/kgo"
+
+	"github.com/bosonicalio/geck/transport/stream"
+	"github.com/bosonicalio/geck/transport/stream/kafka"
+	"github.com/bosonicalio/geck/transport/stream/kafka/kafkatest"
+	"github.com/bosonicalio/geck/transport/stream/memstream"
+)
+
+func TestNewRecord(t *testing.T) {
+	header := stream.Header{"ce_id": {"1", "2"}, "ce_type": {"acme.user.created"}, "empty": nil}
+	timestamp := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	record := kafkatest.NewRecord(memstream.Record{
+		Topic:     "acme.user.created",
+		Partition: 1,
+		Offset:    3,
+		Key:       "user-1",
+		Header:    header,
+		Data:      []byte(`{}`),
+		Timestamp: timestamp,
+	})
+
+	// headers are converted as written by Kafka writers
+	assert.ElementsMatch(t, kafka.MarshalHeaders(header), record.Headers)
+	assert.ElementsMatch(t, []kgo.RecordHeader{
+		{Key: "ce_id", Value: []byte("1")},
+		{Key: "ce_type", Value: []byte("acme.user.created")},
+	}, record.Headers)
+	assert.Equal(t, []byte("user-1"), record.Key)
+	assert.Equal(t, "acme.user.created", record.Topic)
+	assert.Equal(t, int32(1), record.Partition)
+	assert.Equal(t, int64(3), record.Offset)
+	assert.Equal(t, timestamp, record.Timestamp)
+
+	assert.Nil(t, kafkatest.NewRecord(memstream.Record{}).Key)
+}
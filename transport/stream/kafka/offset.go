@@ -0,0 +1,185 @@
+package kafka
+
+import (
+	"slices"
+	"sync"
+
+	"github.com/twmb/franz-go/pkg/kgo"
+)
+
+// offsetTracker keeps track of the processing state of fetched records per partition, computing the highest
+// offset safe to be committed for each one of them (i.e. all previous records were processed successfully).
+//
+// Records failing to be processed are never marked as processed, blocking the commit of its partition until the
+// partition is revoked from the client (e.g. after a restart or a consumer group rebalance). Hence, such records
+// will be fetched again by the consumer group. Once a record of a partition fails, records with a greater offset
+// are not tracked anymore as their offsets cannot be committed (see [partitionOffsets.markFailed]).
+type offsetTracker struct {
+	mu         sync.Mutex
+	partitions map[string]map[int32]*partitionOffsets
+}
+
+type partitionOffsets struct {
+	mu        sync.Mutex
+	inFlight  []kgo.EpochOffset // ascending order, as fetched from the partition
+	processed map[int64]struct{}
+	commit    kgo.EpochOffset
+	dirty     bool
+	// failed is the offset of the first record that failed to be processed, -1 if no record failed.
+	failed  int64
+	revoked bool
+}
+
+func newOffsetTracker() *offsetTracker {
+	return &offsetTracker{
+		partitions: make(map[string]map[int32]*partitionOffsets),
+	}
+}
+
+// track registers `record` as in-flight, returning the partition state the record belongs to.
+func (t *offsetTracker) track(record *kgo.Record) *partitionOffsets {
+	t.mu.Lock()
+	topicPartitions, ok := t.partitions[record.Topic]
+	if !ok {
+		topicPartitions = make(map[int32]*partitionOffsets)
+		t.partitions[record.Topic] = topicPartitions
+	}
+	partition, ok := topicPartitions[record.Partition]
+	if !ok {
+		partition = &partitionOffsets{
+			inFlight:  make([]kgo.EpochOffset, 0),
+			processed: make(map[int64]struct{}),
+			failed:    -1,
+		}
+		topicPartitions[record.Partition] = partition
+	}
+	t.mu.Unlock()
+
+	partition.mu.Lock()
+	if !partition.isBlocked(record.Offset) {
+		partition.inFlight = append(partition.inFlight, kgo.EpochOffset{
+			Epoch:  record.LeaderEpoch,
+			Offset: record.Offset,
+		})
+	}
+	partition.mu.Unlock()
+	return partition
+}
+
+// uncommitted returns the offsets to commit for every partition which commit offset advanced since the
+// last successful commit.
+func (t *offsetTracker) uncommitted() map[string]map[int32]kgo.EpochOffset {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	offsets := make(map[string]map[int32]kgo.EpochOffset)
+	for topic, topicPartitions := range t.partitions {
+		for partitionID, partition := range topicPartitions {
+			partition.mu.Lock()
+			if partition.dirty {
+				if _, ok := offsets[topic]; !ok {
+					offsets[topic] = make(map[int32]kgo.EpochOffset)
+				}
+				offsets[topic][partitionID] = partition.commit
+			}
+			partition.mu.Unlock()
+		}
+	}
+	return offsets
+}
+
+// markCommitted acknowledges `offsets` were committed successfully.
+func (t *offsetTracker) markCommitted(offsets map[string]map[int32]kgo.EpochOffset) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for topic, topicOffsets := range offsets {
+		for partitionID, offset := range topicOffsets {
+			partition, ok := t.partitions[topic][partitionID]
+			if !ok {
+				continue
+			}
+			partition.mu.Lock()
+			if partition.commit == offset {
+				partition.dirty = false
+			}
+			partition.mu.Unlock()
+		}
+	}
+}
+
+// revoke removes the state of the given partitions, returning their offsets pending to be committed.
+//
+// Records belonging to revoked partitions still being processed are ignored once they finish.
+func (t *offsetTracker) revoke(revoked map[string][]int32) map[string]map[int32]kgo.EpochOffset {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	offsets := make(map[string]map[int32]kgo.EpochOffset)
+	for topic, partitionIDs := range revoked {
+		for _, partitionID := range partitionIDs {
+			partition, ok := t.partitions[topic][partitionID]
+			if !ok {
+				continue
+			}
+			delete(t.partitions[topic], partitionID)
+			partition.mu.Lock()
+			partition.revoked = true
+			if partition.dirty {
+				if _, ok = offsets[topic]; !ok {
+					offsets[topic] = make(map[int32]kgo.EpochOffset)
+				}
+				offsets[topic][partitionID] = partition.commit
+			}
+			partition.mu.Unlock()
+		}
+	}
+	return offsets
+}
+
+// markProcessed marks `record` as processed, advancing the commit offset of the partition up to the
+// highest offset of contiguous processed records.
+func (p *partitionOffsets) markProcessed(record *kgo.Record) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.isBlocked(record.Offset) {
+		return
+	}
+	p.processed[record.Offset] = struct{}{}
+	for len(p.inFlight) > 0 {
+		head := p.inFlight[0]
+		if _, ok := p.processed[head.Offset]; !ok {
+			break
+		}
+		delete(p.processed, head.Offset)
+		p.inFlight = p.inFlight[1:]
+		// committed offsets point to the next record to fetch
+		p.commit = kgo.EpochOffset{Epoch: head.Epoch, Offset: head.Offset + 1}
+		p.dirty = true
+	}
+}
+
+// markFailed marks `record` as failed, discarding the state of records with a greater offset as the commit offset
+// of the partition is not able to advance past `record` anymore. Records with a lower offset are still tracked.
+//
+// Returns true if `record` is the first record of the partition that failed and the partition was not revoked.
+func (p *partitionOffsets) markFailed(record *kgo.Record) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	isFirst := p.failed < 0 && !p.revoked
+	if !isFirst && p.failed <= record.Offset {
+		return false
+	}
+	p.failed = record.Offset
+	p.inFlight = slices.DeleteFunc(p.inFlight, func(offset kgo.EpochOffset) bool {
+		return offset.Offset > record.Offset
+	})
+	for offset := range p.processed {
+		if offset > record.Offset {
+			delete(p.processed, offset)
+		}
+	}
+	return isFirst
+}
+
+// isBlocked indicates whether the commit offset of the partition is not able to reach `offset` due a failed record.
+func (p *partitionOffsets) isBlocked(offset int64) bool {
+	return p.failed >= 0 && offset > p.failed
+}
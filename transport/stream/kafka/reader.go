@@ -2,7 +2,11 @@ package kafka
 
 import (
 	"context"
+	"encoding/binary"
 	"errors"
+	"fmt"
+	"hash/fnv"
+	"slices"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -10,6 +14,7 @@ import (
 	"github.com/samber/lo"
 	"github.com/twmb/franz-go/pkg/kerr"
 	"github.com/twmb/franz-go/pkg/kgo"
+	"github.com/twmb/franz-go/pkg/kmsg"
 )
 
 // -- Error(s) --
@@ -25,6 +30,9 @@ var (
 	ErrReaderManagerClosed = errors.New("reader manager is closed")
 	// ErrReaderManagerAlreadyStarted is returned when the reader manager is already started.
 	ErrReaderManagerAlreadyStarted = errors.New("reader manager already started")
+	// ErrPartitionPaused is returned when a partition stops being fetched as one of its records failed to be
+	// processed (see [WithReaderManagerOffsetTracking]).
+	ErrPartitionPaused = errors.New("partition paused")
 )
 
 // -- Reader Manager --
@@ -74,13 +82,30 @@ type ReaderManager interface {
 //
 // It is the responsibility of the user to handle the errors returned by the handler function. It is recommended
 // to use a retry mechanism or a dead-letter queue (DLQ) to handle these errors to fully guarantee no data-loss.
+//
+// Alternatively, offset tracking can be enabled (see [WithReaderManagerOffsetTracking]) for consumer groups. In this
+// mode, pollers do not wait for the whole polled batch to be processed. Instead, the processing state of each record
+// is tracked per partition and the highest offset of contiguous successfully processed records is committed
+// periodically (see [WithReaderManagerCommitInterval]). Records which handler failed are not committed, so they
+// will be fetched again by the consumer group after a restart or rebalance. As the commit offset of a partition
+// cannot advance past a failed record, the partition stops being fetched until it is revoked from the client and
+// an error wrapping [ErrPartitionPaused] is passed to the error handler (see [WithReaderManagerErrorHandler]).
+//
+// By default, polled records are fed to workers through a single shared channel, giving no ordering guarantees.
+// If key ordering is enabled (see [WithReaderManagerKeyOrdering]), each worker gets its own channel (aka. lane) and
+// records are routed to a fixed lane by hashing their key (or their topic partition if the record has no key).
+// Thus, records sharing the same key are processed sequentially while records with different keys are processed
+// in parallel.
 type ChannelReaderManager struct {
 	options readerManagerOptions
 	client  *kgo.Client
 
 	topicHandlerMap     map[string]ReaderHandlerFunc
+	clientsMu           sync.RWMutex
 	topicGroupClientMap map[string]*kgo.Client
-	messageWorkerChanel chan *kgo.Record
+	clientTrackers      map[*kgo.Client]*offsetTracker
+	messageWorkerChanel chan readerTask
+	workerLanes         []chan readerTask
 	inFlightProcs       sync.WaitGroup
 	alreadyStarted      atomic.Bool
 	isClosed            atomic.Bool
@@ -92,6 +117,15 @@ type ChannelReaderManager struct {
 // compile-time assertion
 var _ ReaderManager = (*ChannelReaderManager)(nil)
 
+// readerTask is a unit of work sent to the worker pool.
+type readerTask struct {
+	record *kgo.Record
+	// partition is the tracked state of the record partition, nil if offset tracking is disabled.
+	partition *partitionOffsets
+	// client is the client the record was fetched from.
+	client *kgo.Client
+}
+
 // NewChannelReaderManager creates a new instance of [ChannelReaderManager].
 func NewChannelReaderManager(opts ...ReaderManagerOption) (*ChannelReaderManager, error) {
 	options := readerManagerOptions{}
@@ -99,16 +133,58 @@ func NewChannelReaderManager(opts ...ReaderManagerOption) (*ChannelReaderManager
 		opt(&options)
 	}
 
-	client, err := kgo.NewClient(options.baseOpts...)
-	if err != nil {
-		return nil, err
-	}
-	return &ChannelReaderManager{
+	manager := &ChannelReaderManager{
 		options:             options,
-		client:              client,
 		topicHandlerMap:     make(map[string]ReaderHandlerFunc),
 		topicGroupClientMap: make(map[string]*kgo.Client),
-	}, nil
+		clientTrackers:      make(map[*kgo.Client]*offsetTracker),
+	}
+	client, err := manager.newClient("", options.baseOpts...)
+	if err != nil {
+		return nil, err
+	}
+	manager.client = client
+	return manager, nil
+}
+
+// newClient allocates a [kgo.Client] joining the consumer group `group` (if not empty), registering an offset tracker
+// for it if offset tracking is enabled.
+//
+// Clients not joining a consumer group are never tracked as they cannot commit offsets nor get partitions revoked
+// (i.e. partitions paused due failed records would never be resumed).
+func (c *ChannelReaderManager) newClient(group string, opts ...kgo.Opt) (*kgo.Client, error) {
+	if group != "" {
+		opts = slices.Concat(opts, []kgo.Opt{kgo.ConsumerGroup(group)})
+	}
+	if !c.options.trackOffsets || group == "" {
+		return kgo.NewClient(opts...)
+	}
+
+	tracker := newOffsetTracker()
+	client, err := kgo.NewClient(slices.Concat(opts, []kgo.Opt{
+		kgo.DisableAutoCommit(),
+		kgo.OnPartitionsRevoked(func(ctx context.Context, client *kgo.Client, revoked map[string][]int32) {
+			// partitions paused due failed records are fetched again from their committed offset once assigned
+			client.ResumeFetchPartitions(revoked)
+			offsets := tracker.revoke(revoked)
+			if len(offsets) == 0 {
+				return
+			}
+			if err := c.commitOffsets(ctx, client, offsets); err != nil && c.options.errorHandler != nil {
+				c.options.errorHandler(ctx, err)
+			}
+		}),
+		kgo.OnPartitionsLost(func(_ context.Context, client *kgo.Client, lost map[string][]int32) {
+			// partitions were lost, committing is not possible anymore
+			client.ResumeFetchPartitions(lost)
+			_ = tracker.revoke(lost)
+		}),
+	})...)
+	if err != nil {
+		return nil, err
+	}
+	c.clientTrackers[client] = tracker
+	return client, nil
 }
 
 // Register registers a handler for a specific topic. The handler will be invoked everytime a record is fetched
@@ -147,6 +223,8 @@ func (c *ChannelReaderManager) Register(name string, handler ReaderHandlerFunc,
 		return nil
 	}
 
+	c.clientsMu.Lock()
+	defer c.clientsMu.Unlock()
 	var err error
 	groupClient, ok := c.topicGroupClientMap[name]
 	if ok {
@@ -154,9 +232,7 @@ func (c *ChannelReaderManager) Register(name string, handler ReaderHandlerFunc,
 		return nil
 	}
 
-	groupClient, err = kgo.NewClient(
-		append(c.options.baseOpts, kgo.ConsumerGroup(ops.group.String()))...,
-	)
+	groupClient, err = c.newClient(ops.group.String(), c.options.baseOpts...)
 	if err != nil {
 		return err
 	}
@@ -196,13 +272,29 @@ func (c *ChannelReaderManager) Start() error {
 	c.options.pollInterval = lo.CoalesceOrEmpty(c.options.pollInterval, 500*time.Millisecond)
 	c.options.workerPoolSize = lo.CoalesceOrEmpty(c.options.workerPoolSize, c.options.pollBatchSize/2)
 	c.options.handlerTimeout = lo.CoalesceOrEmpty(c.options.handlerTimeout, 30*time.Second)
+	c.options.commitInterval = lo.CoalesceOrEmpty(c.options.commitInterval, time.Second)
 
 	// bootstrap worker pool
-	c.messageWorkerChanel = make(chan *kgo.Record, c.options.workerPoolSize)
-	go c.startWorkerProc()
+	if c.options.keyOrdering {
+		totalLanes := max(c.options.workerPoolSize, 1)
+		laneBufferSize := max(c.options.pollBatchSize/totalLanes, 1)
+		c.workerLanes = make([]chan readerTask, totalLanes)
+		for i := range c.workerLanes {
+			c.workerLanes[i] = make(chan readerTask, laneBufferSize)
+			go c.startWorkerProc(c.workerLanes[i])
+		}
+	} else {
+		c.messageWorkerChanel = make(chan readerTask, c.options.workerPoolSize)
+		go c.startWorkerProc(c.messageWorkerChanel)
+	}
+
+	for client, tracker := range c.clientTrackers {
+		go c.startCommitter(client, tracker)
+	}
 
 	c.alreadyStarted.Store(true)
-	errs := make([]error, 0, len(c.topicGroupClientMap)+1)
+	groupClients := c.groupClients()
+	errs := make([]error, 0, len(groupClients)+1)
 	errsMu := sync.Mutex{}
 	go func() {
 		if err := c.startPoller(c.client); err != nil {
@@ -211,7 +303,7 @@ func (c *ChannelReaderManager) Start() error {
 			errsMu.Unlock()
 		}
 	}()
-	for _, groupClient := range c.topicGroupClientMap {
+	for _, groupClient := range groupClients {
 		go func() {
 			if err := c.startPoller(groupClient); err != nil {
 				errsMu.Lock()
@@ -256,10 +348,17 @@ func (c *ChannelReaderManager) startPoller(client *kgo.Client) error {
 		numRecords := fetches.NumRecords()
 		iter := fetches.RecordIter()
 		c.inFlightProcs.Add(numRecords)
+		tracker, isTracked := c.clientTrackers[client]
 		for !iter.Done() {
-			record := iter.Next()
-			// send to worker channel
-			c.messageWorkerChanel <- record
+			task := readerTask{record: iter.Next(), client: client}
+			if isTracked {
+				task.partition = tracker.track(task.record)
+			}
+			c.dispatch(task)
+		}
+		if isTracked {
+			// offsets are committed by the committer job as records are processed
+			continue
 		}
 		c.inFlightProcs.Wait()
 		err = client.CommitUncommittedOffsets(c.ctxBase)
@@ -269,24 +368,120 @@ func (c *ChannelReaderManager) startPoller(client *kgo.Client) error {
 	}
 }
 
-func (c *ChannelReaderManager) startWorkerProc() {
-	for message := range c.messageWorkerChanel {
-		err := c.processRecord(message)
+// groupClients returns the clients of the consumer groups registered in the reader manager.
+func (c *ChannelReaderManager) groupClients() []*kgo.Client {
+	c.clientsMu.RLock()
+	defer c.clientsMu.RUnlock()
+	clients := make([]*kgo.Client, 0, len(c.topicGroupClientMap))
+	for _, client := range c.topicGroupClientMap {
+		clients = append(clients, client)
+	}
+	return clients
+}
+
+// dispatch sends `task` to the worker pool.
+func (c *ChannelReaderManager) dispatch(task readerTask) {
+	if len(c.workerLanes) == 0 {
+		c.messageWorkerChanel <- task
+		return
+	}
+	c.workerLanes[laneIndex(task.record, len(c.workerLanes))] <- task
+}
+
+// laneIndex returns the worker lane of `record` by hashing its key, falling back to its topic partition
+// if the record has no key.
+func laneIndex(record *kgo.Record, totalLanes int) int {
+	hash := fnv.New32a()
+	if len(record.Key) > 0 {
+		_, _ = hash.Write(record.Key)
+	} else {
+		_, _ = hash.Write([]byte(record.Topic))
+		_, _ = hash.Write(binary.BigEndian.AppendUint32(nil, uint32(record.Partition)))
+	}
+	return int(hash.Sum32() % uint32(totalLanes))
+}
+
+func (c *ChannelReaderManager) startWorkerProc(tasks <-chan readerTask) {
+	for task := range tasks {
+		err := c.processRecord(task)
 		if err != nil && c.options.errorHandler != nil {
 			c.options.errorHandler(c.ctxBase, err)
 		}
 	}
 }
 
-func (c *ChannelReaderManager) processRecord(record *kgo.Record) error {
+func (c *ChannelReaderManager) processRecord(task readerTask) error {
 	defer c.inFlightProcs.Done()
-	handlerFunc, ok := c.topicHandlerMap[record.Topic]
+	handlerFunc, ok := c.topicHandlerMap[task.record.Topic]
 	if !ok {
 		return ErrNoHandlerFound
 	}
 	scopedCtx, cancelFunc := context.WithTimeout(c.ctxBase, c.options.handlerTimeout)
 	defer cancelFunc()
-	return handlerFunc(scopedCtx, record)
+	if err := handlerFunc(scopedCtx, task.record); err != nil {
+		if task.partition != nil && task.partition.markFailed(task.record) {
+			// stop fetching records which offsets cannot be committed
+			task.client.PauseFetchPartitions(map[string][]int32{task.record.Topic: {task.record.Partition}})
+			err = errors.Join(err, fmt.Errorf("%w: topic %s, partition %d", ErrPartitionPaused,
+				task.record.Topic, task.record.Partition))
+		}
+		return err
+	}
+	if task.partition != nil {
+		task.partition.markProcessed(task.record)
+	}
+	return nil
+}
+
+func (c *ChannelReaderManager) startCommitter(client *kgo.Client, tracker *offsetTracker) {
+	ticker := time.NewTicker(c.options.commitInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-c.ctxBase.Done():
+			return
+		case <-ticker.C:
+		}
+		if err := c.commitTracked(c.ctxBase, client, tracker); err != nil && c.options.errorHandler != nil {
+			c.options.errorHandler(c.ctxBase, err)
+		}
+	}
+}
+
+// commitTracked commits the offsets of `tracker` processed since the last commit.
+func (c *ChannelReaderManager) commitTracked(ctx context.Context, client *kgo.Client, tracker *offsetTracker) error {
+	offsets := tracker.uncommitted()
+	if len(offsets) == 0 {
+		return nil
+	}
+	if err := c.commitOffsets(ctx, client, offsets); err != nil {
+		return err
+	}
+	tracker.markCommitted(offsets)
+	return nil
+}
+
+func (c *ChannelReaderManager) commitOffsets(ctx context.Context, client *kgo.Client,
+	offsets map[string]map[int32]kgo.EpochOffset) error {
+	var errCommit error
+	client.CommitOffsetsSync(ctx, offsets,
+		func(_ *kgo.Client, _ *kmsg.OffsetCommitRequest, resp *kmsg.OffsetCommitResponse, err error) {
+			if err != nil {
+				errCommit = err
+				return
+			}
+			errs := make([]error, 0)
+			for _, topic := range resp.Topics {
+				for _, partition := range topic.Partitions {
+					if errPartition := kerr.ErrorForCode(partition.ErrorCode); errPartition != nil {
+						errs = append(errs, errPartition)
+					}
+				}
+			}
+			errCommit = errors.Join(errs...)
+		},
+	)
+	return errCommit
 }
 
 // Close closes the reader manager. It stops polling records from Kafka and waits for all in-flight handlers
@@ -303,12 +498,23 @@ func (c *ChannelReaderManager) Close(ctx context.Context) error {
 	}
 	c.ctxCancelFunc()
 	c.inFlightProcs.Wait()
-	close(c.messageWorkerChanel)
+	if c.messageWorkerChanel != nil {
+		close(c.messageWorkerChanel)
+	}
+	for _, lane := range c.workerLanes {
+		close(lane)
+	}
+	errs := make([]error, 0)
+	for client, tracker := range c.clientTrackers {
+		if err := c.commitTracked(ctx, client, tracker); err != nil {
+			errs = append(errs, err)
+		}
+	}
 	c.client.Close()
-	for _, groupClient := range c.topicGroupClientMap {
+	for _, groupClient := range c.groupClients() {
 		groupClient.Close()
 	}
-	return nil
+	return errors.Join(errs...)
 }
 
 // -- Option(s) --
@@ -348,6 +554,9 @@ type readerManagerOptions struct {
 	pollInterval   time.Duration
 	handlerTimeout time.Duration
 	errorHandler   func(context.Context, error)
+	trackOffsets   bool
+	commitInterval time.Duration
+	keyOrdering    bool
 }
 
 // ReaderManagerOption represents an option for configuring the [ReaderManager].
@@ -405,3 +614,33 @@ func WithReaderManagerErrorHandler(handler func(context.Context, error)) ReaderM
 		o.errorHandler = handler
 	}
 }
+
+// WithReaderManagerOffsetTracking enables per-record offset tracking for consumer groups of the [ReaderManager].
+//
+// When enabled, the highest offset of contiguous successfully processed records is committed per partition
+// periodically, instead of committing all polled records once the whole batch was processed.
+func WithReaderManagerOffsetTracking(enabled bool) ReaderManagerOption {
+	return func(o *readerManagerOptions) {
+		o.trackOffsets = enabled
+	}
+}
+
+// WithReaderManagerCommitInterval sets the interval for committing tracked offsets. Defaults to one second.
+//
+// Only used if offset tracking is enabled (see [WithReaderManagerOffsetTracking]).
+func WithReaderManagerCommitInterval(interval time.Duration) ReaderManagerOption {
+	return func(o *readerManagerOptions) {
+		o.commitInterval = interval
+	}
+}
+
+// WithReaderManagerKeyOrdering enables ordered per-key processing of records in the [ReaderManager].
+//
+// When enabled, records are routed to a fixed worker lane (one per worker of the pool) by hashing their key,
+// or their topic partition if the record has no key. Records sharing the same key are processed sequentially,
+// while records with different keys are processed in parallel.
+func WithReaderManagerKeyOrdering(enabled bool) ReaderManagerOption {
+	return func(o *readerManagerOptions) {
+		o.keyOrdering = enabled
+	}
+}
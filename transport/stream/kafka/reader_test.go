@@ -1,8 +1,215 @@
-package kafka_test
+package kafka
 
 import (
+	"context"
+	"errors"
+	"strconv"
 	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/twmb/franz-go/pkg/kgo"
 )
 
-func TestReader(t *testing.T) {
+func newTestRecord(topic string, partition int32, offset int64) *kgo.Record {
+	return &kgo.Record{Topic: topic, Partition: partition, Offset: offset, LeaderEpoch: 1}
+}
+
+func TestOffsetTracker_MarkProcessed(t *testing.T) {
+	tracker := newOffsetTracker()
+	records := make([]*kgo.Record, 0, 4)
+	var partition *partitionOffsets
+	for offset := range int64(4) {
+		record := newTestRecord("orders", 0, offset)
+		records = append(records, record)
+		partition = tracker.track(record)
+	}
+	assert.Empty(t, tracker.uncommitted())
+
+	// out of order, commit offset waits for previous records
+	partition.markProcessed(records[1])
+	partition.markProcessed(records[3])
+	assert.Empty(t, tracker.uncommitted())
+
+	partition.markProcessed(records[0])
+	exp := map[string]map[int32]kgo.EpochOffset{"orders": {0: {Epoch: 1, Offset: 2}}}
+	assert.Equal(t, exp, tracker.uncommitted())
+
+	tracker.markCommitted(exp)
+	assert.Empty(t, tracker.uncommitted())
+
+	partition.markProcessed(records[2])
+	assert.Equal(t, map[string]map[int32]kgo.EpochOffset{"orders": {0: {Epoch: 1, Offset: 4}}},
+		tracker.uncommitted())
+	assert.Empty(t, partition.inFlight)
+	assert.Empty(t, partition.processed)
+
+	// stale commits do not clear newer offsets
+	tracker.markCommitted(exp)
+	assert.NotEmpty(t, tracker.uncommitted())
+}
+
+func TestOffsetTracker_MarkFailed(t *testing.T) {
+	tracker := newOffsetTracker()
+	records := make([]*kgo.Record, 0, 5)
+	var partition *partitionOffsets
+	for offset := range int64(5) {
+		record := newTestRecord("orders", 0, offset)
+		records = append(records, record)
+		partition = tracker.track(record)
+	}
+
+	partition.markProcessed(records[3])
+	assert.True(t, partition.markFailed(records[2]))
+	assert.False(t, partition.markFailed(records[4]))
+	// records after the failed one are not tracked anymore
+	assert.Len(t, partition.inFlight, 3)
+	assert.Empty(t, partition.processed)
+	for offset := int64(5); offset < 100; offset++ {
+		tracker.track(newTestRecord("orders", 0, offset))
+		partition.markProcessed(newTestRecord("orders", 0, offset))
+	}
+	assert.Len(t, partition.inFlight, 3)
+	assert.Empty(t, partition.processed)
+
+	// records before the failed one are still committed
+	partition.markProcessed(records[0])
+	partition.markProcessed(records[1])
+	assert.Equal(t, map[string]map[int32]kgo.EpochOffset{"orders": {0: {Epoch: 1, Offset: 2}}},
+		tracker.uncommitted())
+
+	// a failure of a previous record moves the failed offset back
+	partition = tracker.track(newTestRecord("payments", 0, 0))
+	tracker.track(newTestRecord("payments", 0, 1))
+	assert.True(t, partition.markFailed(newTestRecord("payments", 0, 1)))
+	assert.False(t, partition.markFailed(newTestRecord("payments", 0, 0)))
+	assert.Len(t, partition.inFlight, 1)
+}
+
+func TestOffsetTracker_Revoke(t *testing.T) {
+	tracker := newOffsetTracker()
+	processed := newTestRecord("orders", 0, 0)
+	tracker.track(processed).markProcessed(processed)
+	inFlight := newTestRecord("orders", 1, 0)
+	partition := tracker.track(inFlight)
+	tracker.track(newTestRecord("payments", 0, 0))
+
+	offsets := tracker.revoke(map[string][]int32{"orders": {0, 1}, "unknown": {0}})
+	assert.Equal(t, map[string]map[int32]kgo.EpochOffset{"orders": {0: {Epoch: 1, Offset: 1}}}, offsets)
+	assert.Empty(t, tracker.uncommitted())
+	assert.NotContains(t, tracker.partitions["orders"], int32(0))
+	assert.Contains(t, tracker.partitions["payments"], int32(0))
+
+	// records of revoked partitions finishing afterward are ignored
+	partition.markProcessed(inFlight)
+	assert.Empty(t, tracker.uncommitted())
+	assert.False(t, partition.markFailed(inFlight))
+}
+
+func TestLaneIndex(t *testing.T) {
+	const totalLanes = 8
+	lanes := make(map[int]struct{})
+	for i := range 100 {
+		key := []byte("order-" + strconv.Itoa(i))
+		lane := laneIndex(&kgo.Record{Topic: "orders", Partition: int32(i % 3), Key: key}, totalLanes)
+		assert.GreaterOrEqual(t, lane, 0)
+		assert.Less(t, lane, totalLanes)
+		// records sharing the same key are routed to the same lane, regardless of their partition
+		assert.Equal(t, lane, laneIndex(&kgo.Record{Topic: "orders", Partition: 5, Key: key}, totalLanes))
+		lanes[lane] = struct{}{}
+	}
+	// records with different keys are spread across lanes
+	assert.Len(t, lanes, totalLanes)
+
+	// records without key are routed by topic partition
+	record := &kgo.Record{Topic: "orders", Partition: 1}
+	assert.Equal(t, laneIndex(record, totalLanes), laneIndex(&kgo.Record{Topic: "orders", Partition: 1}, totalLanes))
+	assert.Zero(t, laneIndex(record, 1))
+}
+
+func TestChannelReaderManager_KeyOrdering(t *testing.T) {
+	manager := &ChannelReaderManager{
+		workerLanes: make([]chan readerTask, 4),
+	}
+	for i := range manager.workerLanes {
+		manager.workerLanes[i] = make(chan readerTask, 100)
+	}
+	keys := []string{"a", "b", "c", "d", "e", "f"}
+	for offset := range 60 {
+		key := keys[offset%len(keys)]
+		manager.dispatch(readerTask{record: &kgo.Record{Topic: "orders", Key: []byte(key), Offset: int64(offset)}})
+	}
+
+	// each key lives in a single lane keeping the order records were dispatched
+	keyLanes := make(map[string]int)
+	lastOffsets := make(map[string]int64)
+	usedLanes := 0
+	for i, lane := range manager.workerLanes {
+		close(lane)
+		if len(lane) > 0 {
+			usedLanes++
+		}
+		for task := range lane {
+			key := string(task.record.Key)
+			if prev, ok := keyLanes[key]; ok {
+				assert.Equal(t, prev, i)
+				assert.Greater(t, task.record.Offset, lastOffsets[key])
+			}
+			keyLanes[key] = i
+			lastOffsets[key] = task.record.Offset
+		}
+	}
+	assert.Len(t, keyLanes, len(keys))
+	assert.Greater(t, usedLanes, 1)
+}
+
+func TestChannelReaderManager_OffsetTracking(t *testing.T) {
+	manager, err := NewChannelReaderManager(WithReaderManagerOffsetTracking(true))
+	assert.NoError(t, err)
+	defer manager.client.Close()
+
+	// the base client does not join any consumer group, hence its offsets are not tracked
+	assert.Empty(t, manager.clientTrackers)
+
+	handler := func(ctx context.Context, record *kgo.Record) error { return nil }
+	assert.NoError(t, manager.Register("payments", handler))
+	assert.Empty(t, manager.clientTrackers)
+
+	assert.NoError(t, manager.Register("orders", handler, WithReaderGroup(MustConsumerGroup("acme", "orders", "process"))))
+	defer manager.topicGroupClientMap["orders"].Close()
+	assert.Len(t, manager.clientTrackers, 1)
+	assert.Contains(t, manager.clientTrackers, manager.topicGroupClientMap["orders"])
+}
+
+func TestChannelReaderManager_ProcessRecord(t *testing.T) {
+	errHandler := errors.New("handler failed")
+	manager := &ChannelReaderManager{
+		ctxBase: context.Background(),
+		options: readerManagerOptions{handlerTimeout: time.Second},
+		topicHandlerMap: map[string]ReaderHandlerFunc{
+			"orders": func(ctx context.Context, record *kgo.Record) error {
+				return errHandler
+			},
+		},
+	}
+
+	t.Run("untracked client", func(t *testing.T) {
+		manager.inFlightProcs.Add(1)
+		err := manager.processRecord(readerTask{record: newTestRecord("orders", 0, 1)})
+		assert.ErrorIs(t, err, errHandler)
+		assert.NotErrorIs(t, err, ErrPartitionPaused)
+	})
+	t.Run("tracked client", func(t *testing.T) {
+		client, err := kgo.NewClient()
+		assert.NoError(t, err)
+		defer client.Close()
+
+		tracker := newOffsetTracker()
+		record := newTestRecord("orders", 0, 1)
+		task := readerTask{record: record, client: client, partition: tracker.track(record)}
+		manager.inFlightProcs.Add(1)
+		err = manager.processRecord(task)
+		assert.ErrorIs(t, err, errHandler)
+		assert.ErrorIs(t, err, ErrPartitionPaused)
+	})
 }
@@ -0,0 +1,29 @@
+package kafka
+
+import (
+	"context"
+
+	"github.com/twmb/franz-go/pkg/kgo"
+
+	"github.com/bosonicalio/geck/event"
+	"github.com/bosonicalio/geck/transport/stream"
+)
+
+// Subscribe creates a [ReaderHandlerFunc] decoding records into [event.Envelope] instances of type `T` using
+// `registry`, then calling `handler`.
+//
+// The topic of the event (i.e. [event.HeaderEventType]) must be registered in `registry` with type `T`
+// (see [event.Register]), otherwise records are rejected with an error.
+func Subscribe[T any](registry *event.Registry, handler func(ctx context.Context, envelope event.Envelope[T]) error) ReaderHandlerFunc {
+	return func(ctx context.Context, msg *kgo.Record) error {
+		envelope, err := event.DecodeEnvelope[T](registry, stream.Message{
+			Key:    string(msg.Key),
+			Header: ParseHeaders(msg),
+			Data:   msg.Value,
+		})
+		if err != nil {
+			return err
+		}
+		return handler(ctx, envelope)
+	}
+}
@@ -0,0 +1,191 @@
+package memstream
+
+import (
+	"context"
+	"hash/fnv"
+	"sync"
+	"time"
+
+	"github.com/samber/lo"
+
+	"github.com/bosonicalio/geck/transport/stream"
+)
+
+// Record is a message stored in a [Broker] topic.
+type Record struct {
+	// Topic is the name of the topic the record was written to.
+	Topic string
+	// Partition is the topic partition the record was written to, computed using the record key.
+	Partition int32
+	// Offset is the position of the record within its partition.
+	Offset int64
+	// Key is the record key.
+	Key string
+	// Header contains additional metadata about the record.
+	Header stream.Header
+	// Data is the record data.
+	Data []byte
+	// Timestamp is the time the record was written.
+	Timestamp time.Time
+}
+
+// Broker is a thread-safe, in-memory message broker implementing [stream.Writer]. Intended for testing purposes.
+//
+// Topics are created on first write. Every topic has a fixed number of partitions (see [WithBrokerPartitions]);
+// records are assigned to partitions using the hash of their key, keeping the order of records sharing the same
+// key. Each partition keeps its own offset sequence.
+type Broker struct {
+	partitions int
+
+	mu      sync.RWMutex
+	topics  map[string][][]Record
+	written chan struct{}
+}
+
+// compile-time assertion
+var _ stream.Writer = (*Broker)(nil)
+
+// NewBroker creates a new [Broker] instance.
+func NewBroker(opts ...BrokerOption) *Broker {
+	options := brokerOptions{}
+	for _, opt := range opts {
+		opt(&options)
+	}
+	return &Broker{
+		partitions: max(options.partitions, 1),
+		topics:     make(map[string][][]Record),
+		written:    make(chan struct{}),
+	}
+}
+
+// Write writes a message into the topic `name`.
+func (b *Broker) Write(ctx context.Context, name string, message stream.Message) error {
+	_, err := b.WriteBatch(ctx, name, []stream.Message{message})
+	return err
+}
+
+// WriteBatch writes a batch of messages into the topic `name`.
+func (b *Broker) WriteBatch(ctx context.Context, name string, messages []stream.Message) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	topic, ok := b.topics[name]
+	if !ok {
+		topic = make([][]Record, b.partitions)
+	}
+	now := time.Now().UTC()
+	for _, msg := range messages {
+		partition := b.partition(msg.Key)
+		topic[partition] = append(topic[partition], Record{
+			Topic:     name,
+			Partition: partition,
+			Offset:    int64(len(topic[partition])),
+			Key:       msg.Key,
+			Header:    cloneHeader(msg.Header),
+			Data:      append([]byte(nil), msg.Data...),
+			Timestamp: now,
+		})
+	}
+	b.topics[name] = topic
+	// wake up waiters
+	close(b.written)
+	b.written = make(chan struct{})
+	return len(messages), nil
+}
+
+func (b *Broker) partition(key string) int32 {
+	if key == "" || b.partitions == 1 {
+		return 0
+	}
+	hash := fnv.New32a()
+	_, _ = hash.Write([]byte(key))
+	return int32(hash.Sum32() % uint32(b.partitions))
+}
+
+// Records returns a snapshot of the records written into `topic`, sorted by partition and offset.
+func (b *Broker) Records(topic string) []Record {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	records := make([]Record, 0)
+	for _, partition := range b.topics[topic] {
+		records = append(records, cloneRecords(partition)...)
+	}
+	return records
+}
+
+// Topics returns the names of the topics written so far.
+func (b *Broker) Topics() []string {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return lo.Keys(b.topics)
+}
+
+// WaitFor blocks until `topic` holds at least `n` records or `ctx` is done. Returns a snapshot of the records
+// written into `topic`.
+func (b *Broker) WaitFor(ctx context.Context, topic string, n int) ([]Record, error) {
+	for {
+		b.mu.RLock()
+		written := b.written
+		b.mu.RUnlock()
+		records := b.Records(topic)
+		if len(records) >= n {
+			return records, nil
+		}
+		select {
+		case <-ctx.Done():
+			return records, ctx.Err()
+		case <-written:
+		}
+	}
+}
+
+// fetch returns the records of `topic` and `partition` starting from `offset`, along with a channel closed
+// on the next write.
+func (b *Broker) fetch(topic string, partition int32, offset int64) ([]Record, <-chan struct{}) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	partitions := b.topics[topic]
+	if int(partition) >= len(partitions) || offset >= int64(len(partitions[partition])) {
+		return nil, b.written
+	}
+	return cloneRecords(partitions[partition][offset:]), b.written
+}
+
+// cloneRecords copies `records` along with their headers, so every delivery owns its header.
+func cloneRecords(records []Record) []Record {
+	clones := make([]Record, 0, len(records))
+	for _, record := range records {
+		record.Header = cloneHeader(record.Header)
+		clones = append(clones, record)
+	}
+	return clones
+}
+
+func cloneHeader(header stream.Header) stream.Header {
+	if header == nil {
+		return nil
+	}
+	clone := make(stream.Header, len(header))
+	for k, v := range header {
+		clone[k] = append([]string(nil), v...)
+	}
+	return clone
+}
+
+// -- Options --
+
+type brokerOptions struct {
+	partitions int
+}
+
+// BrokerOption is a routine used to set up [Broker] optional configuration.
+type BrokerOption func(*brokerOptions)
+
+// WithBrokerPartitions sets the number of partitions of every topic of a [Broker]. Defaults to 1.
+func WithBrokerPartitions(n int) BrokerOption {
+	return func(o *brokerOptions) {
+		o.partitions = n
+	}
+}
@@ -0,0 +1,139 @@
+package memstream_test
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bosonicalio/geck/transport/stream"
+	"github.com/bosonicalio/geck/transport/stream/memstream"
+)
+
+func TestBroker_WriteBatch(t *testing.T) {
+	ctx := context.Background()
+	broker := memstream.NewBroker(memstream.WithBrokerPartitions(4))
+	messages := make([]stream.Message, 0, 10)
+	for i := range 10 {
+		messages = append(messages, stream.Message{
+			Key:  "key-" + strconv.Itoa(i%2),
+			Data: []byte(strconv.Itoa(i)),
+		})
+	}
+	n, err := broker.WriteBatch(ctx, "orders", messages)
+	require.NoError(t, err)
+	assert.Equal(t, 10, n)
+
+	records := memstream.RequireRecords(t, broker, "orders", 10, time.Second)
+	partitions := make(map[string]int32)
+	offsets := make(map[int32]int64)
+	for _, record := range records {
+		// records sharing the same key are written into the same partition
+		if partition, ok := partitions[record.Key]; ok {
+			assert.Equal(t, partition, record.Partition)
+		}
+		partitions[record.Key] = record.Partition
+		assert.Equal(t, offsets[record.Partition], record.Offset)
+		offsets[record.Partition]++
+	}
+	memstream.RequireNoRecords(t, broker, "payments")
+	assert.Equal(t, []string{"orders"}, broker.Topics())
+}
+
+func TestBroker_WaitFor(t *testing.T) {
+	broker := memstream.NewBroker()
+	go func() {
+		for range 3 {
+			_ = broker.Write(context.Background(), "orders", stream.Message{Data: []byte("foo")})
+		}
+	}()
+	records := memstream.RequireRecords(t, broker, "orders", 3, time.Second)
+	assert.Len(t, records, 3)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err := broker.WaitFor(ctx, "orders", 4)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestReader(t *testing.T) {
+	broker := memstream.NewBroker(memstream.WithBrokerPartitions(2))
+	mu := sync.Mutex{}
+	received := make([]string, 0)
+	errs := make(chan error, 1)
+	reader := memstream.NewReader(broker, memstream.WithReaderErrorHandler(func(_ context.Context, err error) {
+		errs <- err
+	}))
+	reader.MustRegister("orders", func(_ context.Context, record memstream.Record) error {
+		if string(record.Data) == "fail" {
+			return errors.New("some error")
+		}
+		mu.Lock()
+		received = append(received, string(record.Data))
+		mu.Unlock()
+		return nil
+	})
+	assert.ErrorIs(t, reader.Register("orders", nil), memstream.ErrHandlerAlreadyRegistered)
+	require.NoError(t, reader.Start())
+	assert.ErrorIs(t, reader.Start(), memstream.ErrReaderAlreadyStarted)
+
+	ctx := context.Background()
+	require.NoError(t, broker.Write(ctx, "orders", stream.Message{Key: "a", Data: []byte("1")}))
+	require.NoError(t, broker.Write(ctx, "orders", stream.Message{Key: "a", Data: []byte("fail")}))
+	require.NoError(t, broker.Write(ctx, "orders", stream.Message{Key: "a", Data: []byte("2")}))
+
+	select {
+	case err := <-errs:
+		assert.EqualError(t, err, "some error")
+	case <-time.After(time.Second):
+		t.Fatal("expected handler error")
+	}
+	assert.Eventually(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(received) == 2
+	}, time.Second, time.Millisecond)
+	assert.Equal(t, []string{"1", "2"}, received)
+
+	require.NoError(t, reader.Close(ctx))
+	assert.ErrorIs(t, reader.Close(ctx), memstream.ErrReaderClosed)
+}
+
+func TestReader_HeaderIsolation(t *testing.T) {
+	ctx := context.Background()
+	broker := memstream.NewBroker()
+	received := make(chan string, 2)
+	for range 2 {
+		reader := memstream.NewReader(broker)
+		reader.MustRegister("orders", func(_ context.Context, record memstream.Record) error {
+			// handlers mutating their header do not affect other deliveries of the same record
+			received <- record.Header.Get("trace")
+			record.Header.Set("trace", "mutated")
+			return nil
+		})
+		require.NoError(t, reader.Start())
+		defer func() {
+			assert.NoError(t, reader.Close(ctx))
+		}()
+	}
+
+	header := stream.Header{}
+	header.Set("trace", "abc")
+	require.NoError(t, broker.Write(ctx, "orders", stream.Message{Header: header, Data: []byte("1")}))
+	for range 2 {
+		select {
+		case trace := <-received:
+			assert.Equal(t, "abc", trace)
+		case <-time.After(time.Second):
+			t.Fatal("expected record delivery")
+		}
+	}
+	records := broker.Records("orders")
+	require.Len(t, records, 1)
+	assert.Equal(t, "abc", records[0].Header.Get("trace"))
+}
@@ -0,0 +1,186 @@
+package memstream
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/samber/lo"
+)
+
+// -- Error(s) --
+
+var (
+	// ErrReaderAlreadyStarted is returned when the reader is already started.
+	ErrReaderAlreadyStarted = errors.New("memstream reader already started")
+	// ErrReaderClosed is returned when the reader is closed.
+	ErrReaderClosed = errors.New("memstream reader is closed")
+	// ErrHandlerAlreadyRegistered is returned when a handler is already registered for a topic.
+	ErrHandlerAlreadyRegistered = errors.New("memstream handler already registered")
+)
+
+// HandlerFunc is a routine processing a [Record].
+//
+// Handlers written for other stream implementations (e.g. Apache Kafka readers) may be adapted to this type
+// by converting [Record] into their native record type.
+type HandlerFunc func(ctx context.Context, record Record) error
+
+// Reader is a component consuming records from a [Broker], dispatching them to the handlers registered for
+// their topics.
+//
+// Every topic partition is consumed by its own goroutine starting from the earliest offset, so records of the same
+// partition are processed sequentially. Handler errors are reported to the error handler
+// (see [WithReaderErrorHandler]) and the reader continues with the next record.
+type Reader struct {
+	broker  *Broker
+	options readerOptions
+
+	regMu    sync.Mutex
+	handlers map[string]HandlerFunc
+
+	alreadyStarted atomic.Bool
+	isClosed       atomic.Bool
+	inFlightProcs  sync.WaitGroup
+	ctxBase        context.Context
+	ctxCancelFunc  context.CancelFunc
+}
+
+// NewReader creates a new [Reader] instance.
+func NewReader(broker *Broker, opts ...ReaderOption) *Reader {
+	options := readerOptions{}
+	for _, opt := range opts {
+		opt(&options)
+	}
+	options.handlerTimeout = lo.CoalesceOrEmpty(options.handlerTimeout, 30*time.Second)
+	if options.errorHandler == nil {
+		options.errorHandler = func(_ context.Context, _ error) {}
+	}
+	return &Reader{
+		broker:   broker,
+		options:  options,
+		handlers: make(map[string]HandlerFunc),
+	}
+}
+
+// Register registers `handler` to process the records of `topic`.
+//
+// This routine must be called before [Reader.Start] is called, otherwise it returns [ErrReaderAlreadyStarted].
+func (r *Reader) Register(topic string, handler HandlerFunc) error {
+	r.regMu.Lock()
+	defer r.regMu.Unlock()
+	if r.alreadyStarted.Load() {
+		return ErrReaderAlreadyStarted
+	} else if _, ok := r.handlers[topic]; ok {
+		return fmt.Errorf("%w: %s", ErrHandlerAlreadyRegistered, topic)
+	}
+	r.handlers[topic] = handler
+	return nil
+}
+
+// MustRegister registers `handler` to process the records of `topic`.
+//
+// This routine will panic if any error occurs.
+func (r *Reader) MustRegister(topic string, handler HandlerFunc) {
+	if err := r.Register(topic, handler); err != nil {
+		panic(err)
+	}
+}
+
+// Start starts consuming records in the background until the reader is closed ([Reader.Close]).
+func (r *Reader) Start() error {
+	r.regMu.Lock()
+	defer r.regMu.Unlock()
+	if r.isClosed.Load() {
+		return ErrReaderClosed
+	} else if !r.alreadyStarted.CompareAndSwap(false, true) {
+		return ErrReaderAlreadyStarted
+	}
+	r.ctxBase, r.ctxCancelFunc = context.WithCancel(context.Background())
+	for topic, handler := range r.handlers {
+		for partition := range r.broker.partitions {
+			r.inFlightProcs.Add(1)
+			go r.startPartitionProc(topic, int32(partition), handler)
+		}
+	}
+	return nil
+}
+
+func (r *Reader) startPartitionProc(topic string, partition int32, handler HandlerFunc) {
+	defer r.inFlightProcs.Done()
+	var offset int64
+	for {
+		records, written := r.broker.fetch(topic, partition, offset)
+		for _, record := range records {
+			if r.ctxBase.Err() != nil {
+				return
+			}
+			r.processRecord(handler, record)
+			offset++
+		}
+		if len(records) > 0 {
+			continue
+		}
+		select {
+		case <-r.ctxBase.Done():
+			return
+		case <-written:
+		}
+	}
+}
+
+func (r *Reader) processRecord(handler HandlerFunc, record Record) {
+	scopedCtx, cancel := context.WithTimeout(r.ctxBase, r.options.handlerTimeout)
+	defer cancel()
+	if err := handler(scopedCtx, record); err != nil {
+		r.options.errorHandler(scopedCtx, err)
+	}
+}
+
+// Close stops the reader, waiting for in-flight records to be processed or `ctx` to be done.
+func (r *Reader) Close(ctx context.Context) error {
+	if !r.isClosed.CompareAndSwap(false, true) {
+		return ErrReaderClosed
+	}
+	if !r.alreadyStarted.Load() {
+		return nil
+	}
+	r.ctxCancelFunc()
+	done := make(chan struct{})
+	go func() {
+		r.inFlightProcs.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// -- Options --
+
+type readerOptions struct {
+	handlerTimeout time.Duration
+	errorHandler   func(context.Context, error)
+}
+
+// ReaderOption is a routine used to set up [Reader] optional configuration.
+type ReaderOption func(*readerOptions)
+
+// WithReaderHandlerTimeout sets the maximum time a handler can take to process a record. Defaults to 30 seconds.
+func WithReaderHandlerTimeout(timeout time.Duration) ReaderOption {
+	return func(o *readerOptions) {
+		o.handlerTimeout = timeout
+	}
+}
+
+// WithReaderErrorHandler sets the routine called when a handler returns an error.
+func WithReaderErrorHandler(handler func(context.Context, error)) ReaderOption {
+	return func(o *readerOptions) {
+		o.errorHandler = handler
+	}
+}
@@ -0,0 +1,28 @@
+package memstream
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// RequireRecords waits until `topic` holds at least `n` records, failing the test if `timeout` is reached.
+// Returns a snapshot of the records written into `topic`.
+func RequireRecords(t testing.TB, broker *Broker, topic string, n int, timeout time.Duration) []Record {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	records, err := broker.WaitFor(ctx, topic, n)
+	if err != nil {
+		t.Fatalf("memstream: expected at least %d records on topic %s, got %d: %v", n, topic, len(records), err)
+	}
+	return records
+}
+
+// RequireNoRecords fails the test if `topic` holds any record.
+func RequireNoRecords(t testing.TB, broker *Broker, topic string) {
+	t.Helper()
+	if records := broker.Records(topic); len(records) > 0 {
+		t.Fatalf("memstream: expected no records on topic %s, got %d", topic, len(records))
+	}
+}
@@ -0,0 +1,100 @@
+package stream
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"errors"
+	"time"
+
+	"github.com/samber/lo"
+
+	gecksql "github.com/bosonicalio/geck/persistence/sql"
+)
+
+// DedupMigrations contains the [goose] SQL migration scripts (Postgres dialect) creating the default table used
+// by [SQLDedupStore].
+//
+// Scripts are located under the `migration/dedup` directory, e.g.
+//
+//	sqltest.RunMigrations(ctx, "postgres", db, stream.DedupMigrations, "migration/dedup")
+//
+//go:embed migration/dedup/*.sql
+var DedupMigrations embed.FS
+
+const _defaultDedupTable = "processed_message"
+
+// SQLDedupStore is a [DedupStore] implementation persisting processed message keys into a SQL table.
+//
+// If a transaction is found in the context (see [gecksql.TxDriver] and
+// [github.com/bosonicalio/geck/persistence.ExecInTx]), keys are read and written using such transaction. Thus, a
+// processed key is recorded atomically with the rest of the work done by the message handler and discarded if
+// the transaction is rolled back.
+//
+// SQL statements are written using the Postgres dialect.
+type SQLDedupStore struct {
+	db    gecksql.DB
+	table string
+}
+
+// compile-time assertion
+var _ DedupStore = (*SQLDedupStore)(nil)
+
+// NewSQLDedupStore creates a new [SQLDedupStore] instance. `db` is wrapped using [gecksql.NewDBTxPropagator].
+func NewSQLDedupStore(db gecksql.DB, opts ...SQLDedupStoreOption) SQLDedupStore {
+	options := sqlDedupStoreOptions{}
+	for _, opt := range opts {
+		opt(&options)
+	}
+	return SQLDedupStore{
+		db:    gecksql.NewDBTxPropagator(db),
+		table: lo.CoalesceOrEmpty(options.table, _defaultDedupTable),
+	}
+}
+
+// IsProcessed indicates whether the message identified by `key` was already processed.
+func (s SQLDedupStore) IsProcessed(ctx context.Context, key string) (bool, error) {
+	var exists int
+	err := s.db.QueryRowContext(ctx, "SELECT 1 FROM "+s.table+" WHERE message_key = $1", key).Scan(&exists)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// MarkProcessed records the message identified by `key` as processed.
+//
+// Returns an error if the key was already recorded (e.g. another consumer processed the same message
+// concurrently), so the enclosing transaction gets rolled back.
+func (s SQLDedupStore) MarkProcessed(ctx context.Context, key string) error {
+	_, err := s.db.ExecContext(ctx, "INSERT INTO "+s.table+" (message_key, process_time) VALUES ($1, $2)",
+		key, time.Now().UTC())
+	return err
+}
+
+// Purge removes the keys processed before `t`, returning the number of removed keys.
+func (s SQLDedupStore) Purge(ctx context.Context, t time.Time) (int64, error) {
+	res, err := s.db.ExecContext(ctx, "DELETE FROM "+s.table+" WHERE process_time < $1", t.UTC())
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+// -- Options --
+
+type sqlDedupStoreOptions struct {
+	table string
+}
+
+// SQLDedupStoreOption is a routine used to set up [SQLDedupStore] optional configuration.
+type SQLDedupStoreOption func(*sqlDedupStoreOptions)
+
+// WithSQLDedupTable sets the name of the table used by a [SQLDedupStore].
+func WithSQLDedupTable(table string) SQLDedupStoreOption {
+	return func(o *sqlDedupStoreOptions) {
+		o.table = table
+	}
+}
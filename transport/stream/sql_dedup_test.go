@@ -0,0 +1,220 @@
+package stream_test
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"io/fs"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bosonicalio/geck/persistence"
+	gecksql "github.com/bosonicalio/geck/persistence/sql"
+	"github.com/bosonicalio/geck/transport/stream"
+)
+
+func TestDedupMigrations(t *testing.T) {
+	files, err := fs.Glob(stream.DedupMigrations, "migration/dedup/*.sql")
+	require.NoError(t, err)
+	assert.NotEmpty(t, files)
+}
+
+func TestSQLDedupStore(t *testing.T) {
+	ctx := context.Background()
+	db := newFakeDedupDB()
+	store := stream.NewSQLDedupStore(sql.OpenDB(db), stream.WithSQLDedupTable("consumed_message"))
+
+	processed, err := store.IsProcessed(ctx, "key-1")
+	require.NoError(t, err)
+	assert.False(t, processed)
+
+	require.NoError(t, store.MarkProcessed(ctx, "key-1"))
+	processed, err = store.IsProcessed(ctx, "key-1")
+	require.NoError(t, err)
+	assert.True(t, processed)
+	assert.Contains(t, db.lastStatement(), "FROM consumed_message")
+
+	// keys already recorded are rejected
+	assert.Error(t, store.MarkProcessed(ctx, "key-1"))
+
+	purged, err := store.Purge(ctx, time.Now().Add(time.Minute))
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), purged)
+	processed, err = store.IsProcessed(ctx, "key-1")
+	require.NoError(t, err)
+	assert.False(t, processed)
+}
+
+func TestSQLDedupStore_UnitOfWork(t *testing.T) {
+	ctx := context.Background()
+	db := newFakeDedupDB()
+	client := sql.OpenDB(db)
+	store := stream.NewSQLDedupStore(client)
+	factory := gecksql.NewTxFactory(client, nil)
+
+	errFn := errors.New("some error")
+	err := persistence.ExecInTx(ctx, factory, func(ctx context.Context) error {
+		require.NoError(t, store.MarkProcessed(ctx, "key-1"))
+		return errFn
+	})
+	require.ErrorIs(t, err, errFn)
+	processed, err := store.IsProcessed(ctx, "key-1")
+	require.NoError(t, err)
+	assert.False(t, processed)
+
+	err = persistence.ExecInTx(ctx, factory, func(ctx context.Context) error {
+		return store.MarkProcessed(ctx, "key-1")
+	})
+	require.NoError(t, err)
+	processed, err = store.IsProcessed(ctx, "key-1")
+	require.NoError(t, err)
+	assert.True(t, processed)
+}
+
+// -- Fake driver --
+
+// fakeDedupDB is a [driver.Connector] emulating the statements written by [stream.SQLDedupStore] in memory.
+// Writes done within transactions are applied on commit.
+type fakeDedupDB struct {
+	mu         sync.Mutex
+	keys       map[string]time.Time
+	statements []string
+}
+
+func newFakeDedupDB() *fakeDedupDB {
+	return &fakeDedupDB{keys: make(map[string]time.Time)}
+}
+
+func (d *fakeDedupDB) lastStatement() string {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.statements[len(d.statements)-1]
+}
+
+func (d *fakeDedupDB) Connect(_ context.Context) (driver.Conn, error) {
+	return &fakeDedupConn{db: d}, nil
+}
+
+func (d *fakeDedupDB) Driver() driver.Driver {
+	return nil
+}
+
+type fakeDedupConn struct {
+	db      *fakeDedupDB
+	inTx    bool
+	pending []func()
+}
+
+func (c *fakeDedupConn) Prepare(_ string) (driver.Stmt, error) {
+	return nil, errors.New("not supported")
+}
+
+func (c *fakeDedupConn) Close() error {
+	return nil
+}
+
+func (c *fakeDedupConn) Begin() (driver.Tx, error) {
+	c.inTx = true
+	return c, nil
+}
+
+func (c *fakeDedupConn) Commit() error {
+	defer c.Rollback()
+	c.db.mu.Lock()
+	defer c.db.mu.Unlock()
+	for _, fn := range c.pending {
+		fn()
+	}
+	return nil
+}
+
+func (c *fakeDedupConn) Rollback() error {
+	c.inTx = false
+	c.pending = nil
+	return nil
+}
+
+func (c *fakeDedupConn) ExecContext(_ context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	c.db.mu.Lock()
+	defer c.db.mu.Unlock()
+	c.db.statements = append(c.db.statements, query)
+	var (
+		apply        func()
+		rowsAffected int64
+	)
+	switch {
+	case strings.HasPrefix(query, "INSERT INTO"):
+		key := args[0].Value.(string)
+		if _, ok := c.db.keys[key]; ok {
+			return nil, errors.New("duplicate key: " + key)
+		}
+		apply = func() {
+			c.db.keys[key] = args[1].Value.(time.Time)
+		}
+		rowsAffected = 1
+	case strings.HasPrefix(query, "DELETE FROM"):
+		before := args[0].Value.(time.Time)
+		for _, processTime := range c.db.keys {
+			if processTime.Before(before) {
+				rowsAffected++
+			}
+		}
+		apply = func() {
+			for key, processTime := range c.db.keys {
+				if processTime.Before(before) {
+					delete(c.db.keys, key)
+				}
+			}
+		}
+	default:
+		return nil, errors.New("unexpected statement: " + query)
+	}
+	if c.inTx {
+		c.pending = append(c.pending, apply)
+	} else {
+		apply()
+	}
+	return driver.RowsAffected(rowsAffected), nil
+}
+
+func (c *fakeDedupConn) QueryContext(_ context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	c.db.mu.Lock()
+	defer c.db.mu.Unlock()
+	if !strings.HasPrefix(query, "SELECT 1 FROM") {
+		return nil, errors.New("unexpected query: " + query)
+	}
+	c.db.statements = append(c.db.statements, query)
+	rows := &fakeDedupRows{}
+	if _, ok := c.db.keys[args[0].Value.(string)]; ok {
+		rows.total = 1
+	}
+	return rows, nil
+}
+
+type fakeDedupRows struct {
+	total int
+}
+
+func (r *fakeDedupRows) Columns() []string {
+	return []string{"exists"}
+}
+
+func (r *fakeDedupRows) Close() error {
+	return nil
+}
+
+func (r *fakeDedupRows) Next(dest []driver.Value) error {
+	if r.total == 0 {
+		return io.EOF
+	}
+	r.total--
+	dest[0] = int64(1)
+	return nil
+}